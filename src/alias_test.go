@@ -0,0 +1,178 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/juls0730/passport/src/audit"
+)
+
+func TestParseAlias(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{raw: "", want: ""},
+		{raw: "  ", want: ""},
+		{raw: "gh", want: "gh"},
+		{raw: " GH ", want: "gh"},
+		{raw: "/gh/", want: "gh"},
+		{raw: "my-repo_2.0", want: "my-repo_2.0"},
+		{raw: strings.Repeat("a", 40), want: strings.Repeat("a", 40)},
+		{raw: strings.Repeat("a", 41), wantErr: true},
+		// has to start with a letter so /go/<id> still works
+		{raw: "42", wantErr: true},
+		{raw: "-gh", wantErr: true},
+		{raw: "g h", wantErr: true},
+		{raw: "gh/repo", wantErr: true},
+		{raw: "gh?q", wantErr: true},
+		{raw: "admin", wantErr: true},
+		{raw: "API", wantErr: true},
+		{raw: "favicon.ico", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.raw, func(t *testing.T) {
+			got, err := ParseAlias(test.raw)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ParseAlias(%q) error = %v, want error %v", test.raw, err, test.wantErr)
+			}
+
+			if got != test.want {
+				t.Errorf("ParseAlias(%q) = %q, want %q", test.raw, got, test.want)
+			}
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"gh", "gh", 0},
+		{"", "gh", 2},
+		{"gh", "", 2},
+		{"gh", "gl", 1},
+		{"gh", "ghe", 1},
+		{"ghe", "gh", 1},
+		{"gh", "hg", 2},
+		{"kitten", "sitting", 3},
+		{"jellyfin", "jelyfin", 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.a+"/"+test.b, func(t *testing.T) {
+			if got := editDistance(test.a, test.b); got != test.want {
+				t.Errorf("editDistance(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+			}
+		})
+	}
+}
+
+func TestAliasTarget(t *testing.T) {
+	tests := []struct {
+		name  string
+		url   string
+		rest  string
+		query string
+		want  string
+	}{
+		{name: "alias only", url: "https://github.com", want: "https://github.com"},
+		{name: "path", url: "https://github.com", rest: "juls0730/passport", want: "https://github.com/juls0730/passport"},
+		{name: "path onto a path", url: "https://github.com/juls0730", rest: "passport", want: "https://github.com/juls0730/passport"},
+		{name: "trailing slash", url: "https://github.com/", rest: "juls0730", want: "https://github.com/juls0730"},
+		{name: "query", url: "https://github.com/search", query: "q=passport", want: "https://github.com/search?q=passport"},
+		{name: "query onto a query", url: "https://example.com/?lang=en", query: "q=go", want: "https://example.com/?lang=en&q=go"},
+		{name: "path and query", url: "https://github.com", rest: "search", query: "q=passport", want: "https://github.com/search?q=passport"},
+		{name: "fragment kept", url: "https://example.com/docs#top", rest: "intro", want: "https://example.com/docs/intro#top"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := aliasTarget(test.url, test.rest, test.query); got != test.want {
+				t.Errorf("aliasTarget(%q, %q, %q) = %q, want %q", test.url, test.rest, test.query, got, test.want)
+			}
+		})
+	}
+}
+
+func TestAliasesStayUnique(t *testing.T) {
+	tests := []struct {
+		name string
+		// what of the first link with the alias is in the trash when the second one is created
+		trash string
+		// whether the second link is refused the alias
+		wantTaken bool
+	}{
+		{name: "in use", wantTaken: true},
+		{name: "link in the trash", trash: "link"},
+		{name: "category in the trash", trash: "category", wantTaken: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			manager := newTestManager(t)
+			board := manager.GetDefaultBoard()
+
+			category := createTestCategory(t, manager, board.ID, "Monitoring")
+			first := createTestLink(t, manager, Link{CategoryID: category.ID, Name: "Grafana", URL: "https://grafana.example", Alias: "grafana"})
+
+			switch test.trash {
+			case "link":
+				trashTestLink(t, manager, first)
+			case "category":
+				if err := manager.DeleteCategory(audit.Actor{}, category.ID); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			other := createTestCategory(t, manager, board.ID, "Other")
+			second, err := manager.CreateLink(manager.db, audit.Actor{}, Link{CategoryID: other.ID, Name: "New Grafana",
+				URL: "https://grafana2.example", Visibility: VisibilityPublic, Alias: "grafana"})
+
+			var taken *TakenError
+			if errors.As(err, &taken) != test.wantTaken {
+				t.Fatalf("CreateLink() = %v, want taken %v", err, test.wantTaken)
+			}
+
+			switch test.trash {
+			case "link":
+				if err := manager.RestoreLink(audit.Actor{}, first.ID); err != nil {
+					t.Fatal(err)
+				}
+			case "category":
+				if err := manager.RestoreCategory(audit.Actor{}, category.ID); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// the link restored from the trash gives way to the one that took its alias
+			want := first
+			if !test.wantTaken {
+				want = second
+				if restored := manager.GetLink(first.ID); restored == nil || restored.Alias != "" {
+					t.Errorf("restored link = %+v, want it without an alias", restored)
+				}
+			}
+
+			if got := manager.FindAlias("grafana", true); got == nil || got.ID != want.ID {
+				t.Errorf("FindAlias() = %+v, want link %d", got, want.ID)
+			}
+		})
+	}
+}
+
+func TestAliasIndexIsUnique(t *testing.T) {
+	manager := newTestManager(t)
+	category := createTestCategory(t, manager, manager.GetDefaultBoard().ID, "Monitoring")
+	createTestLink(t, manager, Link{CategoryID: category.ID, Name: "Grafana", URL: "https://grafana.example", Alias: "grafana"})
+	link := createTestLink(t, manager, Link{CategoryID: category.ID, Name: "Prometheus", URL: "https://prometheus.example"})
+
+	if _, err := manager.db.Exec(`UPDATE links SET alias = 'grafana' WHERE id = ?`, link.ID); err == nil {
+		t.Error("two links were given the same alias")
+	}
+}
@@ -0,0 +1,207 @@
+package audit
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+	ActionPurge   = "purge"
+	ActionReorder = "reorder"
+	ActionMove    = "move"
+)
+
+const (
+	EntityBoard          = "board"
+	EntityCategory       = "category"
+	EntityLink           = "link"
+	EntityBang           = "bang"
+	EntitySearchProvider = "search_provider"
+)
+
+// Actor is whoever made a change. SessionID is the id of their sessions row, or 0 if the change was not
+// made through the admin dashboard.
+type Actor struct {
+	SessionID int64
+	IP        string
+}
+
+type Entry struct {
+	ID         int64           `json:"id"`
+	SessionID  int64           `json:"session_id"`
+	IP         string          `json:"ip"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   int64           `json:"entity_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	CreatedAt  string          `json:"created_at"`
+}
+
+// BeforeText returns the state before the change as indented JSON, for showing in templates
+func (entry Entry) BeforeText() string {
+	return indent(entry.Before)
+}
+
+// AfterText returns the state after the change as indented JSON, for showing in templates
+func (entry Entry) AfterText() string {
+	return indent(entry.After)
+}
+
+func indent(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, raw, "", "  "); err != nil {
+		return string(raw)
+	}
+
+	return indented.String()
+}
+
+// Record writes an entry to the audit log. It takes the transaction the change is made in, so the entry
+// is only kept if the change is. before and after are stored as JSON, nil is stored as NULL.
+func Record(tx *sql.Tx, actor Actor, action string, entityType string, entityID int64, before any, after any) error {
+	beforeJSON, err := marshal(before)
+	if err != nil {
+		return err
+	}
+
+	afterJSON, err := marshal(after)
+	if err != nil {
+		return err
+	}
+
+	var sessionID sql.NullInt64
+	if actor.SessionID != 0 {
+		sessionID = sql.NullInt64{Int64: actor.SessionID, Valid: true}
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO audit_log (session_id, ip, action, entity_type, entity_id, before, after, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, sessionID, actor.IP, action, entityType, entityID, beforeJSON, afterJSON, time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+func marshal(value any) (sql.NullString, error) {
+	if value == nil {
+		return sql.NullString{}, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+// Filter narrows down the entries returned by List, zero values are ignored
+type Filter struct {
+	Action     string
+	EntityType string
+	EntityID   int64
+	SessionID  int64
+	IP         string
+	// Since and Until are RFC 3339 timestamps
+	Since string
+	Until string
+
+	Limit  int
+	Offset int
+}
+
+// List returns the entries matching the filter, newest first, along with how many entries match in total
+func List(db *sql.DB, filter Filter) ([]Entry, int, error) {
+	var conditions []string
+	var args []any
+
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, filter.Action)
+	}
+
+	if filter.EntityType != "" {
+		conditions = append(conditions, "entity_type = ?")
+		args = append(args, filter.EntityType)
+	}
+
+	if filter.EntityID != 0 {
+		conditions = append(conditions, "entity_id = ?")
+		args = append(args, filter.EntityID)
+	}
+
+	if filter.SessionID != 0 {
+		conditions = append(conditions, "session_id = ?")
+		args = append(args, filter.SessionID)
+	}
+
+	if filter.IP != "" {
+		conditions = append(conditions, "ip = ?")
+		args = append(args, filter.IP)
+	}
+
+	if filter.Since != "" {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.Since)
+	}
+
+	if filter.Until != "" {
+		conditions = append(conditions, "created_at <= ?")
+		args = append(args, filter.Until)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM audit_log `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := db.Query(`
+		SELECT id, COALESCE(session_id, 0), ip, action, entity_type, entity_id, before, after, created_at
+		FROM audit_log
+		`+where+`
+		ORDER BY id DESC
+		LIMIT ? OFFSET ?
+	`, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		var entry Entry
+		var before, after sql.NullString
+		if err := rows.Scan(&entry.ID, &entry.SessionID, &entry.IP, &entry.Action, &entry.EntityType, &entry.EntityID,
+			&before, &after, &entry.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+
+		if before.Valid {
+			entry.Before = json.RawMessage(before.String)
+		}
+
+		if after.Valid {
+			entry.After = json.RawMessage(after.String)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, total, rows.Err()
+}
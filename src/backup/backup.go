@@ -0,0 +1,242 @@
+// Package backup reads and writes backup archives, a gzipped tarball holding a snapshot of the database, the uploaded
+// icons and a manifest listing both
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// FormatVersion is bumped whenever the layout of the archive changes
+const FormatVersion = 1
+
+const (
+	ManifestFile = "manifest.json"
+	DatabaseFile = "passport.db"
+	UploadsDir   = "uploads"
+)
+
+var ErrInvalidArchive = errors.New("not a valid passport backup")
+
+type Manifest struct {
+	Format    int       `json:"format"`
+	CreatedAt time.Time `json:"created_at"`
+	// the migration version of the database in the archive
+	SchemaVersion int    `json:"schema_version"`
+	Files         []File `json:"files"`
+}
+
+type File struct {
+	// the path in the archive
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Write writes an archive of the database snapshot at dbPath and the named files from uploadsDir
+func Write(w io.Writer, schemaVersion int, dbPath string, uploadsDir string, uploads []string) error {
+	manifest := Manifest{
+		Format:        FormatVersion,
+		CreatedAt:     time.Now().UTC(),
+		SchemaVersion: schemaVersion,
+	}
+
+	// where each file in the archive is read from
+	paths := []string{DatabaseFile}
+	sources := map[string]string{DatabaseFile: dbPath}
+	for _, name := range uploads {
+		archivePath := path.Join(UploadsDir, name)
+		paths = append(paths, archivePath)
+		sources[archivePath] = filepath.Join(uploadsDir, name)
+	}
+
+	// the manifest goes first so restoring can reject an archive early, which means hashing everything up front
+	for _, archivePath := range paths {
+		size, sum, err := hashFile(sources[archivePath])
+		if err != nil {
+			return err
+		}
+
+		manifest.Files = append(manifest.Files, File{Path: archivePath, Size: size, SHA256: sum})
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(w)
+	archive := tar.NewWriter(gz)
+
+	if err := writeEntry(archive, ManifestFile, int64(len(manifestData)), bytes.NewReader(manifestData)); err != nil {
+		return err
+	}
+
+	for _, file := range manifest.Files {
+		src, err := os.Open(sources[file.Path])
+		if err != nil {
+			return err
+		}
+
+		err = writeEntry(archive, file.Path, file.Size, src)
+		src.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return err
+	}
+
+	return gz.Close()
+}
+
+func writeEntry(archive *tar.Writer, name string, size int64, r io.Reader) error {
+	err := archive.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    size,
+		ModTime: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.CopyN(archive, r, size)
+	return err
+}
+
+func hashFile(name string) (int64, string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Extract unpacks an archive into dir, which should be empty, and checks every file in it against the manifest. The
+// database ends up at dir/passport.db and the uploads in dir/uploads
+func Extract(r io.Reader, dir string) (*Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	defer gz.Close()
+
+	if err := os.MkdirAll(filepath.Join(dir, UploadsDir), 0755); err != nil {
+		return nil, err
+	}
+
+	var manifest *Manifest
+	extracted := map[string]File{}
+
+	archive := tar.NewReader(gz)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("%w: %s is not a regular file", ErrInvalidArchive, header.Name)
+		}
+
+		if header.Name == ManifestFile {
+			manifest = &Manifest{}
+			if err := json.NewDecoder(io.LimitReader(archive, 16*1024*1024)).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("%w: manifest is invalid: %v", ErrInvalidArchive, err)
+			}
+			continue
+		}
+
+		// only the database and flat files in uploads belong in an archive, which also keeps paths from escaping dir
+		dirName, fileName := path.Split(header.Name)
+		if header.Name != DatabaseFile && (dirName != UploadsDir+"/" || fileName == "" || fileName == "." || fileName == "..") {
+			return nil, fmt.Errorf("%w: unexpected file %s", ErrInvalidArchive, header.Name)
+		}
+
+		if _, ok := extracted[header.Name]; ok {
+			return nil, fmt.Errorf("%w: %s is in the archive twice", ErrInvalidArchive, header.Name)
+		}
+
+		file, err := extractFile(archive, filepath.Join(dir, filepath.FromSlash(header.Name)))
+		if err != nil {
+			return nil, err
+		}
+		file.Path = header.Name
+		extracted[header.Name] = file
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("%w: %s is missing", ErrInvalidArchive, ManifestFile)
+	}
+
+	if manifest.Format < 1 || manifest.Format > FormatVersion {
+		return nil, fmt.Errorf("%w: archive format %d is not supported", ErrInvalidArchive, manifest.Format)
+	}
+
+	if _, ok := extracted[DatabaseFile]; !ok {
+		return nil, fmt.Errorf("%w: %s is missing", ErrInvalidArchive, DatabaseFile)
+	}
+
+	listed := map[string]bool{}
+	for _, file := range manifest.Files {
+		listed[file.Path] = true
+
+		got, ok := extracted[file.Path]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is listed in the manifest but missing", ErrInvalidArchive, file.Path)
+		}
+
+		if got.Size != file.Size || got.SHA256 != file.SHA256 {
+			return nil, fmt.Errorf("%w: %s does not match the manifest", ErrInvalidArchive, file.Path)
+		}
+	}
+
+	for name := range extracted {
+		if !listed[name] {
+			return nil, fmt.Errorf("%w: %s is not listed in the manifest", ErrInvalidArchive, name)
+		}
+	}
+
+	return manifest, nil
+}
+
+func extractFile(r io.Reader, name string) (File, error) {
+	out, err := os.Create(name)
+	if err != nil {
+		return File{}, err
+	}
+	defer out.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(out, hash), r)
+	if err != nil {
+		return File{}, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	return File{Size: size, SHA256: hex.EncodeToString(hash.Sum(nil))}, nil
+}
@@ -0,0 +1,165 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type entry struct {
+	name     string
+	data     string
+	typeflag byte
+}
+
+// writeArchive writes the entries as an archive, after a manifest listing the regular files among them that edit can
+// change first. A nil edit leaves the manifest out
+func writeArchive(t *testing.T, entries []entry, edit func(manifest *Manifest)) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	archive := tar.NewWriter(gz)
+
+	write := func(header *tar.Header, data string) {
+		if err := archive.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := archive.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if edit != nil {
+		manifest := Manifest{Format: FormatVersion, SchemaVersion: 17}
+		for _, entry := range entries {
+			if entry.typeflag == tar.TypeReg {
+				sum := sha256.Sum256([]byte(entry.data))
+				manifest.Files = append(manifest.Files, File{Path: entry.name, Size: int64(len(entry.data)), SHA256: hex.EncodeToString(sum[:])})
+			}
+		}
+		edit(&manifest)
+
+		data, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		write(&tar.Header{Name: ManifestFile, Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg}, string(data))
+	}
+
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Mode: 0644, Typeflag: entry.typeflag}
+		if entry.typeflag == tar.TypeReg {
+			header.Size = int64(len(entry.data))
+		} else {
+			header.Linkname = "/etc/passwd"
+		}
+		write(header, entry.data)
+	}
+
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return &buf
+}
+
+func TestWriteCanBeExtracted(t *testing.T) {
+	src := t.TempDir()
+	dbPath := filepath.Join(src, "passport.db")
+	if err := os.WriteFile(dbPath, []byte("database"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	uploads := filepath.Join(src, "uploads")
+	if err := os.Mkdir(uploads, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(uploads, "icon.png"), []byte("icon"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, 17, dbPath, uploads, []string{"icon.png"}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	manifest, err := Extract(&buf, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if manifest.SchemaVersion != 17 || len(manifest.Files) != 2 {
+		t.Errorf("manifest = %+v, want schema version 17 and 2 files", manifest)
+	}
+
+	for name, want := range map[string]string{DatabaseFile: "database", "uploads/icon.png": "icon"} {
+		got, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestExtractRejects(t *testing.T) {
+	database := entry{name: DatabaseFile, data: "database", typeflag: tar.TypeReg}
+	icon := entry{name: "uploads/icon.png", data: "icon", typeflag: tar.TypeReg}
+	keep := func(manifest *Manifest) {}
+
+	tests := []struct {
+		name    string
+		entries []entry
+		edit    func(manifest *Manifest)
+	}{
+		{name: "parent directory", entries: []entry{database, {name: "../escaped", data: "x", typeflag: tar.TypeReg}}, edit: keep},
+		{name: "parent directory in uploads", entries: []entry{database, {name: "uploads/../../escaped", data: "x", typeflag: tar.TypeReg}}, edit: keep},
+		{name: "absolute path", entries: []entry{database, {name: "/tmp/escaped", data: "x", typeflag: tar.TypeReg}}, edit: keep},
+		{name: "nested in uploads", entries: []entry{database, {name: "uploads/icons/icon.png", data: "x", typeflag: tar.TypeReg}}, edit: keep},
+		{name: "unknown file", entries: []entry{database, {name: "config.yml", data: "x", typeflag: tar.TypeReg}}, edit: keep},
+		{name: "symlink", entries: []entry{database, {name: "uploads/icon.png", typeflag: tar.TypeSymlink}}, edit: keep},
+		{name: "file twice", entries: []entry{database, icon, icon}, edit: keep},
+		{name: "no manifest", entries: []entry{database, icon}},
+		{name: "no database", entries: []entry{icon}, edit: keep},
+		{name: "newer format", entries: []entry{database}, edit: func(manifest *Manifest) { manifest.Format = FormatVersion + 1 }},
+		{name: "changed file", entries: []entry{database, icon}, edit: func(manifest *Manifest) { manifest.Files[1].SHA256 = manifest.Files[0].SHA256 }},
+		{name: "wrong size", entries: []entry{database, icon}, edit: func(manifest *Manifest) { manifest.Files[1].Size++ }},
+		{name: "missing file", entries: []entry{database}, edit: func(manifest *Manifest) {
+			manifest.Files = append(manifest.Files, File{Path: "uploads/icon.png"})
+		}},
+		{name: "file not in the manifest", entries: []entry{database, icon}, edit: func(manifest *Manifest) {
+			manifest.Files = manifest.Files[:1]
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// extract into a directory of its own, so anything written next to it can be found
+			parent := t.TempDir()
+			dir := filepath.Join(parent, "restore")
+
+			_, err := Extract(writeArchive(t, test.entries, test.edit), dir)
+			if !errors.Is(err, ErrInvalidArchive) {
+				t.Fatalf("Extract() = %v, want %v", err, ErrInvalidArchive)
+			}
+
+			if _, err := os.Stat(filepath.Join(parent, "escaped")); err == nil {
+				t.Error("a file was written outside of the directory")
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+// Package bookmarks reads and writes the Netscape bookmark file format that every browser uses for exports
+package bookmarks
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+type Bookmark struct {
+	Title       string
+	URL         string
+	Description string
+	Tags        []string
+	// the favicon the browser had for the bookmark as a data URI, if any. Bookmarks read from another dashboard's
+	// configuration have a URL or the name of an icon instead
+	Icon string
+}
+
+type Folder struct {
+	// empty for bookmarks that are not in any folder
+	Name      string
+	Bookmarks []Bookmark
+	// browsers do not have folder icons, only other dashboards set this, the same way as a bookmark's icon
+	Icon string
+	// only used when writing, Parse flattens every folder
+	Folders []Folder
+}
+
+var ErrNotBookmarks = errors.New("file is not a bookmark export")
+
+// Parse reads a bookmark export. Nested folders are flattened, every folder that directly holds bookmarks is returned
+// once, with the bookmarks of folders that share its name merged in, in the order they first appear
+func Parse(r io.Reader) ([]Folder, error) {
+	tokenizer := html.NewTokenizer(r)
+
+	var folders []Folder
+	indexes := map[string]int{}
+
+	// the name of every open <dl>, and the last folder heading, which names the next <dl>
+	var stack []string
+	var heading string
+	var sawList bool
+
+	// the bookmark being read, it is added once the next one starts since its description follows the link
+	var current *Bookmark
+	var text strings.Builder
+	var readingHeading, readingTitle, readingDescription bool
+
+	add := func(folder string, bookmark Bookmark) {
+		i, ok := indexes[folder]
+		if !ok {
+			i = len(folders)
+			indexes[folder] = i
+			folders = append(folders, Folder{Name: folder})
+		}
+		folders[i].Bookmarks = append(folders[i].Bookmarks, bookmark)
+	}
+
+	// descriptions are not closed, they end wherever the next tag starts
+	endDescription := func() {
+		if readingDescription && current != nil {
+			current.Description = collapseSpace(text.String())
+		}
+		readingDescription = false
+	}
+
+	flush := func() {
+		endDescription()
+		if current != nil {
+			folder := ""
+			if len(stack) > 0 {
+				folder = stack[len(stack)-1]
+			}
+			add(folder, *current)
+			current = nil
+		}
+	}
+
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			if err := tokenizer.Err(); err != io.EOF {
+				return nil, err
+			}
+			break
+		}
+
+		token := tokenizer.Token()
+
+		switch tokenType {
+		case html.TextToken:
+			if readingHeading || readingTitle || readingDescription {
+				text.WriteString(token.Data)
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			switch token.Data {
+			case "h3":
+				flush()
+				readingHeading = true
+				text.Reset()
+			case "dl":
+				flush()
+				sawList = true
+				stack = append(stack, heading)
+				heading = ""
+			case "dt":
+				flush()
+			case "a":
+				flush()
+				bookmark := Bookmark{}
+				for _, attr := range token.Attr {
+					switch attr.Key {
+					case "href":
+						bookmark.URL = strings.TrimSpace(attr.Val)
+					case "icon":
+						bookmark.Icon = attr.Val
+					case "tags":
+						for _, tag := range strings.Split(attr.Val, ",") {
+							if tag = strings.TrimSpace(tag); tag != "" {
+								bookmark.Tags = append(bookmark.Tags, tag)
+							}
+						}
+					}
+				}
+				current = &bookmark
+				readingTitle = true
+				text.Reset()
+			case "dd":
+				endDescription()
+				if current != nil {
+					readingDescription = true
+					text.Reset()
+				}
+			default:
+				endDescription()
+			}
+		case html.EndTagToken:
+			switch token.Data {
+			case "h3":
+				if readingHeading {
+					heading = collapseSpace(text.String())
+				}
+				readingHeading = false
+			case "a":
+				if readingTitle && current != nil {
+					current.Title = collapseSpace(text.String())
+				}
+				readingTitle = false
+			case "dl":
+				flush()
+				if len(stack) > 0 {
+					stack = stack[:len(stack)-1]
+				}
+			}
+		}
+	}
+
+	flush()
+
+	if !sawList {
+		return nil, ErrNotBookmarks
+	}
+
+	return folders, nil
+}
+
+// Write writes folders as a bookmark export that any browser can import
+func Write(w io.Writer, folders []Folder) error {
+	buf := bufio.NewWriter(w)
+
+	buf.WriteString(`<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<!-- This is an automatically generated file.
+     It will be read and overwritten.
+     DO NOT EDIT! -->
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+`)
+
+	for _, folder := range folders {
+		writeFolder(buf, folder, 1)
+	}
+
+	buf.WriteString("</DL><p>\n")
+
+	return buf.Flush()
+}
+
+func writeFolder(buf *bufio.Writer, folder Folder, depth int) {
+	indent := strings.Repeat("    ", depth)
+
+	fmt.Fprintf(buf, "%s<DT><H3>%s</H3>\n%s<DL><p>\n", indent, html.EscapeString(folder.Name), indent)
+
+	for _, child := range folder.Folders {
+		writeFolder(buf, child, depth+1)
+	}
+
+	for _, bookmark := range folder.Bookmarks {
+		fmt.Fprintf(buf, "%s    <DT><A HREF=\"%s\"", indent, html.EscapeString(bookmark.URL))
+		if bookmark.Icon != "" {
+			fmt.Fprintf(buf, " ICON=\"%s\"", html.EscapeString(bookmark.Icon))
+		}
+		if len(bookmark.Tags) > 0 {
+			fmt.Fprintf(buf, " TAGS=\"%s\"", html.EscapeString(strings.Join(bookmark.Tags, ",")))
+		}
+		fmt.Fprintf(buf, ">%s</A>\n", html.EscapeString(bookmark.Title))
+
+		if bookmark.Description != "" {
+			fmt.Fprintf(buf, "%s    <DD>%s\n", indent, html.EscapeString(bookmark.Description))
+		}
+	}
+
+	fmt.Fprintf(buf, "%s</DL><p>\n", indent)
+}
+
+// EncodeIcon turns an icon into the data URI bookmark exports embed icons as
+func EncodeIcon(data []byte, contentType string) string {
+	return "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// DecodeIcon decodes the data URI of a bookmark icon
+func DecodeIcon(dataURI string) ([]byte, string, error) {
+	rest, ok := strings.CutPrefix(dataURI, "data:")
+	if !ok {
+		return nil, "", errors.New("icon is not a data URI")
+	}
+
+	header, data, ok := strings.Cut(rest, ",")
+	if !ok {
+		return nil, "", errors.New("icon is not a valid data URI")
+	}
+
+	contentType, params, _ := strings.Cut(header, ";")
+	contentType = strings.ToLower(contentType)
+
+	if strings.HasSuffix(params, "base64") {
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return decoded, contentType, nil
+	}
+
+	decoded, err := url.PathUnescape(data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return []byte(decoded), contentType, nil
+}
+
+// collapseSpace trims a string and replaces every run of whitespace in it with a single space
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,156 @@
+package bookmarks
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want []Folder
+		err  error
+	}{
+		{
+			name: "not bookmarks",
+			html: `<html><body><a href="https://example.com">Example</a></body></html>`,
+			err:  ErrNotBookmarks,
+		},
+		{
+			name: "empty export",
+			html: `<!DOCTYPE NETSCAPE-Bookmark-file-1><H1>Bookmarks</H1><DL><p></DL><p>`,
+		},
+		{
+			name: "outside of any folder",
+			html: `<DL><p>
+				<DT><A HREF=" https://example.com ">  Example
+					site </A>
+			</DL><p>`,
+			want: []Folder{{Bookmarks: []Bookmark{{Title: "Example site", URL: "https://example.com"}}}},
+		},
+		{
+			name: "description, tags and icon",
+			html: `<DL><p>
+				<DT><H3>Dev</H3>
+				<DL><p>
+					<DT><A HREF="https://go.dev" ICON="data:image/png;base64,AA==" TAGS="lang, ,go">Go</A>
+					<DD>The Go
+						website
+					<DT><A HREF="https://ziglang.org">Zig</A>
+				</DL><p>
+			</DL><p>`,
+			want: []Folder{{Name: "Dev", Bookmarks: []Bookmark{
+				{Title: "Go", URL: "https://go.dev", Description: "The Go website", Tags: []string{"lang", "go"}, Icon: "data:image/png;base64,AA=="},
+				{Title: "Zig", URL: "https://ziglang.org"},
+			}}},
+		},
+		{
+			name: "nested folders are flattened",
+			html: `<DL><p>
+				<DT><H3>Toolbar</H3>
+				<DL><p>
+					<DT><A HREF="https://a.example">A</A>
+					<DT><H3>Nested</H3>
+					<DL><p>
+						<DT><A HREF="https://b.example">B</A>
+					</DL><p>
+					<DT><A HREF="https://c.example">C</A>
+				</DL><p>
+			</DL><p>`,
+			want: []Folder{
+				{Name: "Toolbar", Bookmarks: []Bookmark{{Title: "A", URL: "https://a.example"}, {Title: "C", URL: "https://c.example"}}},
+				{Name: "Nested", Bookmarks: []Bookmark{{Title: "B", URL: "https://b.example"}}},
+			},
+		},
+		{
+			name: "folders with the same name are merged",
+			html: `<DL><p>
+				<DT><H3>Dev</H3>
+				<DL><p><DT><A HREF="https://a.example">A</A></DL><p>
+				<DT><H3>News</H3>
+				<DL><p><DT><A HREF="https://b.example">B</A></DL><p>
+				<DT><H3>Dev</H3>
+				<DL><p><DT><A HREF="https://c.example">C</A></DL><p>
+			</DL><p>`,
+			want: []Folder{
+				{Name: "Dev", Bookmarks: []Bookmark{{Title: "A", URL: "https://a.example"}, {Title: "C", URL: "https://c.example"}}},
+				{Name: "News", Bookmarks: []Bookmark{{Title: "B", URL: "https://b.example"}}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(test.html))
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Parse() error = %v, want %v", err, test.err)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestWriteCanBeParsed(t *testing.T) {
+	folders := []Folder{
+		{Name: "Dev", Bookmarks: []Bookmark{
+			{Title: "Go & friends", URL: "https://go.dev/?a=1&b=2", Description: `"quoted" <b>text</b>`, Tags: []string{"lang", "go"}},
+			{Title: "Zig", URL: "https://ziglang.org", Icon: EncodeIcon([]byte("<svg/>"), "image/svg+xml")},
+		}},
+		{Name: "News", Folders: []Folder{
+			{Name: "Tech", Bookmarks: []Bookmark{{Title: "Feed", URL: "https://feed.example"}}},
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, folders); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// folders that only hold other folders are left out when reading
+	want := []Folder{folders[0], folders[1].Folders[0]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse(Write()) = %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeIcon(t *testing.T) {
+	tests := []struct {
+		name        string
+		dataURI     string
+		data        string
+		contentType string
+		wantErr     bool
+	}{
+		{name: "base64", dataURI: EncodeIcon([]byte("icon"), "image/png"), data: "icon", contentType: "image/png"},
+		{name: "upper case type", dataURI: "data:IMAGE/PNG;base64,aWNvbg==", data: "icon", contentType: "image/png"},
+		{name: "escaped", dataURI: "data:image/svg+xml,%3Csvg%2F%3E", data: "<svg/>", contentType: "image/svg+xml"},
+		{name: "not a data URI", dataURI: "https://example.com/icon.png", wantErr: true},
+		{name: "no data", dataURI: "data:image/png;base64", wantErr: true},
+		{name: "bad base64", dataURI: "data:image/png;base64,!!!", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, contentType, err := DecodeIcon(test.dataURI)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("DecodeIcon(%q) error = %v, want error %v", test.dataURI, err, test.wantErr)
+			}
+
+			if string(data) != test.data || contentType != test.contentType {
+				t.Errorf("DecodeIcon(%q) = %q, %q, want %q, %q", test.dataURI, data, contentType, test.data, test.contentType)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+// Package dashboard reads and writes the YAML file boards, categories and links can be declared in, for running passport
+// with its contents kept in version control
+package dashboard
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type File struct {
+	Boards []Board `yaml:"boards"`
+	// the bangs of the search bar, leaving them out keeps the ones passport has and leaves them to the admin dashboard
+	Bangs []Bang `yaml:"bangs,omitempty"`
+	// the sites the search bar can search, leaving them out keeps the ones passport has and leaves them to the admin
+	// dashboard
+	SearchProviders []SearchProvider `yaml:"search_providers,omitempty"`
+}
+
+type Board struct {
+	Slug  string `yaml:"slug"`
+	Title string `yaml:"title"`
+	// the board served at /, the first board is the default if none is marked
+	Default    bool       `yaml:"default,omitempty"`
+	Categories []Category `yaml:"categories"`
+}
+
+type Category struct {
+	Name string `yaml:"name"`
+	// see ParseIcon for what an icon can be
+	Icon       string `yaml:"icon,omitempty"`
+	Sort       string `yaml:"sort,omitempty"`
+	Visibility string `yaml:"visibility,omitempty"`
+	Links      []Link `yaml:"links"`
+}
+
+type Link struct {
+	Name        string   `yaml:"name"`
+	URL         string   `yaml:"url"`
+	Description string   `yaml:"description,omitempty"`
+	Icon        string   `yaml:"icon,omitempty"`
+	Tags        []string `yaml:"tags,omitempty,flow"`
+	Pinned      bool     `yaml:"pinned,omitempty"`
+	Visibility  string   `yaml:"visibility,omitempty"`
+	// typed into the search bar to open the link, or to search it through the search URL, where %s is the search terms
+	Keyword   string `yaml:"keyword,omitempty"`
+	SearchURL string `yaml:"search_url,omitempty"`
+	// the go link, /go/<alias> or /<alias>, that redirects to the link
+	Alias string `yaml:"alias,omitempty"`
+}
+
+type Bang struct {
+	// without the !
+	Bang string `yaml:"bang"`
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+}
+
+type SearchProvider struct {
+	Name string `yaml:"name"`
+	// without the @
+	Shortcut string `yaml:"shortcut"`
+	URL      string `yaml:"url"`
+	// where suggestions for what is being typed come from, in the OpenSearch suggestions format
+	SuggestURL string `yaml:"suggest_url,omitempty"`
+	// see ParseIcon for what an icon can be, the site's favicon is used when there is none
+	Icon string `yaml:"icon,omitempty"`
+	// the provider picked until another one is, the first provider is the default if none is marked
+	Default bool `yaml:"default,omitempty"`
+}
+
+// Load reads and checks the dashboard file at path
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(bytes.NewReader(data))
+}
+
+// Parse reads a dashboard file and checks that it is complete, fields that are not part of the format are an error so
+// typos dont go unnoticed
+func Parse(r io.Reader) (*File, error) {
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+
+	var file File
+	if err := decoder.Decode(&file); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("dashboard file is empty")
+		}
+		return nil, err
+	}
+
+	if err := file.validate(); err != nil {
+		return nil, err
+	}
+
+	return &file, nil
+}
+
+// validate checks what the file needs to make sense on its own, the values themselves are checked when they are applied
+func (file *File) validate() error {
+	if len(file.Boards) == 0 {
+		return errors.New("dashboard file has no boards")
+	}
+
+	slugs := map[string]bool{}
+	defaults := 0
+	for i, board := range file.Boards {
+		if board.Slug == "" || board.Title == "" {
+			return fmt.Errorf("board %d needs a slug and a title", i+1)
+		}
+
+		if slugs[board.Slug] {
+			return fmt.Errorf("board %q is declared twice", board.Slug)
+		}
+		slugs[board.Slug] = true
+
+		if board.Default {
+			defaults++
+		}
+
+		names := map[string]bool{}
+		for j, category := range board.Categories {
+			if category.Name == "" {
+				return fmt.Errorf("board %q: category %d needs a name", board.Slug, j+1)
+			}
+
+			// categories are told apart by name, so two with the same name could not be matched up again
+			if names[category.Name] {
+				return fmt.Errorf("board %q: category %q is declared twice", board.Slug, category.Name)
+			}
+			names[category.Name] = true
+
+			for k, link := range category.Links {
+				if link.Name == "" || link.URL == "" {
+					return fmt.Errorf("board %q, category %q: link %d needs a name and a url", board.Slug, category.Name, k+1)
+				}
+			}
+		}
+	}
+
+	if defaults > 1 {
+		return errors.New("only one board can be the default")
+	}
+
+	bangs := map[string]bool{}
+	for i, bang := range file.Bangs {
+		if bang.Bang == "" || bang.Name == "" || bang.URL == "" {
+			return fmt.Errorf("bang %d needs a bang, a name and a url", i+1)
+		}
+
+		if bangs[bang.Bang] {
+			return fmt.Errorf("bang %q is declared twice", bang.Bang)
+		}
+		bangs[bang.Bang] = true
+	}
+
+	shortcuts := map[string]bool{}
+	providerDefaults := 0
+	for i, provider := range file.SearchProviders {
+		if provider.Name == "" || provider.Shortcut == "" || provider.URL == "" {
+			return fmt.Errorf("search provider %d needs a name, a shortcut and a url", i+1)
+		}
+
+		if shortcuts[provider.Shortcut] {
+			return fmt.Errorf("search provider %q is declared twice", provider.Shortcut)
+		}
+		shortcuts[provider.Shortcut] = true
+
+		if provider.Default {
+			providerDefaults++
+		}
+	}
+
+	if providerDefaults > 1 {
+		return errors.New("only one search provider can be the default")
+	}
+
+	if defaults == 0 {
+		file.Boards[0].Default = true
+	}
+
+	if providerDefaults == 0 && len(file.SearchProviders) > 0 {
+		file.SearchProviders[0].Default = true
+	}
+
+	return nil
+}
+
+// Write writes a dashboard file
+func Write(w io.Writer, file *File) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(file); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// where an icon comes from
+const (
+	IconNone = iota
+	// an image file, relative to the dashboard file
+	IconPath
+	// an image on the web
+	IconURL
+	// the name of an icon in the dashboard icons collection, such as jellyfin
+	IconName
+)
+
+// ParseIcon works out what kind of icon an icon field holds. Anything that looks like a file, because it has a slash or
+// an extension, is a path, addresses are URLs and everything else is the name of an icon
+func ParseIcon(icon string) (int, string) {
+	icon = strings.TrimSpace(icon)
+
+	switch {
+	case icon == "":
+		return IconNone, ""
+	case strings.HasPrefix(icon, "http://") || strings.HasPrefix(icon, "https://"):
+		return IconURL, icon
+	case strings.ContainsAny(icon, `/\.`):
+		return IconPath, icon
+	default:
+		return IconName, strings.ToLower(icon)
+	}
+}
@@ -0,0 +1,667 @@
+package dashboard
+
+import (
+	"fmt"
+	"slices"
+)
+
+// the records are what passport has in its database, in the terms of the dashboard file
+
+type BoardRecord struct {
+	ID      int64
+	Slug    string
+	Title   string
+	Default bool
+}
+
+type CategoryRecord struct {
+	ID         int64
+	BoardID    int64
+	Name       string
+	Icon       string
+	IconSource string
+	Position   int64
+	Sort       string
+	Visibility string
+	Deleted    bool
+}
+
+type LinkRecord struct {
+	ID             int64
+	CategoryID     int64
+	Name           string
+	Description    string
+	URL            string
+	Icon           string
+	IconSource     string
+	Position       int64
+	Tags           []string
+	Pinned         bool
+	PinnedPosition int64
+	Visibility     string
+	Keyword        string
+	SearchURL      string
+	Alias          string
+	Deleted        bool
+}
+
+type BangRecord struct {
+	ID   int64
+	Bang string
+	Name string
+	URL  string
+}
+
+type SearchProviderRecord struct {
+	ID         int64
+	Name       string
+	Shortcut   string
+	URL        string
+	SuggestURL string
+	Icon       string
+	IconSource string
+	Default    bool
+}
+
+// State is everything a dashboard file is applied to, categories and links in the trash included since declaring them
+// again takes them back out of it. Categories and links are in their manual order
+type State struct {
+	Boards          []BoardRecord
+	Categories      []CategoryRecord
+	Links           []LinkRecord
+	Bangs           []BangRecord
+	SearchProviders []SearchProviderRecord
+}
+
+// Plan is what has to change for the database to match a dashboard file. Nothing the file leaves out is deleted for
+// good: categories and links go to the trash, and the categories of boards that are left out go to the trash of the
+// default board
+type Plan struct {
+	Boards []*BoardPlan
+	// boards the file no longer declares
+	RemovedBoards []BoardRecord
+	// categories and links the file no longer declares, on boards it still declares. Those already in the trash stay
+	// there as they are
+	TrashedCategories []CategoryRecord
+	TrashedLinks      []LinkRecord
+	// whether the board the file makes the default is not the default yet
+	DefaultChanged bool
+
+	// bangs and search providers are only planned when the file declares them
+	Bangs                  []*BangPlan
+	RemovedBangs           []BangRecord
+	SearchProviders        []*SearchProviderPlan
+	RemovedSearchProviders []SearchProviderRecord
+}
+
+// a board as the file declares it, Existing is nil for a board that has to be created
+
+type BoardPlan struct {
+	Board      BoardRecord
+	Existing   *BoardRecord
+	Categories []*CategoryPlan
+}
+
+// the Icon and IconSource of categories, links and search providers are left for whoever applies the plan to work out
+// from DeclaredIcon, since that means downloading them
+
+type CategoryPlan struct {
+	Category     CategoryRecord
+	Existing     *CategoryRecord
+	DeclaredIcon string
+	Links        []*LinkPlan
+}
+
+type LinkPlan struct {
+	Link         LinkRecord
+	Existing     *LinkRecord
+	DeclaredIcon string
+}
+
+type BangPlan struct {
+	Bang     BangRecord
+	Existing *BangRecord
+}
+
+type SearchProviderPlan struct {
+	Provider     SearchProviderRecord
+	Existing     *SearchProviderRecord
+	DeclaredIcon string
+}
+
+// NewPlan works out how to make state match file, which has to be checked and have its defaults filled in already.
+// Boards are matched up by slug, categories by name within their board and links by URL within their category, or by
+// name when their URL changed, so they keep their IDs and with that their usage statistics. Bangs are matched up by
+// bang and search providers by shortcut
+func NewPlan(file *File, state *State) *Plan {
+	plan := &Plan{}
+
+	boards := map[string]*BoardRecord{}
+	for i := range state.Boards {
+		boards[state.Boards[i].Slug] = &state.Boards[i]
+	}
+
+	categories := map[int64][]*CategoryRecord{}
+	for i := range state.Categories {
+		category := &state.Categories[i]
+		categories[category.BoardID] = append(categories[category.BoardID], category)
+	}
+
+	links := map[int64][]*LinkRecord{}
+	for i := range state.Links {
+		link := &state.Links[i]
+		links[link.CategoryID] = append(links[link.CategoryID], link)
+	}
+
+	// what is in the trash is only matched up when nothing else is, so a sync never takes something out of the trash in
+	// place of the one that is in use
+	for _, boardCategories := range categories {
+		slices.SortStableFunc(boardCategories, func(a, b *CategoryRecord) int { return compareDeleted(a.Deleted, b.Deleted) })
+	}
+	for _, categoryLinks := range links {
+		slices.SortStableFunc(categoryLinks, func(a, b *LinkRecord) int { return compareDeleted(a.Deleted, b.Deleted) })
+	}
+
+	matchedCategories := map[int64]bool{}
+	matchedLinks := map[int64]bool{}
+	declaredSlugs := map[string]bool{}
+
+	for _, declaredBoard := range file.Boards {
+		declaredSlugs[declaredBoard.Slug] = true
+
+		boardPlan := &BoardPlan{
+			Board:    BoardRecord{Slug: declaredBoard.Slug, Title: declaredBoard.Title, Default: declaredBoard.Default},
+			Existing: boards[declaredBoard.Slug],
+		}
+
+		var existingCategories []*CategoryRecord
+		if boardPlan.Existing != nil {
+			boardPlan.Board.ID = boardPlan.Existing.ID
+			existingCategories = categories[boardPlan.Existing.ID]
+
+			if declaredBoard.Default && !boardPlan.Existing.Default {
+				plan.DefaultChanged = true
+			}
+		} else if declaredBoard.Default {
+			plan.DefaultChanged = true
+		}
+
+		var pinnedPosition int64
+		for position, declared := range declaredBoard.Categories {
+			categoryPlan := &CategoryPlan{
+				Category: CategoryRecord{
+					BoardID:    boardPlan.Board.ID,
+					Name:       declared.Name,
+					Position:   int64(position),
+					Sort:       declared.Sort,
+					Visibility: declared.Visibility,
+				},
+				DeclaredIcon: declared.Icon,
+			}
+
+			for _, existing := range existingCategories {
+				if !matchedCategories[existing.ID] && existing.Name == declared.Name {
+					matchedCategories[existing.ID] = true
+					categoryPlan.Existing = existing
+					categoryPlan.Category.ID = existing.ID
+					break
+				}
+			}
+
+			var existingLinks []*LinkRecord
+			if categoryPlan.Existing != nil {
+				existingLinks = links[categoryPlan.Existing.ID]
+			}
+
+			for linkPosition, declaredLink := range declared.Links {
+				linkPlan := &LinkPlan{
+					Link: LinkRecord{
+						CategoryID:  categoryPlan.Category.ID,
+						Name:        declaredLink.Name,
+						Description: declaredLink.Description,
+						URL:         declaredLink.URL,
+						Position:    int64(linkPosition),
+						Tags:        declaredLink.Tags,
+						Pinned:      declaredLink.Pinned,
+						Visibility:  declaredLink.Visibility,
+						Keyword:     declaredLink.Keyword,
+						SearchURL:   declaredLink.SearchURL,
+						Alias:       declaredLink.Alias,
+					},
+					DeclaredIcon: declaredLink.Icon,
+				}
+
+				if linkPlan.Link.Tags == nil {
+					linkPlan.Link.Tags = []string{}
+				}
+
+				// favorites are ordered the way they appear in the file
+				if declaredLink.Pinned {
+					linkPlan.Link.PinnedPosition = pinnedPosition
+					pinnedPosition++
+				}
+
+				for _, match := range []func(*LinkRecord) bool{
+					func(existing *LinkRecord) bool { return existing.URL == declaredLink.URL },
+					func(existing *LinkRecord) bool { return existing.Name == declaredLink.Name },
+				} {
+					for _, existing := range existingLinks {
+						if !matchedLinks[existing.ID] && match(existing) {
+							matchedLinks[existing.ID] = true
+							linkPlan.Existing = existing
+							linkPlan.Link.ID = existing.ID
+							break
+						}
+					}
+
+					if linkPlan.Existing != nil {
+						break
+					}
+				}
+
+				categoryPlan.Links = append(categoryPlan.Links, linkPlan)
+			}
+
+			// links that are no longer declared go to the trash, unless their whole category does
+			if categoryPlan.Existing != nil {
+				for _, existing := range existingLinks {
+					if !matchedLinks[existing.ID] && !existing.Deleted {
+						plan.TrashedLinks = append(plan.TrashedLinks, *existing)
+					}
+				}
+			}
+
+			boardPlan.Categories = append(boardPlan.Categories, categoryPlan)
+		}
+
+		for _, existing := range existingCategories {
+			if !matchedCategories[existing.ID] && !existing.Deleted {
+				plan.TrashedCategories = append(plan.TrashedCategories, *existing)
+			}
+		}
+
+		plan.Boards = append(plan.Boards, boardPlan)
+	}
+
+	for _, board := range state.Boards {
+		if !declaredSlugs[board.Slug] {
+			plan.RemovedBoards = append(plan.RemovedBoards, board)
+		}
+	}
+
+	if file.Bangs != nil {
+		existing := map[string]*BangRecord{}
+		for i := range state.Bangs {
+			existing[state.Bangs[i].Bang] = &state.Bangs[i]
+		}
+
+		declaredBangs := map[string]bool{}
+		for _, declared := range file.Bangs {
+			declaredBangs[declared.Bang] = true
+
+			bangPlan := &BangPlan{
+				Bang:     BangRecord{Bang: declared.Bang, Name: declared.Name, URL: declared.URL},
+				Existing: existing[declared.Bang],
+			}
+			if bangPlan.Existing != nil {
+				bangPlan.Bang.ID = bangPlan.Existing.ID
+			}
+
+			plan.Bangs = append(plan.Bangs, bangPlan)
+		}
+
+		for _, bang := range state.Bangs {
+			if !declaredBangs[bang.Bang] {
+				plan.RemovedBangs = append(plan.RemovedBangs, bang)
+			}
+		}
+	}
+
+	if file.SearchProviders != nil {
+		existing := map[string]*SearchProviderRecord{}
+		for i := range state.SearchProviders {
+			existing[state.SearchProviders[i].Shortcut] = &state.SearchProviders[i]
+		}
+
+		declaredShortcuts := map[string]bool{}
+		for _, declared := range file.SearchProviders {
+			declaredShortcuts[declared.Shortcut] = true
+
+			providerPlan := &SearchProviderPlan{
+				Provider: SearchProviderRecord{
+					Name:       declared.Name,
+					Shortcut:   declared.Shortcut,
+					URL:        declared.URL,
+					SuggestURL: declared.SuggestURL,
+					Default:    declared.Default,
+				},
+				Existing:     existing[declared.Shortcut],
+				DeclaredIcon: declared.Icon,
+			}
+			if providerPlan.Existing != nil {
+				providerPlan.Provider.ID = providerPlan.Existing.ID
+			}
+
+			plan.SearchProviders = append(plan.SearchProviders, providerPlan)
+		}
+
+		for _, provider := range state.SearchProviders {
+			if !declaredShortcuts[provider.Shortcut] {
+				plan.RemovedSearchProviders = append(plan.RemovedSearchProviders, provider)
+			}
+		}
+	}
+
+	return plan
+}
+
+func compareDeleted(a bool, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	}
+	return -1
+}
+
+// Changed reports whether an existing board has to be updated
+func (plan *BoardPlan) Changed() bool {
+	return plan.Existing != nil && plan.Existing.Title != plan.Board.Title
+}
+
+// Changed reports whether an existing category has to be updated, taking it out of the trash counts as a change
+func (plan *CategoryPlan) Changed() bool {
+	existing, category := plan.Existing, plan.Category
+	return existing != nil && (existing.Name != category.Name || existing.Icon != category.Icon ||
+		existing.IconSource != category.IconSource || existing.Position != category.Position ||
+		existing.Sort != category.Sort || existing.Visibility != category.Visibility || existing.Deleted)
+}
+
+// Changed reports whether an existing link has to be updated, taking it out of the trash counts as a change
+func (plan *LinkPlan) Changed() bool {
+	existing, link := plan.Existing, plan.Link
+	return existing != nil && (existing.CategoryID != link.CategoryID || existing.Name != link.Name ||
+		existing.Description != link.Description || existing.URL != link.URL || existing.Icon != link.Icon ||
+		existing.IconSource != link.IconSource || existing.Position != link.Position ||
+		!slices.Equal(existing.Tags, link.Tags) || existing.Pinned != link.Pinned ||
+		existing.PinnedPosition != link.PinnedPosition || existing.Visibility != link.Visibility ||
+		existing.Keyword != link.Keyword || existing.SearchURL != link.SearchURL || existing.Alias != link.Alias ||
+		existing.Deleted)
+}
+
+// Changed reports whether an existing bang has to be updated
+func (plan *BangPlan) Changed() bool {
+	return plan.Existing != nil && *plan.Existing != plan.Bang
+}
+
+// Changed reports whether an existing search provider has to be updated
+func (plan *SearchProviderPlan) Changed() bool {
+	return plan.Existing != nil && *plan.Existing != plan.Provider
+}
+
+// Describe lists every change the plan makes, one line each, so a sync can be checked before or after it is applied.
+// Icons count as changed once they have been worked out
+func (plan *Plan) Describe() []string {
+	var changes []string
+
+	for _, category := range plan.TrashedCategories {
+		changes = append(changes, fmt.Sprintf("move category %q to the trash", category.Name))
+	}
+
+	for _, link := range plan.TrashedLinks {
+		changes = append(changes, fmt.Sprintf("move link %q to the trash", link.Name))
+	}
+
+	for _, board := range plan.Boards {
+		switch {
+		case board.Existing == nil:
+			changes = append(changes, fmt.Sprintf("create board %q", board.Board.Slug))
+		case board.Changed():
+			changes = append(changes, fmt.Sprintf("update board %q", board.Board.Slug))
+		}
+
+		for _, category := range board.Categories {
+			where := fmt.Sprintf("%s / %s", board.Board.Slug, category.Category.Name)
+
+			switch {
+			case category.Existing == nil:
+				changes = append(changes, fmt.Sprintf("create category %q", where))
+			case category.Existing.Deleted:
+				changes = append(changes, fmt.Sprintf("restore category %q from the trash", where))
+			case category.Changed():
+				changes = append(changes, fmt.Sprintf("update category %q", where))
+			}
+
+			for _, link := range category.Links {
+				switch {
+				case link.Existing == nil:
+					changes = append(changes, fmt.Sprintf("create link %q in %q", link.Link.Name, where))
+				case link.Existing.Deleted:
+					changes = append(changes, fmt.Sprintf("restore link %q in %q from the trash", link.Link.Name, where))
+				case link.Changed():
+					changes = append(changes, fmt.Sprintf("update link %q in %q", link.Link.Name, where))
+				}
+			}
+		}
+
+		if plan.DefaultChanged && board.Board.Default {
+			changes = append(changes, fmt.Sprintf("make board %q the default", board.Board.Slug))
+		}
+	}
+
+	for _, board := range plan.RemovedBoards {
+		changes = append(changes, fmt.Sprintf("delete board %q and move its categories to the trash", board.Slug))
+	}
+
+	for _, bang := range plan.RemovedBangs {
+		changes = append(changes, fmt.Sprintf("delete bang !%s", bang.Bang))
+	}
+
+	for _, bang := range plan.Bangs {
+		switch {
+		case bang.Existing == nil:
+			changes = append(changes, fmt.Sprintf("create bang !%s", bang.Bang.Bang))
+		case bang.Changed():
+			changes = append(changes, fmt.Sprintf("update bang !%s", bang.Bang.Bang))
+		}
+	}
+
+	for _, provider := range plan.RemovedSearchProviders {
+		changes = append(changes, fmt.Sprintf("delete search provider @%s", provider.Shortcut))
+	}
+
+	for _, provider := range plan.SearchProviders {
+		switch {
+		case provider.Existing == nil:
+			changes = append(changes, fmt.Sprintf("create search provider @%s", provider.Provider.Shortcut))
+		case provider.Changed():
+			changes = append(changes, fmt.Sprintf("update search provider @%s", provider.Provider.Shortcut))
+		}
+	}
+
+	return changes
+}
+
+// Store makes the changes of a plan, all of them should be part of one transaction so a sync that fails leaves
+// everything as it was
+type Store interface {
+	CreateBoard(board BoardRecord) (int64, error)
+	UpdateBoard(before BoardRecord, after BoardRecord) error
+	SetDefaultBoard(id int64) error
+	// deletes a board that is not the default after moving its categories to the trash of the default board
+	RemoveBoard(board BoardRecord) error
+
+	CreateCategory(category CategoryRecord) (int64, error)
+	UpdateCategory(before CategoryRecord, after CategoryRecord) error
+	TrashCategory(category CategoryRecord) error
+
+	CreateLink(link LinkRecord) (int64, error)
+	UpdateLink(before LinkRecord, after LinkRecord) error
+	TrashLink(link LinkRecord) error
+
+	CreateBang(bang BangRecord) (int64, error)
+	UpdateBang(before BangRecord, after BangRecord) error
+	DeleteBang(bang BangRecord) error
+
+	CreateSearchProvider(provider SearchProviderRecord) (int64, error)
+	UpdateSearchProvider(before SearchProviderRecord, after SearchProviderRecord) error
+	DeleteSearchProvider(provider SearchProviderRecord) error
+}
+
+// Apply makes the changes of the plan through store, once the icons have been worked out, and returns how many were
+// made
+func (plan *Plan) Apply(store Store) (int, error) {
+	changes := 0
+
+	// what is no longer declared goes first, so it does not stand in the way of what replaces it
+	for _, category := range plan.TrashedCategories {
+		if err := store.TrashCategory(category); err != nil {
+			return 0, err
+		}
+		changes++
+	}
+
+	for _, link := range plan.TrashedLinks {
+		if err := store.TrashLink(link); err != nil {
+			return 0, err
+		}
+		changes++
+	}
+
+	var defaultBoard int64
+	for _, boardPlan := range plan.Boards {
+		switch {
+		case boardPlan.Existing == nil:
+			id, err := store.CreateBoard(boardPlan.Board)
+			if err != nil {
+				return 0, err
+			}
+			boardPlan.Board.ID = id
+			changes++
+		case boardPlan.Changed():
+			if err := store.UpdateBoard(*boardPlan.Existing, boardPlan.Board); err != nil {
+				return 0, err
+			}
+			changes++
+		}
+
+		if boardPlan.Board.Default {
+			defaultBoard = boardPlan.Board.ID
+		}
+
+		for _, categoryPlan := range boardPlan.Categories {
+			categoryPlan.Category.BoardID = boardPlan.Board.ID
+
+			switch {
+			case categoryPlan.Existing == nil:
+				id, err := store.CreateCategory(categoryPlan.Category)
+				if err != nil {
+					return 0, err
+				}
+				categoryPlan.Category.ID = id
+				changes++
+			case categoryPlan.Changed():
+				if err := store.UpdateCategory(*categoryPlan.Existing, categoryPlan.Category); err != nil {
+					return 0, err
+				}
+				changes++
+			}
+
+			for _, linkPlan := range categoryPlan.Links {
+				linkPlan.Link.CategoryID = categoryPlan.Category.ID
+
+				switch {
+				case linkPlan.Existing == nil:
+					id, err := store.CreateLink(linkPlan.Link)
+					if err != nil {
+						return 0, err
+					}
+					linkPlan.Link.ID = id
+					changes++
+				case linkPlan.Changed():
+					if err := store.UpdateLink(*linkPlan.Existing, linkPlan.Link); err != nil {
+						return 0, err
+					}
+					changes++
+				}
+			}
+		}
+	}
+
+	// the categories of removed boards are moved to the default board, so it has to be the new one by then
+	if plan.DefaultChanged {
+		if err := store.SetDefaultBoard(defaultBoard); err != nil {
+			return 0, err
+		}
+	}
+
+	for _, board := range plan.RemovedBoards {
+		if err := store.RemoveBoard(board); err != nil {
+			return 0, err
+		}
+		changes++
+	}
+
+	for _, bang := range plan.RemovedBangs {
+		if err := store.DeleteBang(bang); err != nil {
+			return 0, err
+		}
+		changes++
+	}
+
+	for _, bangPlan := range plan.Bangs {
+		switch {
+		case bangPlan.Existing == nil:
+			id, err := store.CreateBang(bangPlan.Bang)
+			if err != nil {
+				return 0, err
+			}
+			bangPlan.Bang.ID = id
+			changes++
+		case bangPlan.Changed():
+			if err := store.UpdateBang(*bangPlan.Existing, bangPlan.Bang); err != nil {
+				return 0, err
+			}
+			changes++
+		}
+	}
+
+	for _, provider := range plan.RemovedSearchProviders {
+		if err := store.DeleteSearchProvider(provider); err != nil {
+			return 0, err
+		}
+		changes++
+	}
+
+	// only one search provider can be the default at a time, so the new default goes last, once the old one is cleared
+	providers := slices.Clone(plan.SearchProviders)
+	slices.SortStableFunc(providers, func(a, b *SearchProviderPlan) int {
+		switch {
+		case a.Provider.Default == b.Provider.Default:
+			return 0
+		case a.Provider.Default:
+			return 1
+		}
+		return -1
+	})
+
+	for _, providerPlan := range providers {
+		switch {
+		case providerPlan.Existing == nil:
+			id, err := store.CreateSearchProvider(providerPlan.Provider)
+			if err != nil {
+				return 0, err
+			}
+			providerPlan.Provider.ID = id
+			changes++
+		case providerPlan.Changed():
+			if err := store.UpdateSearchProvider(*providerPlan.Existing, providerPlan.Provider); err != nil {
+				return 0, err
+			}
+			changes++
+		}
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,416 @@
+package dashboard
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func testState() *State {
+	return &State{
+		Boards: []BoardRecord{
+			{ID: 1, Slug: "home", Title: "Home", Default: true},
+			{ID: 2, Slug: "work", Title: "Work"},
+		},
+		Categories: []CategoryRecord{
+			{ID: 10, BoardID: 1, Name: "Dev", Position: 0, Sort: "manual", Visibility: "public"},
+			{ID: 11, BoardID: 1, Name: "News", Position: 1, Sort: "manual", Visibility: "public"},
+			{ID: 12, BoardID: 1, Name: "Old", Position: 2, Sort: "manual", Visibility: "public", Deleted: true},
+			{ID: 20, BoardID: 2, Name: "Jira", Position: 0, Sort: "manual", Visibility: "public"},
+		},
+		Links: []LinkRecord{
+			{ID: 100, CategoryID: 10, Name: "Go", URL: "https://go.dev", Position: 0, Tags: []string{}, Visibility: "public"},
+			{ID: 101, CategoryID: 10, Name: "Rust", URL: "https://rust-lang.org", Position: 1, Tags: []string{}, Visibility: "public"},
+			{ID: 102, CategoryID: 10, Name: "Zig", URL: "https://ziglang.org", Position: 2, Tags: []string{}, Visibility: "public", Deleted: true},
+		},
+		Bangs: []BangRecord{
+			{ID: 1, Bang: "w", Name: "Wikipedia", URL: "https://en.wikipedia.org/w/index.php?search=%s"},
+		},
+	}
+}
+
+func declaredLink(name string, url string) Link {
+	return Link{Name: name, URL: url, Visibility: "public"}
+}
+
+func declaredCategory(name string, links ...Link) Category {
+	return Category{Name: name, Sort: "manual", Visibility: "public", Links: links}
+}
+
+func TestNewPlan(t *testing.T) {
+	tests := []struct {
+		name              string
+		file              File
+		matchedCategories []int64
+		matchedLinks      []int64
+		trashedCategories []int64
+		trashedLinks      []int64
+		removedBoards     []string
+		defaultChanged    bool
+	}{
+		{
+			name: "unchanged",
+			file: File{Boards: []Board{
+				{Slug: "home", Title: "Home", Default: true, Categories: []Category{
+					declaredCategory("Dev", declaredLink("Go", "https://go.dev"), declaredLink("Rust", "https://rust-lang.org")),
+					declaredCategory("News"),
+				}},
+				{Slug: "work", Title: "Work", Categories: []Category{declaredCategory("Jira")}},
+			}},
+			matchedCategories: []int64{10, 11, 20},
+			matchedLinks:      []int64{100, 101},
+		},
+		{
+			name: "links are matched by url and then by name",
+			file: File{Boards: []Board{
+				{Slug: "home", Title: "Home", Default: true, Categories: []Category{
+					declaredCategory("Dev", declaredLink("Rust", "https://www.rust-lang.org"), declaredLink("Golang", "https://go.dev")),
+					declaredCategory("News"),
+				}},
+				{Slug: "work", Title: "Work", Categories: []Category{declaredCategory("Jira")}},
+			}},
+			matchedCategories: []int64{10, 11, 20},
+			matchedLinks:      []int64{101, 100},
+		},
+		{
+			name: "what is left out goes to the trash",
+			file: File{Boards: []Board{
+				{Slug: "home", Title: "Home", Default: true, Categories: []Category{
+					declaredCategory("Dev", declaredLink("Go", "https://go.dev")),
+				}},
+			}},
+			matchedCategories: []int64{10},
+			matchedLinks:      []int64{100},
+			trashedCategories: []int64{11},
+			trashedLinks:      []int64{101},
+			removedBoards:     []string{"work"},
+		},
+		{
+			name: "declaring something again takes it out of the trash",
+			file: File{Boards: []Board{
+				{Slug: "home", Title: "Home", Default: true, Categories: []Category{
+					declaredCategory("Dev", declaredLink("Go", "https://go.dev"), declaredLink("Rust", "https://rust-lang.org"),
+						declaredLink("Zig", "https://ziglang.org")),
+					declaredCategory("News"),
+					declaredCategory("Old"),
+				}},
+				{Slug: "work", Title: "Work", Categories: []Category{declaredCategory("Jira")}},
+			}},
+			matchedCategories: []int64{10, 11, 12, 20},
+			matchedLinks:      []int64{100, 101, 102},
+		},
+		{
+			name: "new boards and categories are created and can become the default",
+			file: File{Boards: []Board{
+				{Slug: "home", Title: "Home", Categories: []Category{
+					declaredCategory("Dev", declaredLink("Go", "https://go.dev"), declaredLink("Rust", "https://rust-lang.org")),
+					declaredCategory("News"),
+					declaredCategory("Games"),
+				}},
+				{Slug: "work", Title: "Work", Categories: []Category{declaredCategory("Jira")}},
+				{Slug: "lab", Title: "Lab", Default: true},
+			}},
+			matchedCategories: []int64{10, 11, 0, 20},
+			matchedLinks:      []int64{100, 101},
+			defaultChanged:    true,
+		},
+		{
+			name: "categories are matched within their board only",
+			file: File{Boards: []Board{
+				{Slug: "home", Title: "Home", Default: true, Categories: []Category{
+					declaredCategory("Dev", declaredLink("Go", "https://go.dev"), declaredLink("Rust", "https://rust-lang.org")),
+					declaredCategory("News"),
+					declaredCategory("Jira"),
+				}},
+				{Slug: "work", Title: "Work"},
+			}},
+			matchedCategories: []int64{10, 11, 0},
+			matchedLinks:      []int64{100, 101},
+			trashedCategories: []int64{20},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plan := NewPlan(&test.file, testState())
+
+			var matchedCategories, matchedLinks []int64
+			for _, board := range plan.Boards {
+				for _, category := range board.Categories {
+					matchedCategories = append(matchedCategories, category.Category.ID)
+					for _, link := range category.Links {
+						matchedLinks = append(matchedLinks, link.Link.ID)
+					}
+				}
+			}
+
+			var trashedCategories, trashedLinks []int64
+			for _, category := range plan.TrashedCategories {
+				trashedCategories = append(trashedCategories, category.ID)
+			}
+			for _, link := range plan.TrashedLinks {
+				trashedLinks = append(trashedLinks, link.ID)
+			}
+
+			var removedBoards []string
+			for _, board := range plan.RemovedBoards {
+				removedBoards = append(removedBoards, board.Slug)
+			}
+
+			for _, check := range []struct {
+				what      string
+				got, want any
+			}{
+				{"matched categories", matchedCategories, test.matchedCategories},
+				{"matched links", matchedLinks, test.matchedLinks},
+				{"trashed categories", trashedCategories, test.trashedCategories},
+				{"trashed links", trashedLinks, test.trashedLinks},
+				{"removed boards", removedBoards, test.removedBoards},
+				{"default changed", plan.DefaultChanged, test.defaultChanged},
+			} {
+				if fmt.Sprint(check.got) != fmt.Sprint(check.want) {
+					t.Errorf("%s = %v, want %v", check.what, check.got, check.want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewPlanPrefersWhatIsInUse(t *testing.T) {
+	state := testState()
+	// a category of the same name that was moved to the trash earlier comes first in the manual order
+	state.Categories = append([]CategoryRecord{{ID: 9, BoardID: 1, Name: "News", Position: 0, Deleted: true}}, state.Categories...)
+
+	file := File{Boards: []Board{{Slug: "home", Default: true, Categories: []Category{declaredCategory("News")}}}}
+	plan := NewPlan(&file, state)
+
+	if got := plan.Boards[0].Categories[0].Category.ID; got != 11 {
+		t.Errorf("matched category %d, want 11", got)
+	}
+}
+
+// fakeStore records the changes made to it in the order they are made
+type fakeStore struct {
+	calls  []string
+	nextID int64
+}
+
+func (store *fakeStore) record(format string, args ...any) {
+	store.calls = append(store.calls, fmt.Sprintf(format, args...))
+}
+
+func (store *fakeStore) create(format string, args ...any) int64 {
+	store.record(format, args...)
+	store.nextID++
+	return 1000 + store.nextID
+}
+
+func (store *fakeStore) CreateBoard(board BoardRecord) (int64, error) {
+	return store.create("create board %s", board.Slug), nil
+}
+
+func (store *fakeStore) UpdateBoard(before BoardRecord, after BoardRecord) error {
+	store.record("update board %s", after.Slug)
+	return nil
+}
+
+func (store *fakeStore) SetDefaultBoard(id int64) error {
+	store.record("default board %d", id)
+	return nil
+}
+
+func (store *fakeStore) RemoveBoard(board BoardRecord) error {
+	store.record("remove board %s", board.Slug)
+	return nil
+}
+
+func (store *fakeStore) CreateCategory(category CategoryRecord) (int64, error) {
+	return store.create("create category %s on %d", category.Name, category.BoardID), nil
+}
+
+func (store *fakeStore) UpdateCategory(before CategoryRecord, after CategoryRecord) error {
+	store.record("update category %s", after.Name)
+	return nil
+}
+
+func (store *fakeStore) TrashCategory(category CategoryRecord) error {
+	store.record("trash category %s", category.Name)
+	return nil
+}
+
+func (store *fakeStore) CreateLink(link LinkRecord) (int64, error) {
+	return store.create("create link %s in %d", link.Name, link.CategoryID), nil
+}
+
+func (store *fakeStore) UpdateLink(before LinkRecord, after LinkRecord) error {
+	store.record("update link %s", after.Name)
+	return nil
+}
+
+func (store *fakeStore) TrashLink(link LinkRecord) error {
+	store.record("trash link %s", link.Name)
+	return nil
+}
+
+func (store *fakeStore) CreateBang(bang BangRecord) (int64, error) {
+	return store.create("create bang %s", bang.Bang), nil
+}
+
+func (store *fakeStore) UpdateBang(before BangRecord, after BangRecord) error {
+	store.record("update bang %s", after.Bang)
+	return nil
+}
+
+func (store *fakeStore) DeleteBang(bang BangRecord) error {
+	store.record("delete bang %s", bang.Bang)
+	return nil
+}
+
+func (store *fakeStore) CreateSearchProvider(provider SearchProviderRecord) (int64, error) {
+	return store.create("create search provider %s", provider.Shortcut), nil
+}
+
+func (store *fakeStore) UpdateSearchProvider(before SearchProviderRecord, after SearchProviderRecord) error {
+	store.record("update search provider %s", after.Shortcut)
+	return nil
+}
+
+func (store *fakeStore) DeleteSearchProvider(provider SearchProviderRecord) error {
+	store.record("delete search provider %s", provider.Shortcut)
+	return nil
+}
+
+func TestPlanApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  File
+		state func(*State)
+		calls []string
+	}{
+		{
+			name: "nothing to do",
+			file: File{Boards: []Board{
+				{Slug: "home", Title: "Home", Default: true, Categories: []Category{
+					declaredCategory("Dev", declaredLink("Go", "https://go.dev"), declaredLink("Rust", "https://rust-lang.org")),
+					declaredCategory("News"),
+				}},
+				{Slug: "work", Title: "Work", Categories: []Category{declaredCategory("Jira")}},
+			}},
+		},
+		{
+			name: "removals go first and boards are removed once the new default is set",
+			file: File{Boards: []Board{
+				{Slug: "lab", Title: "Lab", Default: true, Categories: []Category{
+					declaredCategory("Tools", declaredLink("Go", "https://go.dev")),
+				}},
+				{Slug: "home", Title: "House", Categories: []Category{
+					declaredCategory("Dev", declaredLink("Go", "https://go.dev")),
+				}},
+			}},
+			calls: []string{
+				"trash category News",
+				"trash link Rust",
+				"create board lab",
+				"create category Tools on 1001",
+				"create link Go in 1002",
+				"update board home",
+				"default board 1001",
+				"remove board work",
+			},
+		},
+		{
+			name: "restoring from the trash is an update",
+			file: File{Boards: []Board{
+				{Slug: "home", Title: "Home", Default: true, Categories: []Category{
+					declaredCategory("Dev", declaredLink("Go", "https://go.dev"), declaredLink("Rust", "https://rust-lang.org"),
+						declaredLink("Zig", "https://ziglang.org")),
+					declaredCategory("News"),
+					declaredCategory("Old"),
+				}},
+				{Slug: "work", Title: "Work", Categories: []Category{declaredCategory("Jira")}},
+			}},
+			calls: []string{
+				"update link Zig",
+				"update category Old",
+			},
+		},
+		{
+			name: "bangs are replaced when declared",
+			file: File{
+				Boards: []Board{
+					{Slug: "home", Title: "Home", Default: true, Categories: []Category{
+						declaredCategory("Dev", declaredLink("Go", "https://go.dev"), declaredLink("Rust", "https://rust-lang.org")),
+						declaredCategory("News"),
+					}},
+					{Slug: "work", Title: "Work", Categories: []Category{declaredCategory("Jira")}},
+				},
+				Bangs: []Bang{{Bang: "gh", Name: "GitHub", URL: "https://github.com/search?q=%s"}},
+			},
+			calls: []string{
+				"delete bang w",
+				"create bang gh",
+			},
+		},
+		{
+			name: "the default search provider is written last",
+			file: File{
+				Boards: []Board{
+					{Slug: "home", Title: "Home", Default: true, Categories: []Category{
+						declaredCategory("Dev", declaredLink("Go", "https://go.dev"), declaredLink("Rust", "https://rust-lang.org")),
+						declaredCategory("News"),
+					}},
+					{Slug: "work", Title: "Work", Categories: []Category{declaredCategory("Jira")}},
+				},
+				SearchProviders: []SearchProvider{
+					{Name: "Kagi", Shortcut: "k", URL: "https://kagi.com/search?q=%s", Default: true},
+					{Name: "DuckDuckGo", Shortcut: "ddg", URL: "https://duckduckgo.com/?q=%s"},
+				},
+			},
+			state: func(state *State) {
+				state.SearchProviders = []SearchProviderRecord{
+					{ID: 1, Name: "DuckDuckGo", Shortcut: "ddg", URL: "https://duckduckgo.com/?q=%s", Default: true},
+					{ID: 2, Name: "Google", Shortcut: "g", URL: "https://www.google.com/search?q=%s"},
+				}
+			},
+			calls: []string{
+				"delete search provider g",
+				"update search provider ddg",
+				"create search provider k",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := testState()
+			if test.state != nil {
+				test.state(state)
+			}
+
+			plan := NewPlan(&test.file, state)
+			store := &fakeStore{}
+
+			changes, err := plan.Apply(store)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(store.calls, test.calls) {
+				t.Errorf("calls = %q, want %q", store.calls, test.calls)
+			}
+
+			// making a board the default is part of creating or updating it, so it is not counted on its own
+			want := len(test.calls)
+			if plan.DefaultChanged {
+				want--
+			}
+			if changes != want {
+				t.Errorf("changes = %d, want %d", changes, want)
+			}
+
+			if described := plan.Describe(); len(described) != len(test.calls) {
+				t.Errorf("described %q, want %d changes", described, len(test.calls))
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Package fuzzy scores how well a few typed letters match a piece of text, so links can be found without typing their
+// whole name
+package fuzzy
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// the scores of each kind of match, a better kind of match always beats a worse one
+const (
+	scoreExact     = 100
+	scorePrefix    = 80
+	scoreWordStart = 60
+	scoreContains  = 40
+	// letters that are spread out score at most scoreContains - 1, depending on how close together they are
+	scoreScattered = 10
+)
+
+// Score returns how well pattern matches text, higher is better, or 0 if it doesnt match. Both are compared case
+// insensitively. The whole text beats its start, which beats the start of a word in it, which beats anywhere else in it.
+// When the letters of pattern are only found apart from each other, in order, the match scores more the closer together
+// they are and the more of them start a word
+func Score(pattern, text string) int {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	text = strings.ToLower(text)
+	if pattern == "" || text == "" {
+		return 0
+	}
+
+	switch {
+	case text == pattern:
+		return scoreExact
+	case strings.HasPrefix(text, pattern):
+		return scorePrefix
+	}
+
+	if strings.Contains(text, pattern) {
+		for i := range text {
+			if strings.HasPrefix(text[i:], pattern) && separator(lastRune(text[:i])) {
+				return scoreWordStart
+			}
+		}
+
+		return scoreContains
+	}
+
+	return scattered([]rune(pattern), []rune(text))
+}
+
+// separator reports whether a letter following r starts a word, like the g of grafana in my-grafana
+func separator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// lastRune returns the last letter of text, or a space if there is none so the text counts as the start of a word
+func lastRune(text string) rune {
+	if text == "" {
+		return ' '
+	}
+
+	r, _ := utf8.DecodeLastRuneInString(text)
+	return r
+}
+
+// scattered scores the letters of pattern found in order in text, trying each place the first letter is found
+func scattered(pattern, text []rune) int {
+	// a single letter anywhere in a long text says nothing about whether it is the one being looked for
+	if len(pattern) < 2 {
+		return 0
+	}
+
+	best := 0
+	for start, r := range text {
+		if r != pattern[0] {
+			continue
+		}
+
+		bonus := 0
+		if start == 0 || separator(text[start-1]) {
+			bonus += 2
+		}
+
+		matched := 1
+		last := start
+		for i := start + 1; i < len(text) && matched < len(pattern); i++ {
+			if text[i] != pattern[matched] {
+				continue
+			}
+
+			if i == last+1 {
+				bonus += 2
+			} else if separator(text[i-1]) {
+				bonus++
+			}
+
+			matched++
+			last = i
+		}
+
+		// letters spread over most of a URL match almost anything
+		if matched < len(pattern) || last-start+1 > len(pattern)*3 {
+			continue
+		}
+
+		best = max(best, min(scoreScattered+bonus, scoreContains-1))
+	}
+
+	return best
+}
@@ -0,0 +1,69 @@
+package fuzzy
+
+import "testing"
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		pattern string
+		text    string
+		want    int
+	}{
+		{"graf", "graf", scoreExact},
+		{"GRAF", "Graf", scoreExact},
+		{" graf ", "graf", scoreExact},
+		{"graf", "Grafana", scorePrefix},
+		{"graf", "my-grafana", scoreWordStart},
+		{"graf", "my grafana", scoreWordStart},
+		{"graf", "mygrafana", scoreContains},
+		// the first place it is found is not the start of a word, a later one is
+		{"graf", "xgraf graf", scoreWordStart},
+		{"graf", "g-r-a-f", scoreScattered + 2 + 3},
+		{"graf", "gxrxaxf", scoreScattered + 2},
+		{"grf", "grafana", scoreScattered + 2 + 2},
+		{"", "grafana", 0},
+		{"graf", "", 0},
+		{"n", "grafana", scoreContains},
+		// not in the text at all
+		{"x", "grafana", 0},
+		{"graf", "garf", 0},
+		// spread out over too much of the text
+		{"gf", "github.com/juls0730/passport/foo", 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern+"/"+test.text, func(t *testing.T) {
+			if got := Score(test.pattern, test.text); got != test.want {
+				t.Errorf("Score(%q, %q) = %d, want %d", test.pattern, test.text, got, test.want)
+			}
+		})
+	}
+}
+
+func TestScoreRanking(t *testing.T) {
+	tests := []struct {
+		pattern string
+		// best match first
+		texts []string
+	}{
+		{"graf", []string{"graf", "Grafana", "my-grafana", "mygrafana", "g-r-a-f", "gxrxaxf"}},
+		{"jf", []string{"jf", "jfrog", "my jf", "ajf", "j-f", "jxf"}},
+		// letters starting a word count for more
+		{"nc", []string{"next-cloud", "Nextcloud"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			for i := 1; i < len(test.texts); i++ {
+				better, worse := test.texts[i-1], test.texts[i]
+				if Score(test.pattern, better) <= Score(test.pattern, worse) {
+					t.Errorf("%q scores %d, not more than %q with %d", better, Score(test.pattern, better), worse,
+						Score(test.pattern, worse))
+				}
+			}
+
+			if Score(test.pattern, test.texts[len(test.texts)-1]) == 0 {
+				t.Errorf("%q does not match at all", test.texts[len(test.texts)-1])
+			}
+		})
+	}
+}
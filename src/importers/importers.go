@@ -0,0 +1,470 @@
+// Package importers reads the configuration of other homelab dashboards, Homer, Dashy, Heimdall and homepage, into
+// folders of bookmarks that can be imported like a browser's bookmarks. Only the parts shown below are read, anything
+// else in the files is ignored.
+//
+// Homer's config.yml has a list of services, each a group of items:
+//
+//	services:
+//	  - name: Media
+//	    icon: fas fa-film       # or logo: with an image URL
+//	    items:
+//	      - name: Jellyfin
+//	        url: https://jellyfin.example.com
+//	        subtitle: Movies    # becomes the description
+//	        tag: media          # becomes the only tag
+//	        logo: https://example.com/jellyfin.png
+//
+// Dashy's conf.yml has a list of sections, each with a list of items, which can hold sub items of their own:
+//
+//	sections:
+//	  - name: Media
+//	    icon: mdi-filmstrip
+//	    items:
+//	      - title: Jellyfin
+//	        url: https://jellyfin.example.com
+//	        description: Movies
+//	        icon: hl-jellyfin
+//	        tags: [media]
+//	        subItems: []
+//
+// Heimdall's JSON export is a list of items, which all go into one folder:
+//
+//	[{"title": "Jellyfin", "url": "https://jellyfin.example.com", "description": "Movies"}]
+//
+// homepage's services.yaml and bookmarks.yaml are a list of groups that each map their name to a list of services,
+// or of other groups. services.yaml maps each service's name to its settings, bookmarks.yaml to a list holding them:
+//
+//	# services.yaml
+//	- Media:
+//	    - Jellyfin:
+//	        href: https://jellyfin.example.com
+//	        description: Movies
+//	        icon: jellyfin.png
+//
+//	# bookmarks.yaml
+//	- Developer:
+//	    - GitHub:
+//	        - href: https://github.com
+//	          icon: si-github
+package importers
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/juls0730/passport/src/bookmarks"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatHomer    = "homer"
+	FormatDashy    = "dashy"
+	FormatHeimdall = "heimdall"
+	FormatHomepage = "homepage"
+)
+
+var Formats = []string{FormatHomer, FormatDashy, FormatHeimdall, FormatHomepage}
+
+var ErrUnknownFormat = errors.New("file is not a Homer, Dashy, Heimdall or homepage configuration")
+
+// where the icon sets the other dashboards use are downloaded from
+const (
+	fontAwesomeURL   = "https://cdn.jsdelivr.net/npm/@fortawesome/fontawesome-free/svgs/%s/%s.svg"
+	materialIconsURL = "https://cdn.jsdelivr.net/npm/@mdi/svg/svg/%s.svg"
+	simpleIconsURL   = "https://cdn.jsdelivr.net/npm/simple-icons/icons/%s.svg"
+	selfhstIconsURL  = "https://cdn.jsdelivr.net/gh/selfhst/icons/svg/%s.svg"
+)
+
+// Parse reads a configuration in the given format, working out which format it is in if format is empty, and returns
+// its groups as folders along with the format. Icons are returned the way a dashboard file declares them, as a URL or
+// the name of an icon in dashboard icons, references to files on the other dashboard's server are left out
+func Parse(data []byte, format string) ([]bookmarks.Folder, string, error) {
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrUnknownFormat, err)
+	}
+
+	if format == "" {
+		format = detect(doc)
+		if format == "" {
+			return nil, "", ErrUnknownFormat
+		}
+	}
+
+	var folders []bookmarks.Folder
+	var err error
+	switch format {
+	case FormatHomer:
+		folders, err = parseHomer(data)
+	case FormatDashy:
+		folders, err = parseDashy(data)
+	case FormatHeimdall:
+		folders, err = parseHeimdall(data)
+	case FormatHomepage:
+		folders, err = parseHomepage(doc)
+	default:
+		return nil, "", fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(Formats, ", "))
+	}
+
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s configuration: %w", format, err)
+	}
+
+	return folders, format, nil
+}
+
+// detect works out the format from the shape of the document. Every entry of its list has to have the shape of the
+// format, so a file that only starts out like one is not taken for it
+func detect(doc any) string {
+	switch doc := doc.(type) {
+	case map[string]any:
+		if sections, ok := doc["sections"].([]any); ok && everyEntry(sections, isMap) {
+			return FormatDashy
+		}
+
+		if services, ok := doc["services"].([]any); ok && everyEntry(services, isMap) &&
+			slices.ContainsFunc(services, func(service any) bool { return hasKeys(service, "items") }) {
+			return FormatHomer
+		}
+	case []any:
+		// Heimdall exports a list of items, homepage a list of groups that each map their name to a list
+		if everyEntry(doc, func(item any) bool { return hasKeys(item, "title", "url") }) {
+			return FormatHeimdall
+		}
+
+		if everyEntry(doc, isHomepageGroup) {
+			return FormatHomepage
+		}
+	}
+
+	return ""
+}
+
+// everyEntry reports whether list has entries and all of them are what is wanted
+func everyEntry(list []any, wanted func(entry any) bool) bool {
+	return len(list) > 0 && !slices.ContainsFunc(list, func(entry any) bool { return !wanted(entry) })
+}
+
+func isMap(value any) bool {
+	_, ok := value.(map[string]any)
+	return ok
+}
+
+// hasKeys reports whether value is a map with all of the keys
+func hasKeys(value any, keys ...string) bool {
+	entry, ok := value.(map[string]any)
+	if !ok {
+		return false
+	}
+
+	for _, key := range keys {
+		if _, ok := entry[key]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isHomepageGroup reports whether value is a group of homepage's, a name with a list of services, which is left out
+// for a group without any
+func isHomepageGroup(value any) bool {
+	_, services, ok := singleKey(value)
+	if !ok {
+		return false
+	}
+
+	switch services.(type) {
+	case []any, nil:
+		return true
+	}
+
+	return false
+}
+
+type homerConfig struct {
+	Services []struct {
+		Name  string `yaml:"name"`
+		Icon  string `yaml:"icon"`
+		Logo  string `yaml:"logo"`
+		Items []struct {
+			Name     string `yaml:"name"`
+			Subtitle string `yaml:"subtitle"`
+			Tag      string `yaml:"tag"`
+			URL      string `yaml:"url"`
+			Icon     string `yaml:"icon"`
+			Logo     string `yaml:"logo"`
+		} `yaml:"items"`
+	} `yaml:"services"`
+}
+
+func parseHomer(data []byte) ([]bookmarks.Folder, error) {
+	var config homerConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	var folders []bookmarks.Folder
+	for _, service := range config.Services {
+		folder := bookmarks.Folder{Name: service.Name, Icon: firstIcon(service.Logo, service.Icon)}
+
+		for _, item := range service.Items {
+			bookmark := bookmarks.Bookmark{
+				Title:       item.Name,
+				URL:         item.URL,
+				Description: item.Subtitle,
+				Icon:        firstIcon(item.Logo, item.Icon),
+			}
+
+			if item.Tag != "" {
+				bookmark.Tags = []string{item.Tag}
+			}
+
+			folder.Bookmarks = append(folder.Bookmarks, bookmark)
+		}
+
+		folders = append(folders, folder)
+	}
+
+	return folders, nil
+}
+
+type dashyItem struct {
+	Title       string      `yaml:"title"`
+	Description string      `yaml:"description"`
+	URL         string      `yaml:"url"`
+	Icon        string      `yaml:"icon"`
+	Tags        []string    `yaml:"tags"`
+	SubItems    []dashyItem `yaml:"subItems"`
+}
+
+type dashyConfig struct {
+	Sections []struct {
+		Name  string      `yaml:"name"`
+		Icon  string      `yaml:"icon"`
+		Items []dashyItem `yaml:"items"`
+	} `yaml:"sections"`
+}
+
+func parseDashy(data []byte) ([]bookmarks.Folder, error) {
+	var config dashyConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	var folders []bookmarks.Folder
+	for _, section := range config.Sections {
+		folder := bookmarks.Folder{Name: section.Name, Icon: resolveIcon(section.Icon)}
+
+		// an item with sub items is a group of links shown as one, each of them becomes a link of its own
+		var add func(items []dashyItem)
+		add = func(items []dashyItem) {
+			for _, item := range items {
+				if item.URL != "" {
+					folder.Bookmarks = append(folder.Bookmarks, bookmarks.Bookmark{
+						Title:       item.Title,
+						URL:         item.URL,
+						Description: item.Description,
+						Tags:        item.Tags,
+						Icon:        resolveIcon(item.Icon),
+					})
+				}
+
+				add(item.SubItems)
+			}
+		}
+		add(section.Items)
+
+		folders = append(folders, folder)
+	}
+
+	return folders, nil
+}
+
+type heimdallItem struct {
+	Title       string `yaml:"title"`
+	URL         string `yaml:"url"`
+	Description string `yaml:"description"`
+	// only set by some versions, the rest only refer to the icon by the id of the app it belongs to
+	Icon string `yaml:"icon"`
+}
+
+func parseHeimdall(data []byte) ([]bookmarks.Folder, error) {
+	var items []heimdallItem
+	if err := yaml.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+
+	folder := bookmarks.Folder{Name: "Heimdall"}
+	for _, item := range items {
+		// the apps Heimdall knows are mostly in dashboard icons under the same name
+		icon := resolveIcon(item.Icon)
+		if icon == "" {
+			icon = iconName(item.Title)
+		}
+
+		folder.Bookmarks = append(folder.Bookmarks, bookmarks.Bookmark{
+			Title:       item.Title,
+			URL:         item.URL,
+			Description: item.Description,
+			Icon:        icon,
+		})
+	}
+
+	return []bookmarks.Folder{folder}, nil
+}
+
+// parseHomepage reads homepage's services.yaml or bookmarks.yaml, both are a list of groups that map their name to a
+// list of services, which map their name to their settings. Groups can hold other groups, which are flattened into a
+// folder each
+func parseHomepage(doc any) ([]bookmarks.Folder, error) {
+	groups, ok := doc.([]any)
+	if !ok {
+		return nil, errors.New("expected a list of groups")
+	}
+
+	var folders []bookmarks.Folder
+	var addGroup func(name string, entries []any)
+	addGroup = func(name string, entries []any) {
+		// the folder goes before the ones of the groups it holds
+		i := len(folders)
+		folders = append(folders, bookmarks.Folder{Name: name})
+		folder := &folders[i]
+
+		for _, entry := range entries {
+			entryName, value, ok := singleKey(entry)
+			if !ok {
+				continue
+			}
+
+			switch value := value.(type) {
+			case map[string]any:
+				folder.Bookmarks = append(folder.Bookmarks, homepageBookmark(entryName, value))
+			case []any:
+				// bookmarks.yaml wraps the settings of each bookmark in a list
+				if len(value) > 0 {
+					if settings, ok := value[0].(map[string]any); ok {
+						if _, ok := settings["href"]; ok {
+							folder.Bookmarks = append(folder.Bookmarks, homepageBookmark(entryName, settings))
+							continue
+						}
+					}
+				}
+
+				addGroup(entryName, value)
+				folder = &folders[i]
+			}
+		}
+	}
+
+	for _, group := range groups {
+		name, entries, ok := singleKey(group)
+		if !ok {
+			return nil, errors.New("every group needs to be a name with a list of services")
+		}
+
+		list, _ := entries.([]any)
+		addGroup(name, list)
+	}
+
+	// groups that only hold other groups have nothing to import themselves
+	return slices.DeleteFunc(folders, func(folder bookmarks.Folder) bool {
+		return len(folder.Bookmarks) == 0
+	}), nil
+}
+
+func homepageBookmark(name string, settings map[string]any) bookmarks.Bookmark {
+	text := func(key string) string {
+		value, _ := settings[key].(string)
+		return value
+	}
+
+	return bookmarks.Bookmark{
+		Title:       name,
+		URL:         text("href"),
+		Description: text("description"),
+		Icon:        resolveIcon(text("icon")),
+	}
+}
+
+// singleKey returns the only key of a map and its value
+func singleKey(value any) (string, any, bool) {
+	entry, ok := value.(map[string]any)
+	if !ok || len(entry) != 1 {
+		return "", nil, false
+	}
+
+	for key, value := range entry {
+		return key, value, true
+	}
+
+	return "", nil, false
+}
+
+// firstIcon returns the first of the icons that can be resolved
+func firstIcon(icons ...string) string {
+	for _, icon := range icons {
+		if resolved := resolveIcon(icon); resolved != "" {
+			return resolved
+		}
+	}
+
+	return ""
+}
+
+// matches an icon set prefix like mdi-, along with a colour suffix like -#ff0000 that homepage allows
+var iconSetPattern = regexp.MustCompile(`^(mdi|si|sh|hl)-([a-z0-9-]+?)(-#[0-9a-fA-F]{3,8})?$`)
+
+// matches the Font Awesome classes Homer and Dashy use, such as fas fa-cloud
+var fontAwesomePattern = regexp.MustCompile(`^fa([srb]|-solid|-regular|-brands)\s+fa-([a-z0-9-]+)$`)
+
+// resolveIcon turns the icon references the other dashboards understand into ones passport does, or an empty string if
+// the icon cannot be found outside of the dashboard it came from
+func resolveIcon(icon string) string {
+	icon = strings.TrimSpace(icon)
+
+	if parsed, err := url.Parse(icon); err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != "" {
+		return icon
+	}
+
+	if matches := fontAwesomePattern.FindStringSubmatch(icon); matches != nil {
+		style := map[string]string{"s": "solid", "-solid": "solid", "r": "regular", "-regular": "regular", "b": "brands", "-brands": "brands"}[matches[1]]
+		return fmt.Sprintf(fontAwesomeURL, style, matches[2])
+	}
+
+	if matches := iconSetPattern.FindStringSubmatch(strings.ToLower(icon)); matches != nil {
+		switch matches[1] {
+		case "mdi":
+			return fmt.Sprintf(materialIconsURL, matches[2])
+		case "si":
+			return fmt.Sprintf(simpleIconsURL, matches[2])
+		case "sh":
+			return fmt.Sprintf(selfhstIconsURL, matches[2])
+		case "hl":
+			// Dashy's homelab icons are what dashboard icons grew out of
+			return iconName(matches[2])
+		}
+	}
+
+	// homepage takes plain file names to mean dashboard icons, anything with a directory is a file on its server
+	if !strings.Contains(icon, "/") {
+		switch path.Ext(icon) {
+		case ".png", ".svg", ".webp":
+			return iconName(strings.TrimSuffix(icon, path.Ext(icon)))
+		}
+	}
+
+	return ""
+}
+
+var nonIconNamePattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// iconName turns an app's name into the name dashboard icons would most likely have for it
+func iconName(name string) string {
+	return strings.Trim(nonIconNamePattern.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
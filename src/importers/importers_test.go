@@ -0,0 +1,224 @@
+package importers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/juls0730/passport/src/bookmarks"
+	"gopkg.in/yaml.v3"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{name: "homer", doc: "services:\n  - name: Media\n    items: []\n", want: FormatHomer},
+		{name: "homer without items", doc: "services:\n  - name: Media\n", want: ""},
+		{name: "dashy", doc: "sections:\n  - name: Media\n    items: []\n", want: FormatDashy},
+		{name: "dashy with a section that is not a map", doc: "sections:\n  - name: Media\n  - Media\n", want: ""},
+		{name: "heimdall", doc: `[{"title": "Jellyfin", "url": "https://jellyfin.example.com"}]`, want: FormatHeimdall},
+		{name: "heimdall with an item without a url", doc: `[{"title": "Jellyfin", "url": "https://a.example"}, {"title": "Plex"}]`, want: ""},
+		{name: "homepage", doc: "- Media:\n    - Jellyfin:\n        href: https://jellyfin.example.com\n", want: FormatHomepage},
+		{name: "homepage with an empty group", doc: "- Media:\n- Dev:\n    - Go:\n        href: https://go.dev\n", want: FormatHomepage},
+		{name: "homepage and heimdall mixed", doc: "- Media: []\n- title: Jellyfin\n  url: https://jellyfin.example.com\n", want: ""},
+		{name: "empty list", doc: "[]", want: ""},
+		{name: "something else", doc: "title: My dashboard\n", want: ""},
+		{name: "plain text", doc: "hello", want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var doc any
+			if err := yaml.Unmarshal([]byte(test.doc), &doc); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := detect(doc); got != test.want {
+				t.Errorf("detect() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		format     string
+		want       []bookmarks.Folder
+		wantFormat string
+		// part of the error, if reading is expected to fail
+		err string
+	}{
+		{
+			name: "homer",
+			data: `
+services:
+  - name: Media
+    icon: fas fa-film
+    items:
+      - name: Jellyfin
+        url: https://jellyfin.example.com
+        subtitle: Movies
+        tag: media
+        logo: assets/jellyfin.png
+        icon: fab fa-linux
+`,
+			want: []bookmarks.Folder{{
+				Name: "Media",
+				Icon: "https://cdn.jsdelivr.net/npm/@fortawesome/fontawesome-free/svgs/solid/film.svg",
+				Bookmarks: []bookmarks.Bookmark{{
+					Title:       "Jellyfin",
+					URL:         "https://jellyfin.example.com",
+					Description: "Movies",
+					Tags:        []string{"media"},
+					Icon:        "https://cdn.jsdelivr.net/npm/@fortawesome/fontawesome-free/svgs/brands/linux.svg",
+				}},
+			}},
+			wantFormat: FormatHomer,
+		},
+		{
+			name: "dashy with sub items",
+			data: `
+sections:
+  - name: Media
+    icon: mdi-filmstrip
+    items:
+      - title: Jellyfin
+        url: https://jellyfin.example.com
+        icon: hl-jellyfin
+        tags: [media]
+      - title: Arr
+        subItems:
+          - title: Sonarr
+            url: https://sonarr.example.com
+`,
+			want: []bookmarks.Folder{{
+				Name: "Media",
+				Icon: "https://cdn.jsdelivr.net/npm/@mdi/svg/svg/filmstrip.svg",
+				Bookmarks: []bookmarks.Bookmark{
+					{Title: "Jellyfin", URL: "https://jellyfin.example.com", Tags: []string{"media"}, Icon: "jellyfin"},
+					{Title: "Sonarr", URL: "https://sonarr.example.com"},
+				},
+			}},
+			wantFormat: FormatDashy,
+		},
+		{
+			name: "heimdall",
+			data: `[{"title": "Home Assistant", "url": "https://ha.example.com", "description": "Lights"}]`,
+			want: []bookmarks.Folder{{
+				Name: "Heimdall",
+				Bookmarks: []bookmarks.Bookmark{
+					{Title: "Home Assistant", URL: "https://ha.example.com", Description: "Lights", Icon: "home-assistant"},
+				},
+			}},
+			wantFormat: FormatHeimdall,
+		},
+		{
+			name: "homepage services",
+			data: `
+- Media:
+    - Jellyfin:
+        href: https://jellyfin.example.com
+        description: Movies
+        icon: jellyfin.png
+    - Arr:
+        - Sonarr:
+            href: https://sonarr.example.com
+            icon: /icons/sonarr.png
+- Empty:
+`,
+			want: []bookmarks.Folder{
+				{Name: "Media", Bookmarks: []bookmarks.Bookmark{
+					{Title: "Jellyfin", URL: "https://jellyfin.example.com", Description: "Movies", Icon: "jellyfin"},
+				}},
+				{Name: "Arr", Bookmarks: []bookmarks.Bookmark{{Title: "Sonarr", URL: "https://sonarr.example.com"}}},
+			},
+			wantFormat: FormatHomepage,
+		},
+		{
+			name: "homepage bookmarks",
+			data: `
+- Developer:
+    - GitHub:
+        - href: https://github.com
+          icon: si-github
+`,
+			want: []bookmarks.Folder{{Name: "Developer", Bookmarks: []bookmarks.Bookmark{
+				{Title: "GitHub", URL: "https://github.com", Icon: "https://cdn.jsdelivr.net/npm/simple-icons/icons/github.svg"},
+			}}},
+			wantFormat: FormatHomepage,
+		},
+		{
+			name:   "format given",
+			data:   `[{"title": "Jellyfin", "url": "https://jellyfin.example.com"}]`,
+			format: FormatHomepage,
+			err:    "failed to read homepage configuration",
+		},
+		{name: "unknown format given", data: "[]", format: "organizr", err: `unknown format "organizr"`},
+		{name: "not a dashboard", data: "title: My dashboard\n", err: ErrUnknownFormat.Error()},
+		{name: "not yaml", data: "services: [", err: ErrUnknownFormat.Error()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, format, err := Parse([]byte(test.data), test.format)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("Parse() error = %v, want %q", err, test.err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if format != test.wantFormat {
+				t.Errorf("Parse() format = %q, want %q", format, test.wantFormat)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestResolveIcon(t *testing.T) {
+	tests := []struct {
+		icon string
+		want string
+	}{
+		{"", ""},
+		{"https://example.com/icon.png", "https://example.com/icon.png"},
+		{"  http://example.com/icon.png ", "http://example.com/icon.png"},
+		{"fas fa-cloud", "https://cdn.jsdelivr.net/npm/@fortawesome/fontawesome-free/svgs/solid/cloud.svg"},
+		{"fa-regular fa-star", "https://cdn.jsdelivr.net/npm/@fortawesome/fontawesome-free/svgs/regular/star.svg"},
+		{"fab fa-github", "https://cdn.jsdelivr.net/npm/@fortawesome/fontawesome-free/svgs/brands/github.svg"},
+		{"mdi-home", "https://cdn.jsdelivr.net/npm/@mdi/svg/svg/home.svg"},
+		{"mdi-home-#ff0000", "https://cdn.jsdelivr.net/npm/@mdi/svg/svg/home.svg"},
+		{"si-github", "https://cdn.jsdelivr.net/npm/simple-icons/icons/github.svg"},
+		{"SH-Jellyfin", "https://cdn.jsdelivr.net/gh/selfhst/icons/svg/jellyfin.svg"},
+		{"hl-home-assistant", "home-assistant"},
+		{"jellyfin.png", "jellyfin"},
+		{"Home Assistant.svg", "home-assistant"},
+		{"jellyfin.jpg", ""},
+		// files on the other dashboard's server
+		{"/icons/jellyfin.png", ""},
+		{"assets/jellyfin.png", ""},
+		{"file:///icons/jellyfin.png", ""},
+		{"jellyfin", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.icon, func(t *testing.T) {
+			if got := resolveIcon(test.icon); got != test.want {
+				t.Errorf("resolveIcon(%q) = %q, want %q", test.icon, got, test.want)
+			}
+		})
+	}
+}
@@ -4,8 +4,12 @@ package main
 
 import (
 	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
 	"database/sql"
 	"embed"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"image"
@@ -15,15 +19,21 @@ import (
 	"io/fs"
 	"log"
 	"log/slog"
+	"mime"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
+	"unicode/utf8"
 
 	"github.com/HugoSmits86/nativewebp"
 	"github.com/NarmadaWeb/gonify/v3"
@@ -32,11 +42,19 @@ import (
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/middleware/compress"
 	"github.com/gofiber/fiber/v3/middleware/helmet"
+	"github.com/gofiber/fiber/v3/middleware/limiter"
 	"github.com/gofiber/fiber/v3/middleware/static"
 	"github.com/gofiber/template/handlebars/v2"
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
+	"github.com/juls0730/passport/src/audit"
+	"github.com/juls0730/passport/src/backup"
+	"github.com/juls0730/passport/src/bookmarks"
+	"github.com/juls0730/passport/src/dashboard"
+	"github.com/juls0730/passport/src/fuzzy"
+	"github.com/juls0730/passport/src/importers"
 	"github.com/juls0730/passport/src/middleware"
+	"github.com/juls0730/passport/src/migrations"
 	"github.com/juls0730/passport/src/services"
 	"github.com/rwcarlsen/goexif/exif"
 	"github.com/rwcarlsen/goexif/tiff"
@@ -44,7 +62,7 @@ import (
 	_ "modernc.org/sqlite"
 )
 
-//go:embed assets/** templates/** schema.sql scripts/**.js
+//go:embed assets/** templates/** scripts/**.js
 var embeddedAssets embed.FS
 
 var devContent = `<script>
@@ -95,6 +113,14 @@ var (
 	insertLinkStmt     *sql.Stmt
 )
 
+var dbOptions = map[string]any{
+	"_time_format":  "sqlite",
+	"cache":         "shared",
+	"mode":          "rwc",
+	"_journal_mode": "WAL",
+	"_pragma":       "foreign_keys(1)",
+}
+
 type Config struct {
 	DevMode bool `env:"PASSPORT_DEV_MODE" envDefault:"false"`
 	Prefork bool `env:"PASSPORT_ENABLE_PREFORK" envDefault:"false"`
@@ -105,11 +131,28 @@ type Config struct {
 	UptimeAPIKey string `env:"PASSPORT_UPTIME_API_KEY"`
 	Uptime       *services.UptimeConfig
 
+	// probe every link in the background and show its status on its card
+	HealthCheck bool `env:"PASSPORT_HEALTH_CHECK" envDefault:"false"`
+	Health      *services.HealthConfig
+
 	Admin struct {
 		Username string `env:"PASSPORT_ADMIN_USERNAME"`
 		Password string `env:"PASSPORT_ADMIN_PASSWORD"`
 	}
 
+	RepairOrphans bool `env:"PASSPORT_REPAIR_ORPHANS" envDefault:"false"`
+
+	// send link clicks through /go/:linkID so they can be counted
+	TrackClicks bool `env:"PASSPORT_TRACK_CLICKS" envDefault:"false"`
+
+	// days a deleted category or link stays in the trash before it is purged, 0 keeps it until purged by hand
+	TrashRetentionDays int `env:"PASSPORT_TRASH_RETENTION_DAYS" envDefault:"30"`
+
+	// keep the boards, categories and links in step with a YAML file, the admin dashboard is read only while it is set
+	DashboardFile string `env:"PASSPORT_DASHBOARD_FILE"`
+	// where icons declared by name in the dashboard file or an imported configuration are downloaded from
+	DashboardIconURL string `env:"PASSPORT_DASHBOARD_ICON_URL" envDefault:"https://cdn.jsdelivr.net/gh/homarr-labs/dashboard-icons"`
+
 	SearchProvider struct {
 		URL   string `env:"PASSPORT_SEARCH_PROVIDER"`
 		Query string `env:"PASSPORT_SEARCH_PROVIDER_QUERY_PARAM" envDefault:"q"`
@@ -171,6 +214,13 @@ func ParseConfig() (*Config, error) {
 		config.Uptime.UpdateInterval = depricatedUptimeConfig.UpdateInterval
 	}
 
+	if config.HealthCheck {
+		config.Health = &services.HealthConfig{}
+		if err := env.Parse(config.Health); err != nil {
+			return nil, err
+		}
+	}
+
 	return &config, nil
 }
 
@@ -179,7 +229,10 @@ type App struct {
 	*CategoryManager
 	*services.WeatherManager
 	*services.UptimeManager
-	db *sql.DB
+	favicons    *services.FaviconFinder
+	metadata    *services.MetadataFetcher
+	suggestions *services.SuggestionFetcher
+	db          *sql.DB
 }
 
 func (app *App) Close() error {
@@ -192,47 +245,44 @@ func NewApp(dbPath string, options map[string]any) (*App, error) {
 		return nil, err
 	}
 
-	file, err := os.OpenFile(dbPath, os.O_RDWR|os.O_CREATE, 0644)
+	db, err := OpenDB(dbPath, options)
 	if err != nil {
-		if os.IsPermission(err) {
-			return nil, fmt.Errorf("file %s is not readable and writable: %v", dbPath, err)
-		}
-		return nil, fmt.Errorf("failed to open file %s for read/write: %v", dbPath, err)
+		return nil, err
 	}
-	defer file.Close()
-
-	var connectionOpts string
-	for k, v := range options {
-		if connectionOpts != "" {
-			connectionOpts += "&"
-		}
 
-		connectionOpts += fmt.Sprintf("%s=%v", k, v)
+	applied, err := migrations.Up(db)
+	for _, migration := range applied {
+		slog.Info("Applied database migration", "version", migration.Version, "name", migration.Name)
 	}
-
-	db, err := sql.Open("sqlite", fmt.Sprintf("%s?%s", dbPath, connectionOpts))
 	if err != nil {
+		db.Close()
 		return nil, err
 	}
 
-	err = db.Ping()
+	categoryManager, err := NewCategoryManager(db)
 	if err != nil {
 		return nil, err
 	}
 
-	schema, err := embeddedAssets.ReadFile("schema.sql")
-	if err != nil {
+	if err := categoryManager.CheckIntegrity(config.RepairOrphans); err != nil {
+		db.Close()
 		return nil, err
 	}
 
-	_, err = db.Exec(string(schema))
-	if err != nil {
-		return nil, err
+	// with prefork every child process runs NewApp as well, the workers only need to run once, in the parent
+	runWorkers := !fiber.IsChild()
+
+	if config.TrashRetentionDays > 0 && runWorkers {
+		go categoryManager.trashWorker(time.Duration(config.TrashRetentionDays) * 24 * time.Hour)
 	}
 
-	categoryManager, err := NewCategoryManager(db)
-	if err != nil {
-		return nil, err
+	if config.Health != nil && runWorkers {
+		interval := time.Duration(config.Health.Interval) * time.Second
+		if interval < time.Minute {
+			interval = time.Minute
+		}
+
+		go categoryManager.healthWorker(services.NewHealthChecker(config.Health), interval)
 	}
 
 	var weatherCache *services.WeatherManager
@@ -245,643 +295,6981 @@ func NewApp(dbPath string, options map[string]any) (*App, error) {
 		uptimeManager = services.NewUptimeManager(config.Uptime)
 	}
 
-	return &App{
+	app := &App{
 		Config:          config,
 		WeatherManager:  weatherCache,
 		CategoryManager: categoryManager,
 		UptimeManager:   uptimeManager,
+		favicons:        services.NewFaviconFinder(),
+		metadata:        services.NewMetadataFetcher(),
+		suggestions:     services.NewSuggestionFetcher(),
 		db:              db,
-	}, nil
-}
-
-func CropToCenter(img image.Image, outputSize int) (image.Image, error) {
-	if img == nil {
-		return nil, fmt.Errorf("input image is nil")
 	}
-	if outputSize <= 0 {
-		return nil, fmt.Errorf("output size must be positive")
+
+	if err := app.seedSearchProvider(); err != nil {
+		db.Close()
+		return nil, err
 	}
 
-	srcBounds := img.Bounds()
-	srcWidth := srcBounds.Dx()
-	srcHeight := srcBounds.Dy()
+	if config.DashboardFile != "" {
+		config.DashboardFile, err = filepath.Abs(config.DashboardFile)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
 
-	squareSide := min(srcWidth, srcHeight)
+		info, err := os.Stat(config.DashboardFile)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
 
-	cropX := (srcWidth - squareSide) / 2
-	cropY := (srcHeight - squareSide) / 2
+		// the parent applied the file before starting the children
+		if runWorkers {
+			// passport should not start with something other than what the file declares
+			if err := app.syncDashboard(); err != nil {
+				db.Close()
+				return nil, fmt.Errorf("failed to apply %s: %w", config.DashboardFile, err)
+			}
 
-	srcCropRect := image.Rect(cropX, cropY, cropX+squareSide, cropY+squareSide)
+			go app.dashboardWorker(info)
+		}
+	}
 
-	croppedSquareImg := image.NewRGBA(image.Rect(0, 0, squareSide, squareSide))
-	draw.Draw(croppedSquareImg, croppedSquareImg.Rect, img, srcCropRect.Min, draw.Src)
+	return app, nil
+}
 
-	if squareSide == outputSize {
-		return croppedSquareImg, nil
+// dashboardSections reports whether the dashboard file declares bangs and search providers, the ones it leaves out are
+// managed in the admin dashboard as usual. The file is read again every time so this is never out of date, and a file
+// that cannot be read is taken to declare both
+func (app *App) dashboardSections() (bangs bool, providers bool) {
+	file, err := dashboard.Load(app.DashboardFile)
+	if err != nil {
+		return true, true
 	}
 
-	outputImg := image.NewRGBA(image.Rect(0, 0, outputSize, outputSize))
+	return file.Bangs != nil, file.SearchProviders != nil
+}
 
-	draw.CatmullRom.Scale(outputImg, outputImg.Rect, croppedSquareImg, croppedSquareImg.Bounds(), draw.Src, nil)
+// dashboardManages reports whether a change made through the API route at path would change what the dashboard file
+// manages
+func (app *App) dashboardManages(path string) bool {
+	switch {
+	case path == "/api/bang" || strings.HasPrefix(path, "/api/bang/"):
+		bangs, _ := app.dashboardSections()
+		return bangs
+	case path == "/api/search-provider" || strings.HasPrefix(path, "/api/search-provider/"):
+		_, providers := app.dashboardSections()
+		return providers
+	}
 
-	return outputImg, nil
+	return true
 }
 
-func UploadFile(file *multipart.FileHeader, contentType string, c fiber.Ctx) (string, error) {
-	fileId, err := uuid.NewV7()
+// syncDashboard applies the dashboard file to the database
+func (app *App) syncDashboard() error {
+	file, err := dashboard.Load(app.DashboardFile)
 	if err != nil {
-		return "", err
-	}
-
-	var fileName string
-	if filepath.Ext(file.Filename) != ".svg" {
-		fileName = fmt.Sprintf("%s.webp", fileId.String())
-	} else {
-		fileName = fmt.Sprintf("%s.svg", fileId.String())
+		return err
 	}
 
-	srcFile, err := file.Open()
+	icons := newDashboardIcons(context.Background(), filepath.Dir(app.DashboardFile), app.DashboardIconURL, app.favicons)
+	changes, err := app.CategoryManager.ApplyDashboard(file, icons)
 	if err != nil {
-		return "", err
+		return err
 	}
-	defer srcFile.Close()
 
-	var img image.Image
-	switch contentType {
-	case "image/jpeg":
-		img, err = jpeg.Decode(srcFile)
-	case "image/png":
-		img, err = png.Decode(srcFile)
-	case "image/webp":
-		img, err = nativewebp.Decode(srcFile)
-	case "image/svg+xml":
-		// does not fall through (my C brain was tripping over this)
-	default:
-		return "", errors.New("unsupported file type")
+	if changes > 0 {
+		slog.Info("Applied dashboard file", "file", app.DashboardFile, "changes", changes)
 	}
 
-	if err != nil {
-		return "", err
-	}
+	return nil
+}
 
-	if contentType != "image/svg+xml" {
-		off, err := srcFile.Seek(0, io.SeekStart)
-		if err != nil {
-			return "", fmt.Errorf("failed to seek to start of file: %v", err)
+// dashboardWorker applies the dashboard file again whenever it changes. A file that cannot be applied is logged and
+// the dashboard stays as it was until the file changes again
+func (app *App) dashboardWorker(last os.FileInfo) {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	var pending os.FileInfo
+	for range ticker.C {
+		// editors often replace the file instead of writing to it, so it can be missing or empty for a moment
+		info, err := os.Stat(app.DashboardFile)
+		if err != nil || info.Size() == 0 {
+			pending = nil
+			continue
 		}
 
-		if off != 0 {
-			return "", fmt.Errorf("failed to seek to start of file: %v", err)
+		if sameFile(info, last) {
+			pending = nil
+			continue
 		}
 
-		x, err := exif.Decode(srcFile)
-		// if there *is* exif, parse it
-		if err == nil {
-			tag, err := x.Get(exif.Orientation)
-			if err == nil {
-				if tag.Count == 1 && tag.Format() == tiff.IntVal {
-					orientation, err := tag.Int(0)
-					if err != nil {
-						return "", fmt.Errorf("failed to get orientation: %v", err)
-					}
-
-					slog.Debug("Orientation tag found", "orientation", orientation)
-
-					switch orientation {
-					case 3:
-						img = imaging.Rotate180(img)
-					case 6:
-						img = imaging.Rotate270(img)
-					case 8:
-						img = imaging.Rotate90(img)
-					}
-				}
-			}
+		// a file that is still being written to is only applied once it has stayed the same for a whole tick, so a half
+		// saved file does not remove everything it has not gotten to yet
+		if !sameFile(info, pending) {
+			pending = info
+			continue
 		}
+		last, pending = info, nil
 
-		img, err = CropToCenter(img, 96)
-		if err != nil {
-			return "", err
+		if err := app.syncDashboard(); err != nil {
+			slog.Error("Failed to apply dashboard file", "file", app.DashboardFile, "error", err)
 		}
 	}
+}
 
-	assetsDir := "public/uploads"
-
-	iconPath := filepath.Join(assetsDir, fileName)
-
-	if contentType == "image/svg+xml" {
-		// replace currentColor with a text color
-		outFile, err := os.Create(iconPath)
-		if err != nil {
-			return "", err
-		}
-		defer outFile.Close()
-
-		svgText, err := io.ReadAll(srcFile)
-		if err != nil {
-			return "", err
-		}
+func sameFile(info os.FileInfo, other os.FileInfo) bool {
+	return other != nil && info.ModTime().Equal(other.ModTime()) && info.Size() == other.Size()
+}
 
-		svgText = bytes.ReplaceAll(svgText, []byte("currentColor"), []byte(`oklch(87% 0.015 286)`))
-		_, err = outFile.Write(svgText)
-		if err != nil {
-			return "", err
-		}
-	} else {
-		outFile, err := os.Create(iconPath)
-		if err != nil {
-			return "", err
+// OpenDB opens the sqlite database at dbPath without touching its schema
+func OpenDB(dbPath string, options map[string]any) (*sql.DB, error) {
+	file, err := os.OpenFile(dbPath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		if os.IsPermission(err) {
+			return nil, fmt.Errorf("file %s is not readable and writable: %v", dbPath, err)
 		}
-		defer outFile.Close()
+		return nil, fmt.Errorf("failed to open file %s for read/write: %v", dbPath, err)
+	}
+	defer file.Close()
 
-		var buf bytes.Buffer
-		options := &nativewebp.Options{}
-		if err := nativewebp.Encode(&buf, img, options); err != nil {
-			return "", err
+	var connectionOpts string
+	for k, v := range options {
+		if connectionOpts != "" {
+			connectionOpts += "&"
 		}
 
-		if _, err := io.Copy(outFile, &buf); err != nil {
-			return "", err
-		}
+		connectionOpts += fmt.Sprintf("%s=%v", k, v)
 	}
 
-	iconPath = "/uploads/" + fileName
-
-	return iconPath, nil
-}
-
-type Category struct {
-	ID    int64  `json:"id"`
-	Name  string `json:"name"`
-	Icon  string `json:"icon"`
-	Links []Link `json:"links"`
-}
-
-type Link struct {
-	ID          int64  `json:"id"`
-	CategoryID  int64  `json:"category_id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Icon        string `json:"icon"`
-	URL         string `json:"url"`
-}
+	db, err := sql.Open("sqlite", fmt.Sprintf("%s?%s", dbPath, connectionOpts))
+	if err != nil {
+		return nil, err
+	}
 
-type CategoryManager struct {
-	db *sql.DB
-}
+	err = db.Ping()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
 
-func NewCategoryManager(db *sql.DB) (*CategoryManager, error) {
-	return &CategoryManager{
-		db: db,
-	}, nil
+	return db, nil
 }
 
-func (manager *CategoryManager) GetCategories() []Category {
-	rows, err := manager.db.Query(`
-		SELECT id, name, icon
-		FROM categories 
-		ORDER BY id ASC
-	`)
-
+// referencedUploads returns the file names of every uploaded icon the database refers to, trashed items included
+func referencedUploads(q querier) (map[string]bool, error) {
+	rows, err := q.Query(`SELECT icon FROM categories UNION SELECT icon FROM links UNION SELECT icon FROM search_providers`)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 	defer rows.Close()
 
-	var categories []Category
+	uploads := map[string]bool{}
 	for rows.Next() {
-		var cat Category
-
-		if err := rows.Scan(&cat.ID, &cat.Name, &cat.Icon); err != nil {
-			return nil
+		var icon string
+		if err := rows.Scan(&icon); err != nil {
+			return nil, err
 		}
 
-		categories = append(categories, cat)
-	}
-
-	for i, cat := range categories {
-		categories[i].Links = manager.GetLinks(cat.ID)
+		if name, ok := strings.CutPrefix(icon, "/uploads/"); ok && name != "" && !strings.Contains(name, "/") {
+			uploads[name] = true
+		}
 	}
 
-	return categories
+	return uploads, rows.Err()
 }
 
-// Get Category by ID, returns nil if not found
-func (manager *CategoryManager) GetCategory(id int64) *Category {
-	row := manager.db.QueryRow(`SELECT id, name, icon FROM categories WHERE id = ?`, id)
+// createBackup writes a backup archive of the database and every icon it refers to. Sessions are left out, they are
+// credentials and would not be restored anyway
+func createBackup(db *sql.DB, w io.Writer) error {
+	dir, err := os.MkdirTemp("", "passport-backup-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
 
-	var cat Category
-	if err := row.Scan(&cat.ID, &cat.Name, &cat.Icon); err != nil {
-		return nil
+	// VACUUM INTO reads the database in a single transaction, so the snapshot is consistent even while it is written to
+	snapshotPath := filepath.Join(dir, backup.DatabaseFile)
+	if _, err := db.Exec(`VACUUM INTO ?`, snapshotPath); err != nil {
+		return fmt.Errorf("failed to snapshot database: %w", err)
 	}
 
-	return &cat
-}
+	snapshot, err := OpenDB(snapshotPath, nil)
+	if err != nil {
+		return err
+	}
+	defer snapshot.Close()
 
-func (manager *CategoryManager) CreateCategory(category Category) (*Category, error) {
-	var err error
+	// vacuuming again makes sure the deleted sessions are gone from the file, not just unreachable
+	if _, err := snapshot.Exec(`DELETE FROM sessions`); err != nil {
+		return err
+	}
 
-	insertCategoryStmt, err = manager.db.Prepare(`
-		INSERT INTO categories (name, icon) 
-		VALUES (?, ?) RETURNING id`)
+	if _, err := snapshot.Exec(`VACUUM`); err != nil {
+		return err
+	}
 
+	version, err := migrations.CurrentVersion(snapshot)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	defer insertCategoryStmt.Close()
-
-	var categoryID int64
+	referenced, err := referencedUploads(snapshot)
+	if err != nil {
+		return err
+	}
 
-	if err := insertCategoryStmt.QueryRow(category.Name, category.Icon).Scan(&categoryID); err != nil {
-		return nil, err
+	if err := snapshot.Close(); err != nil {
+		return err
 	}
 
-	category.ID = categoryID
+	var uploads []string
+	for name := range referenced {
+		if _, err := os.Stat(filepath.Join("public/uploads", name)); err != nil {
+			slog.Warn("Icon is missing and left out of the backup", "icon", name)
+			continue
+		}
 
-	return &category, nil
+		uploads = append(uploads, name)
+	}
+	slices.Sort(uploads)
+
+	return backup.Write(w, version, snapshotPath, "public/uploads", uploads)
 }
 
-func (manager *CategoryManager) DeleteCategory(id int64) error {
-	rows, err := manager.db.Query(`
-				SELECT icon FROM categories WHERE id = ?
-				UNION
-				SELECT icon FROM links WHERE category_id = ?
-			`, id, id)
+// restoreBackup replaces the contents of the database and the icons with a backup archive. Nothing is changed unless the
+// whole archive is valid, and the database is swapped in a single transaction, so passport can keep running. Sessions
+// are kept, so whoever restored the backup stays logged in
+func restoreBackup(db *sql.DB, r io.Reader) error {
+	dir, err := os.MkdirTemp("", "passport-restore-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
 
+	manifest, err := backup.Extract(r, dir)
 	if err != nil {
 		return err
 	}
 
-	defer rows.Close()
+	latest, err := migrations.Latest()
+	if err != nil {
+		return err
+	}
 
-	var icons []string
-	for rows.Next() {
-		var icon string
-		if err := rows.Scan(&icon); err != nil {
-			return err
-		}
-		icons = append(icons, icon)
+	if manifest.SchemaVersion > latest {
+		return fmt.Errorf("%w (backup is at version %d, latest known version is %d)", migrations.ErrDatabaseTooNew, manifest.SchemaVersion, latest)
 	}
 
-	tx, err := manager.db.Begin()
+	snapshotPath := filepath.Join(dir, backup.DatabaseFile)
+	snapshot, err := OpenDB(snapshotPath, nil)
 	if err != nil {
 		return err
 	}
-	defer tx.Rollback()
+	defer snapshot.Close()
 
-	_, err = tx.Exec("DELETE FROM categories WHERE id = ?", id)
-	if err != nil {
+	var integrity string
+	if err := snapshot.QueryRow(`PRAGMA integrity_check`).Scan(&integrity); err != nil || integrity != "ok" {
+		return fmt.Errorf("%w: database is corrupt", backup.ErrInvalidArchive)
+	}
+
+	// older backups are brought up to date first, so every table lines up with ours
+	if _, err := migrations.Up(snapshot); err != nil {
 		return err
 	}
 
-	_, err = tx.Exec("DELETE FROM links WHERE category_id = ?", id)
+	restored, err := referencedUploads(snapshot)
 	if err != nil {
 		return err
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := snapshot.Close(); err != nil {
 		return err
 	}
 
-	for _, icon := range icons {
-		if icon == "" {
-			slog.Debug("blank icon")
+	// icons have unique names, so the new ones can be put in place before the database refers to them
+	var added []string
+	for name := range restored {
+		target := filepath.Join("public/uploads", name)
+		if _, err := os.Stat(target); err == nil {
 			continue
 		}
 
-		if err := os.Remove(filepath.Join("public/", icon)); err != nil {
-			// dont error to the user if the icon doesnt exist, just log it
-			slog.Error("Failed to delete icon", "icon", icon, "error", err)
+		data, err := os.ReadFile(filepath.Join(dir, backup.UploadsDir, name))
+		if err != nil {
+			slog.Warn("Icon is missing from the backup", "icon", name)
+			continue
 		}
-	}
-
-	return nil
-}
 
-func (manager *CategoryManager) GetLink(id int64) *Link {
-	row := manager.db.QueryRow(`SELECT id, category_id, name, description, icon, url FROM links WHERE id = ?`, id)
+		if err := os.WriteFile(target, data, 0644); err != nil {
+			for _, name := range added {
+				os.Remove(filepath.Join("public/uploads", name))
+			}
+			return err
+		}
 
-	var link Link
-	if err := row.Scan(&link.ID, &link.CategoryID, &link.Name, &link.Description, &link.Icon, &link.URL); err != nil {
-		return nil
+		added = append(added, name)
 	}
 
-	return &link
-}
-
-func (manager *CategoryManager) GetLinks(categoryID int64) []Link {
-	rows, err := manager.db.Query(`
-		SELECT id, category_id, name, description, icon, url 
-		FROM links 
-		WHERE category_id = ? 
-		ORDER BY id ASC
-	`, categoryID)
-
+	previous, err := swapDatabase(db, snapshotPath)
 	if err != nil {
-		return nil
+		for _, name := range added {
+			os.Remove(filepath.Join("public/uploads", name))
+		}
+		return err
 	}
-	defer rows.Close()
 
-	var links []Link
-	for rows.Next() {
-		var link Link
-		if err := rows.Scan(&link.ID, &link.CategoryID, &link.Name, &link.Description,
-			&link.Icon, &link.URL); err != nil {
-			return nil
+	// icons only the old data used are not needed anymore
+	for name := range previous {
+		if !restored[name] {
+			if err := os.Remove(filepath.Join("public/uploads", name)); err != nil && !os.IsNotExist(err) {
+				slog.Error("Failed to delete icon", "icon", name, "error", err)
+			}
 		}
-		links = append(links, link)
 	}
 
-	return links
+	return nil
 }
 
-func (manager *CategoryManager) CreateLink(db *sql.DB, link Link) (*Link, error) {
-	var err error
-	insertLinkStmt, err = db.Prepare(`
-		INSERT INTO links (category_id, name, description, icon, url) 
-		VALUES (?, ?, ?, ?, ?) RETURNING id`)
+// swapDatabase replaces every table apart from sessions and the schema version with the ones in the database at
+// snapshotPath, and returns the icons the replaced data referred to
+func swapDatabase(db *sql.DB, snapshotPath string) (map[string]bool, error) {
+	ctx := context.Background()
+
+	// attached databases belong to a single connection
+	conn, err := db.Conn(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer conn.Close()
 
-	defer insertLinkStmt.Close()
-
-	var linkID int64
-	if err := insertLinkStmt.QueryRow(link.CategoryID, link.Name, link.Description, link.Icon, link.URL).Scan(&linkID); err != nil {
+	if _, err := conn.ExecContext(ctx, `ATTACH DATABASE ? AS snapshot`, snapshotPath); err != nil {
 		return nil, err
 	}
+	defer conn.ExecContext(ctx, `DETACH DATABASE snapshot`)
 
-	link.ID = linkID
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
 
-	return &link, nil
-}
+	// rows are deleted and inserted in whatever order, the foreign keys only have to hold once everything is in place
+	if _, err := tx.Exec(`PRAGMA defer_foreign_keys = ON`); err != nil {
+		return nil, err
+	}
 
-func (manager *CategoryManager) DeleteLink(id any) error {
-	var icon string
-	if err := manager.db.QueryRow("SELECT icon FROM links WHERE id = ?", id).Scan(&icon); err != nil {
-		return err
+	previous, err := referencedUploads(tx)
+	if err != nil {
+		return nil, err
 	}
 
-	_, err := manager.db.Exec("DELETE FROM links WHERE id = ?", id)
+	tables, err := tx.Query(`
+		SELECT name FROM main.sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name NOT IN ('sessions', 'schema_version')
+	`)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if icon != "" {
-		if err := os.Remove(filepath.Join("public/", icon)); err != nil {
-			slog.Error("Failed to delete icon", "icon", icon, "error", err)
+	var names []string
+	for tables.Next() {
+		var name string
+		if err := tables.Scan(&name); err != nil {
+			tables.Close()
+			return nil, err
 		}
+		names = append(names, name)
 	}
+	tables.Close()
 
-	return nil
-}
+	// every table is emptied before anything is copied, since deletes cascade into tables that were already copied
+	for _, table := range names {
+		if _, err := tx.Exec(fmt.Sprintf(`DELETE FROM main.%q`, table)); err != nil {
+			return nil, err
+		}
+	}
 
-var WeatherIcons = map[string]string{
-	"clear-day":           `<svg aria-label="Clear day" xmlns="http://www.w3.org/2000/svg" width="32" height="32" viewBox="0 0 32 32"><path fill="currentColor" d="M16 12.005a4 4 0 1 1-4 4a4.005 4.005 0 0 1 4-4m0-2a6 6 0 1 0 6 6a6 6 0 0 0-6-6M5.394 6.813L6.81 5.399l3.505 3.506L8.9 10.319zM2 15.005h5v2H2zm3.394 10.193L8.9 21.692l1.414 1.414l-3.505 3.506zM15 25.005h2v5h-2zm6.687-1.9l1.414-1.414l3.506 3.506l-1.414 1.414zm3.313-8.1h5v2h-5zm-3.313-6.101l3.506-3.506l1.414 1.414l-3.506 3.506zM15 2.005h2v5h-2z"/></svg>`,
-	"clear-night":         `<svg aria-label="Clear night" xmlns="http://www.w3.org/2000/svg" width="32" height="32" viewBox="0 0 32 32"><path fill="currentColor" d="M13.503 5.414a15.076 15.076 0 0 0 11.593 18.194a11.1 11.1 0 0 1-7.975 3.39c-.138 0-.278.005-.418 0a11.094 11.094 0 0 1-3.2-21.584M14.98 3a1 1 0 0 0-.175.016a13.096 13.096 0 0 0 1.825 25.981c.164.006.328 0 .49 0a13.07 13.07 0 0 0 10.703-5.555a1.01 1.01 0 0 0-.783-1.565A13.08 13.08 0 0 1 15.89 4.38A1.015 1.015 0 0 0 14.98 3"/></svg>`,
-	"partly-cloudy-day":   `<svg aria-label="Partly cloudy day" xmlns="http://www.w3.org/2000/svg" width="32" height="32" viewBox="0 0 32 32"><path fill="currentColor" d="M27 15h4v2h-4zm-4-7.413l3-3L27.415 6l-3 3zM15 1h2v4h-2zM4.586 26l3-3l1.415 1.415l-3 3zM4.585 6L6 4.587l3 3l-1.414 1.415z"/><path fill="currentColor" d="M1 15h4v2H1zm25.794 5.342a6.96 6.96 0 0 0-1.868-3.267A9 9 0 0 0 25 16a9 9 0 1 0-14.585 7.033A4.977 4.977 0 0 0 15 30h10a4.995 4.995 0 0 0 1.794-9.658M9 16a6.996 6.996 0 0 1 13.985-.297A6.9 6.9 0 0 0 20 15a7.04 7.04 0 0 0-6.794 5.342a5 5 0 0 0-1.644 1.048A6.97 6.97 0 0 1 9 16m16 12H15a2.995 2.995 0 0 1-.696-5.908l.658-.157l.099-.67a4.992 4.992 0 0 1 9.878 0l.099.67l.658.156A2.995 2.995 0 0 1 25 28"/></svg>`,
-	"partly-cloudy-night": `<svg aria-label="Partly cloudy night" xmlns="http://www.w3.org/2000/svg" width="32" height="32" viewBox="0 0 32 32"><path fill="currentColor" d="M30 19a4.97 4.97 0 0 0-3.206-4.658A6.971 6.971 0 0 0 13.758 12.9a13.14 13.14 0 0 1 .131-8.52A1.015 1.015 0 0 0 12.98 3a1 1 0 0 0-.175.016a13.096 13.096 0 0 0 1.825 25.981c.164.006.328 0 .49 0a13.04 13.04 0 0 0 10.29-5.038A4.99 4.99 0 0 0 30 19m-15.297 7.998a11.095 11.095 0 0 1-3.2-21.584a15.2 15.2 0 0 0 .844 9.367A4.988 4.988 0 0 0 15 24h7.677a11.1 11.1 0 0 1-7.556 2.998c-.138 0-.278.004-.418 0M25 22H15a2.995 2.995 0 0 1-.696-5.908l.658-.157l.099-.67a4.992 4.992 0 0 1 9.878 0l.099.67l.658.157A2.995 2.995 0 0 1 25 22"/></svg>`,
-	"mostly-cloudy-day":   `<svg aria-label="Mostly cloudy day" xmlns="http://www.w3.org/2000/svg" width="32" height="32" viewBox="0 0 32 32"><path fill="currentColor" d="M21.743 18.692a6 6 0 0 0 1.057-1.086a5.998 5.998 0 1 0-10.733-4.445A7.56 7.56 0 0 0 6.35 18.25A5.993 5.993 0 0 0 8 30.005h11a5.985 5.985 0 0 0 2.743-11.313M18 10.005a4.004 4.004 0 0 1 4 4a3.96 3.96 0 0 1-.8 2.4a4 4 0 0 1-.94.891a7.54 7.54 0 0 0-6.134-4.24A4 4 0 0 1 18 10.006m1 18H8a3.993 3.993 0 0 1-.673-7.93l.663-.112l.146-.656a5.496 5.496 0 0 1 10.729 0l.146.656l.662.112a3.993 3.993 0 0 1-.673 7.93m7-15.001h4v2h-4zM22.95 7.64l2.828-2.827l1.415 1.414l-2.829 2.828zM17 2.005h2v4h-2zM8.808 6.227l1.414-1.414l2.829 2.828l-1.415 1.414z"/></svg>`,
-	"mostly-cloudy-night": `<svg aria-label="Mostly cloudy night" xmlns="http://www.w3.org/2000/svg" width="32" height="32" viewBox="0 0 32 32"><path fill="currentColor" d="M29.844 15.035a1.52 1.52 0 0 0-1.23-.866a5.36 5.36 0 0 1-3.41-1.716a6.47 6.47 0 0 1-1.286-6.392a1.6 1.6 0 0 0-.299-1.546a1.45 1.45 0 0 0-1.36-.493l-.019.003a7.93 7.93 0 0 0-6.22 7.431A7.4 7.4 0 0 0 13.5 11a7.55 7.55 0 0 0-7.15 5.244A5.993 5.993 0 0 0 8 28h11a5.977 5.977 0 0 0 5.615-8.088a7.5 7.5 0 0 0 5.132-3.357a1.54 1.54 0 0 0 .097-1.52M19 26H8a3.993 3.993 0 0 1-.673-7.93l.663-.112l.145-.656a5.496 5.496 0 0 1 10.73 0l.145.656l.663.113A3.993 3.993 0 0 1 19 26m4.465-8.001h-.021a5.96 5.96 0 0 0-2.795-1.755a7.5 7.5 0 0 0-2.6-3.677c-.01-.101-.036-.197-.041-.3a6.08 6.08 0 0 1 3.79-6.05a8.46 8.46 0 0 0 1.94 7.596a7.4 7.4 0 0 0 3.902 2.228a5.43 5.43 0 0 1-4.175 1.958"/></svg>`,
-	"light-rain":          `<svg aria-label="Light rain" xmlns="http://www.w3.org/2000/svg" width="32" height="32" viewBox="0 0 32 32"><path fill="currentColor" d="M11 30a1 1 0 0 1-.894-1.447l2-4a1 1 0 1 1 1.788.894l-2 4A1 1 0 0 1 11 30"/><path fill="currentColor" d="M24.8 9.136a8.994 8.994 0 0 0-17.6 0A6.497 6.497 0 0 0 8.5 22h10.881l-1.276 2.553a1 1 0 0 0 1.789.894L21.618 22H23.5a6.497 6.497 0 0 0 1.3-12.864M23.5 20h-15a4.498 4.498 0 0 1-.356-8.981l.816-.064l.099-.812a6.994 6.994 0 0 1 13.883 0l.099.812l.815.064A4.498 4.498 0 0 1 23.5 20"/></svg>`,
-	"rain":                `<svg aria-label="Rain" xmlns="http://www.w3.org/2000/svg" width="32" height="32" viewBox="0 0 32 32"><path fill="currentColor" d="M23.5 22h-15A6.5 6.5 0 0 1 7.2 9.14a9 9 0 0 1 17.6 0A6.5 6.5 0 0 1 23.5 22M16 4a7 7 0 0 0-6.94 6.14L9 11h-.86a4.5 4.5 0 0 0 .36 9h15a4.5 4.5 0 0 0 .36-9H23l-.1-.82A7 7 0 0 0 16 4m-2 26a.93.93 0 0 1-.45-.11a1 1 0 0 1-.44-1.34l2-4a1 1 0 1 1 1.78.9l-2 4A1 1 0 0 1 14 30m6 0a.93.93 0 0 1-.45-.11a1 1 0 0 1-.44-1.34l2-4a1 1 0 1 1 1.78.9l-2 4A1 1 0 0 1 20 30M8 30a.93.93 0 0 1-.45-.11a1 1 0 0 1-.44-1.34l2-4a1 1 0 1 1 1.78.9l-2 4A1 1 0 0 1 8 30"/></svg>`,
-	"thunder":             `<svg aria-label="Thunder" xmlns="http://www.w3.org/2000/svg" width="32" height="32" viewBox="0 0 32 32"><path fill="currentColor" d="M21 30a1 1 0 0 1-.894-1.447l2-4a1 1 0 1 1 1.788.894l-2 4A1 1 0 0 1 21 30M9 32a1 1 0 0 1-.894-1.447l2-4a1 1 0 1 1 1.788.894l-2 4A1 1 0 0 1 9 32m6.901-1.504l-1.736-.992L17.31 24h-6l4.855-8.496l1.736.992L14.756 22h6.001z"/><path fill="currentColor" d="M24.8 9.136a8.994 8.994 0 0 0-17.6 0a6.493 6.493 0 0 0 .23 12.768l-1.324 2.649a1 1 0 1 0 1.789.894l2-4a1 1 0 0 0-.447-1.341A1 1 0 0 0 9 20.01V20h-.5a4.498 4.498 0 0 1-.356-8.981l.816-.064l.099-.812a6.994 6.994 0 0 1 13.883 0l.099.812l.815.064A4.498 4.498 0 0 1 23.5 20H23v2h.5a6.497 6.497 0 0 0 1.3-12.864"/></svg>`,
-	"snow":                `<svg aria-label="Snow" xmlns="http://www.w3.org/2000/svg" width="32" height="32" viewBox="0 0 32 32"><path fill="currentColor" d="M23.5 22h-15A6.5 6.5 0 0 1 7.2 9.14a9 9 0 0 1 17.6 0A6.5 6.5 0 0 1 23.5 22M16 4a7 7 0 0 0-6.94 6.14L9 11h-.86a4.5 4.5 0 0 0 .36 9h15a4.5 4.5 0 0 0 .36-9H23l-.1-.82A7 7 0 0 0 16 4m-4 21.05L10.95 24L9.5 25.45L8.05 24L7 25.05l1.45 1.45L7 27.95L8.05 29l1.45-1.45L10.95 29L12 27.95l-1.45-1.45zm14 0L24.95 24l-1.45 1.45L22.05 24L21 25.05l1.45 1.45L21 27.95L22.05 29l1.45-1.45L24.95 29L26 27.95l-1.45-1.45zm-7 2L17.95 26l-1.45 1.45L15.05 26L14 27.05l1.45 1.45L14 29.95L15.05 31l1.45-1.45L17.95 31L19 29.95l-1.45-1.45z"/></svg>`,
-	"mist":                `<svg aria-label="Mist" xmlns="http://www.w3.org/2000/svg" width="32" height="32" viewBox="0 0 32 32"><path fill="currentColor" d="M24.8 11.138a8.994 8.994 0 0 0-17.6 0A6.53 6.53 0 0 0 2 17.5V19a1 1 0 0 0 1 1h12a1 1 0 0 0 0-2H4v-.497a4.52 4.52 0 0 1 4.144-4.482l.816-.064l.099-.812a6.994 6.994 0 0 1 13.883 0l.099.813l.815.063A4.496 4.496 0 0 1 23.5 22H7a1 1 0 0 0 0 2h16.5a6.496 6.496 0 0 0 1.3-12.862"/><rect width="18" height="2" x="2" y="26" fill="currentColor" rx="1"/></svg>`,
-}
+	for _, table := range names {
+		rows, err := tx.Query(`SELECT name FROM pragma_table_info(?)`, table)
+		if err != nil {
+			return nil, err
+		}
 
-func getWeatherIcon(iconId string) string {
-	switch iconId {
-	case "01d":
-		return WeatherIcons["clear-day"]
-	case "01n":
-		return WeatherIcons["clear-night"]
-	case "02d", "03d":
-		return WeatherIcons["partly-cloudy-day"]
-	case "02n", "03n":
-		return WeatherIcons["partly-cloudy-night"]
-	case "04d":
-		return WeatherIcons["mostly-cloudy-day"]
-	case "04n":
-		return WeatherIcons["mostly-cloudy-night"]
-	case "09d", "09n":
-		return WeatherIcons["light-rain"]
-	case "10d", "10n":
-		return WeatherIcons["rain"]
-	case "11d", "11n":
-		return WeatherIcons["thunder"]
-	case "13d", "13n":
-		return WeatherIcons["snow"]
-	case "50d", "50n":
-		return WeatherIcons["mist"]
-	default:
-		return ""
+		var columns []string
+		for rows.Next() {
+			var column string
+			if err := rows.Scan(&column); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			columns = append(columns, fmt.Sprintf("%q", column))
+		}
+		rows.Close()
+
+		columnList := strings.Join(columns, ", ")
+		if _, err := tx.Exec(fmt.Sprintf(`INSERT INTO main.%q (%s) SELECT %s FROM snapshot.%q`, table, columnList, columnList, table)); err != nil {
+			return nil, fmt.Errorf("failed to restore %s: %w", table, err)
+		}
 	}
-}
 
-func init() {
-	godotenv.Load()
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return previous, nil
 }
 
-func main() {
-	if err := os.MkdirAll("public/uploads", 0755); err != nil {
-		log.Fatal(err)
-	}
+// runCommand handles the subcommands passport can be started with instead of the web server
+func runCommand(dbPath string, args []string) error {
+	switch args[0] {
+	case "migrate":
+		if len(args) < 2 {
+			return errors.New("usage: passport migrate <status|up>")
+		}
 
-	dbPath, err := filepath.Abs("passport.db")
-	if err != nil {
-		log.Fatal(err)
-	}
+		db, err := OpenDB(dbPath, dbOptions)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
 
-	app, err := NewApp(dbPath, map[string]any{
-		"_time_format":  "sqlite",
-		"cache":         "shared",
-		"mode":          "rwc",
-		"_journal_mode": "WAL",
-	})
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer app.Close()
+		switch args[1] {
+		case "status":
+			statuses, err := migrations.GetStatus(db)
+			if err != nil {
+				return err
+			}
 
-	go func() {
-		c := make(chan os.Signal, 1)
-		signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
-		<-c
-		app.Close()
-		os.Exit(0)
-	}()
+			current, err := migrations.CurrentVersion(db)
+			if err != nil {
+				return err
+			}
 
-	templatesDir, err := fs.Sub(embeddedAssets, "templates")
-	if err != nil {
-		log.Fatal(err)
-	}
+			fmt.Printf("Database version: %d (latest: %d)\n\n", current, len(statuses))
+			for _, status := range statuses {
+				state := "pending"
+				switch {
+				case status.Applied && status.AppliedAt == "":
+					// the database was created before migrations were tracked, migrate up records them
+					state = "applied"
+				case status.Applied:
+					state = "applied " + status.AppliedAt
+				}
+				fmt.Printf("  %04d_%-24s %s\n", status.Version, status.Name, state)
+			}
 
-	assetsDir, err := fs.Sub(embeddedAssets, "assets")
-	if err != nil {
-		log.Fatal(err)
-	}
+			if current > len(statuses) {
+				fmt.Printf("\n%v\n", migrations.ErrDatabaseTooNew)
+			}
 
-	engine := handlebars.NewFileSystem(http.FS(templatesDir), ".hbs")
+			return nil
+		case "up":
+			applied, err := migrations.Up(db)
+			for _, migration := range applied {
+				fmt.Printf("Applied %04d_%s\n", migration.Version, migration.Name)
+			}
+			if err != nil {
+				return err
+			}
 
-	engine.AddFunc("embedFile", func(fileToEmbed string) string {
-		content, err := fs.ReadFile(embeddedAssets, fileToEmbed)
+			if len(applied) == 0 {
+				fmt.Println("Database is already up to date")
+			}
+
+			return nil
+		default:
+			return fmt.Errorf("unknown migrate command %q, expected status or up", args[1])
+		}
+	case "backup":
+		db, err := OpenDB(dbPath, dbOptions)
 		if err != nil {
-			return ""
+			return err
 		}
+		defer db.Close()
 
-		fileExtension := filepath.Ext(fileToEmbed)
+		name := "passport-backup-" + time.Now().Format("20060102-150405") + ".tar.gz"
+		if len(args) > 1 {
+			name = args[1]
+		}
 
-		switch fileExtension {
-		case ".js":
-			return fmt.Sprintf("<script>%s</script>", content)
-		case ".css":
-			return fmt.Sprintf("<style>%s</style>", content)
-		default:
-			return string(content)
+		if name == "-" {
+			return createBackup(db, os.Stdout)
 		}
-	})
 
-	engine.AddFunc("devContent", func() string {
-		if app.Config.DevMode {
-			return devContent
+		out, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			return err
 		}
-		return ""
-	})
 
-	router := fiber.New(fiber.Config{
-		Views: engine,
-	})
+		if err := createBackup(db, out); err != nil {
+			out.Close()
+			os.Remove(name)
+			return err
+		}
 
-	router.Use(helmet.New(helmet.ConfigDefault))
+		if err := out.Close(); err != nil {
+			return err
+		}
 
-	// redirect /favicon.ico to /assets/favicon.ico
-	router.Get("/favicon.ico", func(c fiber.Ctx) error {
-		return c.Redirect().To("/assets/favicon.ico")
-	})
+		fmt.Printf("Wrote backup to %s\n", name)
+		return nil
+	case "export-yaml":
+		name := "passport.yaml"
+		if len(args) > 1 {
+			name = args[1]
+		}
 
-	router.Use(compress.New(compress.Config{
-		Level: compress.LevelBestSpeed,
-	}))
+		db, err := OpenDB(dbPath, dbOptions)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
 
-	router.Use(gonify.New(gonify.Config{
-		MinifySVG:  !app.DevMode,
-		MinifyCSS:  !app.DevMode,
-		MinifyJS:   !app.DevMode,
-		MinifyHTML: !app.DevMode,
-	}))
+		// icon sources are only known to databases that are up to date
+		if _, err := migrations.Up(db); err != nil {
+			return err
+		}
 
-	router.Use("/", static.New("./public", static.Config{
-		Browse: false,
-		MaxAge: 31536000,
-	}))
+		manager, err := NewCategoryManager(db)
+		if err != nil {
+			return err
+		}
 
-	router.Use("/assets", static.New("", static.Config{
-		FS:     assetsDir,
-		Browse: false,
-		MaxAge: 31536000,
-	}))
+		out, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			return err
+		}
 
-	router.Get("/", func(c fiber.Ctx) error {
-		c.Response().Header.Set("Link", "</assets/fonts/InstrumentSans-VariableFont_wdth,wght.woff2>; rel=preload; as=font; type=font/woff2; crossorigin")
+		file, err := manager.ExportDashboard(filepath.Dir(name))
+		if err == nil {
+			err = dashboard.Write(out, file)
+		}
+		if err != nil {
+			out.Close()
+			os.Remove(name)
+			return err
+		}
 
-		renderData := fiber.Map{
-			"SearchProviderURL": app.Config.SearchProvider.URL,
-			"SearchParam":       app.Config.SearchProvider.Query,
-			"Categories":        app.CategoryManager.GetCategories(),
+		if err := out.Close(); err != nil {
+			return err
 		}
 
-		if app.Config.WeatherAPIKey != "" {
-			weather := app.WeatherManager.GetWeather()
+		fmt.Printf("Wrote dashboard to %s, set PASSPORT_DASHBOARD_FILE to it to manage passport with it\n", name)
+		return nil
+	case "import":
+		if len(args) < 2 {
+			return errors.New("usage: passport import <file> [board]")
+		}
 
-			renderData["WeatherData"] = fiber.Map{
-				"Temp": weather.Temperature,
-				"Desc": weather.WeatherText,
-				"Icon": getWeatherIcon(weather.Icon),
-			}
+		config, err := ParseConfig()
+		if err != nil {
+			return err
 		}
 
-		if app.Config.UptimeAPIKey != "" {
-			renderData["UptimeData"] = app.UptimeManager.GetUptime()
+		if config.DashboardFile != "" {
+			return fmt.Errorf("passport is managed by %s, add the links to it instead", config.DashboardFile)
 		}
 
-		return c.Render("views/index", renderData)
-	})
+		data, err := os.ReadFile(args[1])
+		if err != nil {
+			return err
+		}
 
-	router.Use(middleware.AdminMiddleware(app.db))
+		folders, format, err := parseImport(data, "")
+		if err != nil {
+			return err
+		}
 
-	router.Get("/admin/login", func(c fiber.Ctx) error {
-		if c.Locals("IsAdmin") != nil {
-			return c.Redirect().To("/admin")
+		db, err := OpenDB(dbPath, dbOptions)
+		if err != nil {
+			return err
 		}
+		defer db.Close()
 
-		return c.Render("views/admin/login", fiber.Map{})
-	})
+		if _, err := migrations.Up(db); err != nil {
+			return err
+		}
 
-	router.Post("/admin/login", func(c fiber.Ctx) error {
-		if c.Locals("IsAdmin") != nil {
-			return c.Redirect().To("/admin")
+		manager, err := NewCategoryManager(db)
+		if err != nil {
+			return err
 		}
 
-		var loginData struct {
-			Username string `json:"username"`
-			Password string `json:"password"`
+		board := manager.GetDefaultBoard()
+		if len(args) > 2 {
+			board = manager.GetBoardBySlug(args[2])
 		}
-		if err := c.Bind().JSON(&loginData); err != nil {
+		if board == nil {
+			return errors.New("board not found")
+		}
+
+		var icons *dashboardIcons
+		if format != importFormatBookmarks {
+			icons = newDashboardIcons(context.Background(), "", config.DashboardIconURL, services.NewFaviconFinder())
+		}
+
+		plan := manager.PlanImport(board.ID, folders)
+		categories, links, err := manager.ApplyImport(audit.Actor{}, board.ID, plan, VisibilityPublic, false, icons)
+		if err != nil {
 			return err
 		}
 
-		// possible vulnerable to timing attacks
-		if loginData.Username != app.Config.Admin.Username || loginData.Password != app.Config.Admin.Password {
-			return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"message": "Invalid username or password"})
+		fmt.Printf("Imported %d links into %d new categories on %s from a %s file, %d duplicates and %d unsupported links were skipped\n", links, categories, board.Title, format, plan.Duplicates, plan.Skipped)
+		return nil
+	case "restore":
+		if len(args) < 2 {
+			return errors.New("usage: passport restore <file>")
 		}
 
-		// Create new session
-		sessionID := uuid.NewString()
-		expiresAt := time.Now().Add(time.Hour * 24 * 7)
-		_, err := app.db.Exec(`
-			INSERT INTO sessions (session_id, expires_at) 
-			VALUES (?, ?)
-		`, sessionID, expiresAt)
+		in, err := os.Open(args[1])
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		db, err := OpenDB(dbPath, dbOptions)
 		if err != nil {
 			return err
 		}
+		defer db.Close()
+
+		// the live database has to be at the latest version too, or its tables would not line up with the backup's
+		if _, err := migrations.Up(db); err != nil {
+			return err
+		}
+
+		if err := restoreBackup(db, in); err != nil {
+			return err
+		}
+
+		fmt.Printf("Restored backup from %s\n", args[1])
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+func CropToCenter(img image.Image, outputSize int) (image.Image, error) {
+	if img == nil {
+		return nil, fmt.Errorf("input image is nil")
+	}
+	if outputSize <= 0 {
+		return nil, fmt.Errorf("output size must be positive")
+	}
+
+	srcBounds := img.Bounds()
+	srcWidth := srcBounds.Dx()
+	srcHeight := srcBounds.Dy()
+
+	squareSide := min(srcWidth, srcHeight)
+
+	cropX := (srcWidth - squareSide) / 2
+	cropY := (srcHeight - squareSide) / 2
+
+	srcCropRect := image.Rect(cropX, cropY, cropX+squareSide, cropY+squareSide)
+
+	croppedSquareImg := image.NewRGBA(image.Rect(0, 0, squareSide, squareSide))
+	draw.Draw(croppedSquareImg, croppedSquareImg.Rect, img, srcCropRect.Min, draw.Src)
+
+	if squareSide == outputSize {
+		return croppedSquareImg, nil
+	}
+
+	outputImg := image.NewRGBA(image.Rect(0, 0, outputSize, outputSize))
+
+	draw.CatmullRom.Scale(outputImg, outputImg.Rect, croppedSquareImg, croppedSquareImg.Bounds(), draw.Src, nil)
+
+	return outputImg, nil
+}
+
+func UploadFile(file *multipart.FileHeader, contentType string, c fiber.Ctx) (string, error) {
+	srcFile, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer srcFile.Close()
+
+	return saveIcon(srcFile, contentType)
+}
+
+// saveIcon writes an icon to the uploads directory, images are cropped to a 96x96 WebP and SVGs are kept as they are
+func saveIcon(srcFile io.ReadSeeker, contentType string) (string, error) {
+	fileId, err := uuid.NewV7()
+	if err != nil {
+		return "", err
+	}
+
+	var fileName string
+	if contentType != "image/svg+xml" {
+		fileName = fmt.Sprintf("%s.webp", fileId.String())
+	} else {
+		fileName = fmt.Sprintf("%s.svg", fileId.String())
+	}
+
+	var img image.Image
+	switch contentType {
+	case "image/jpeg":
+		img, err = jpeg.Decode(srcFile)
+	case "image/png":
+		img, err = png.Decode(srcFile)
+	case "image/webp":
+		img, err = nativewebp.Decode(srcFile)
+	case "image/svg+xml":
+		// does not fall through (my C brain was tripping over this)
+	default:
+		return "", errors.New("unsupported file type")
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if contentType != "image/svg+xml" {
+		off, err := srcFile.Seek(0, io.SeekStart)
+		if err != nil {
+			return "", fmt.Errorf("failed to seek to start of file: %v", err)
+		}
+
+		if off != 0 {
+			return "", fmt.Errorf("failed to seek to start of file: %v", err)
+		}
+
+		x, err := exif.Decode(srcFile)
+		// if there *is* exif, parse it
+		if err == nil {
+			tag, err := x.Get(exif.Orientation)
+			if err == nil {
+				if tag.Count == 1 && tag.Format() == tiff.IntVal {
+					orientation, err := tag.Int(0)
+					if err != nil {
+						return "", fmt.Errorf("failed to get orientation: %v", err)
+					}
+
+					slog.Debug("Orientation tag found", "orientation", orientation)
+
+					switch orientation {
+					case 3:
+						img = imaging.Rotate180(img)
+					case 6:
+						img = imaging.Rotate270(img)
+					case 8:
+						img = imaging.Rotate90(img)
+					}
+				}
+			}
+		}
+
+		img, err = CropToCenter(img, 96)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	assetsDir := "public/uploads"
+
+	iconPath := filepath.Join(assetsDir, fileName)
+
+	if contentType == "image/svg+xml" {
+		// replace currentColor with a text color
+		outFile, err := os.Create(iconPath)
+		if err != nil {
+			return "", err
+		}
+		defer outFile.Close()
+
+		svgText, err := io.ReadAll(srcFile)
+		if err != nil {
+			return "", err
+		}
+
+		svgText = bytes.ReplaceAll(svgText, []byte("currentColor"), []byte(`oklch(87% 0.015 286)`))
+		_, err = outFile.Write(svgText)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		outFile, err := os.Create(iconPath)
+		if err != nil {
+			return "", err
+		}
+		defer outFile.Close()
+
+		var buf bytes.Buffer
+		options := &nativewebp.Options{}
+		if err := nativewebp.Encode(&buf, img, options); err != nil {
+			return "", err
+		}
+
+		if _, err := io.Copy(outFile, &buf); err != nil {
+			return "", err
+		}
+	}
+
+	iconPath = "/uploads/" + fileName
+
+	return iconPath, nil
+}
+
+// fetchIcon downloads the icon a page declares for itself and saves it like an uploaded one
+func (app *App) fetchIcon(pageURL string) (string, error) {
+	favicon, err := app.favicons.Find(context.Background(), pageURL)
+	if err != nil {
+		return "", err
+	}
+
+	slog.Debug("Found icon", "page", pageURL, "icon", favicon.URL)
+
+	return saveIcon(bytes.NewReader(favicon.Data), favicon.ContentType)
+}
+
+// truncateText shortens text to at most max bytes, which is how the name and description limits are counted, without
+// splitting a character
+func truncateText(text string, max int) string {
+	if len(text) <= max {
+		return text
+	}
+
+	const ellipsis = "…"
+	cut := max - len(ellipsis)
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+
+	return strings.TrimRight(text[:cut], " ") + ellipsis
+}
+
+// validateLinkURL checks that a link points somewhere a browser can actually open
+func validateLinkURL(rawURL string) error {
+	if rawURL == "" {
+		return errors.New("URL is required")
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return errors.New("URL is invalid, it must be absolute, e.g. https://example.com")
+	}
+
+	// links are rendered as hrefs on the public board, so javascript: and data: URLs would run in whoever clicks them
+	if scheme := strings.ToLower(parsed.Scheme); scheme != "http" && scheme != "https" {
+		return errors.New("URL must start with http:// or https://")
+	}
+
+	return nil
+}
+
+// actorFrom returns who is making the request, for the audit log
+func actorFrom(c fiber.Ctx) audit.Actor {
+	sessionID, _ := c.Locals("SessionID").(int64)
+	return audit.Actor{
+		SessionID: sessionID,
+		IP:        c.IP(),
+	}
+}
+
+// parseAuditQuery reads the audit log filters and paging from the query string. Since and until accept
+// either RFC 3339 timestamps or plain dates, an until date includes the whole day.
+func parseAuditQuery(c fiber.Ctx) (audit.Filter, int, error) {
+	filter := audit.Filter{
+		Action:     c.Query("action"),
+		EntityType: c.Query("entity_type"),
+		IP:         c.Query("ip"),
+	}
+
+	page := 1
+	perPage := 50
+
+	ints := []struct {
+		key   string
+		value *int64
+	}{
+		{"entity_id", &filter.EntityID},
+		{"session_id", &filter.SessionID},
+	}
+	for _, field := range ints {
+		if raw := c.Query(field.key); raw != "" {
+			value, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				return filter, 0, fmt.Errorf("Failed to parse %s: %v", field.key, err)
+			}
+			*field.value = value
+		}
+	}
+
+	if raw := c.Query("page"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 1 {
+			return filter, 0, errors.New("Page must be a positive number")
+		}
+		page = value
+	}
+
+	if raw := c.Query("per_page"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 1 || value > 200 {
+			return filter, 0, errors.New("per_page must be between 1 and 200")
+		}
+		perPage = value
+	}
+
+	for _, field := range []struct {
+		key      string
+		value    *string
+		endOfDay bool
+	}{
+		{"since", &filter.Since, false},
+		{"until", &filter.Until, true},
+	} {
+		raw := c.Query(field.key)
+		if raw == "" {
+			continue
+		}
+
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			parsed, err = time.ParseInLocation(time.DateOnly, raw, time.Local)
+			if err != nil {
+				return filter, 0, fmt.Errorf("Failed to parse %s, expected a date or an RFC 3339 timestamp", field.key)
+			}
+
+			if field.endOfDay {
+				parsed = parsed.AddDate(0, 0, 1).Add(-time.Second)
+			}
+		}
+
+		*field.value = parsed.UTC().Format(time.RFC3339)
+	}
+
+	filter.Limit = perPage
+	filter.Offset = (page - 1) * perPage
+
+	return filter, page, nil
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx, so lookups can run inside a transaction
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// formHas reports whether the request body contains the given form field, even if it is empty
+func formHas(c fiber.Ctx, key string) bool {
+	if form, err := c.MultipartForm(); err == nil {
+		_, ok := form.Value[key]
+		return ok
+	}
+
+	return c.Request().PostArgs().Has(key)
+}
+
+type Board struct {
+	ID        int64  `json:"id"`
+	Slug      string `json:"slug"`
+	Title     string `json:"title"`
+	IsDefault bool   `json:"is_default"`
+}
+
+// Path returns where the board is served, the default board lives at /
+func (board Board) Path() string {
+	if board.IsDefault {
+		return "/"
+	}
+
+	return "/b/" + board.Slug
+}
+
+type Category struct {
+	ID         int64  `json:"id"`
+	BoardID    int64  `json:"board_id"`
+	Name       string `json:"name"`
+	Icon       string `json:"icon"`
+	Position   int64  `json:"position"`
+	Sort       string `json:"sort"`
+	Visibility string `json:"visibility"`
+	Links      []Link `json:"links"`
+}
+
+// who can see a category or link, private ones are only shown to logged in admins
+const (
+	VisibilityPublic  = "public"
+	VisibilityPrivate = "private"
+)
+
+// ValidateVisibility checks that visibility is one of the visibility settings
+func ValidateVisibility(visibility string) error {
+	if visibility != VisibilityPublic && visibility != VisibilityPrivate {
+		return fmt.Errorf("Visibility must be %q or %q", VisibilityPublic, VisibilityPrivate)
+	}
+
+	return nil
+}
+
+// how the links in a category are ordered
+const (
+	SortManual   = "manual"
+	SortMostUsed = "most_used"
+)
+
+// ValidateSort checks that sort is one of the category sort modes
+func ValidateSort(sort string) error {
+	if sort != SortManual && sort != SortMostUsed {
+		return fmt.Errorf("Sort must be %q or %q", SortManual, SortMostUsed)
+	}
+
+	return nil
+}
+
+type Link struct {
+	ID          int64    `json:"id"`
+	CategoryID  int64    `json:"category_id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Icon        string   `json:"icon"`
+	URL         string   `json:"url"`
+	Position    int64    `json:"position"`
+	Tags        []string `json:"tags"`
+	Pinned      bool     `json:"pinned"`
+	Visibility  string   `json:"visibility"`
+	// typed into the search bar to open the link, or to search it through SearchURL
+	Keyword   string `json:"keyword"`
+	SearchURL string `json:"search_url"`
+	// the go link, /go/<alias> or /<alias>, that redirects to the link
+	Alias string `json:"alias"`
+	// only filled in by GetLinks
+	Clicks   int64       `json:"clicks,omitempty"`
+	LastUsed string      `json:"last_used,omitempty"`
+	Health   *LinkHealth `json:"health,omitempty"`
+}
+
+// LinkHealth is the latest result of the built in link checker
+type LinkHealth struct {
+	Status    int    `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+	CheckedAt string `json:"checked_at"`
+}
+
+// State is "up" if the link responded without an error status, or "down". Sites that ask for a login are up.
+func (health LinkHealth) State() string {
+	if health.Error != "" || health.Status == 0 {
+		return "down"
+	}
+
+	if health.Status < 400 || health.Status == http.StatusUnauthorized || health.Status == http.StatusForbidden {
+		return "up"
+	}
+
+	return "down"
+}
+
+// Summary describes the result for the tooltip of the status dot
+func (health LinkHealth) Summary() string {
+	checked := formatTime(health.CheckedAt)
+	if health.Error != "" {
+		return fmt.Sprintf("%s, checked %s", health.Error, checked)
+	}
+
+	return fmt.Sprintf("%d %s in %dms, checked %s", health.Status, http.StatusText(health.Status), health.LatencyMs, checked)
+}
+
+// LastUsedText returns when the link was last clicked in local time, for showing in templates
+func (link Link) LastUsedText() string {
+	if link.LastUsed == "" {
+		return ""
+	}
+
+	return formatTime(link.LastUsed)
+}
+
+type CategoryManager struct {
+	db *sql.DB
+}
+
+func NewCategoryManager(db *sql.DB) (*CategoryManager, error) {
+	return &CategoryManager{
+		db: db,
+	}, nil
+}
+
+var (
+	ErrBoardNotFound  = errors.New("board not found")
+	ErrBoardSlugTaken = errors.New("a board with that slug already exists")
+	ErrDefaultBoard   = errors.New("the default board cannot be deleted, make another board the default first")
+	ErrNoDefaultBoard = errors.New("there must be a default board, make another board the default instead")
+)
+
+var boardSlugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// ValidateBoardSlug checks that a slug is safe to use in a path
+func ValidateBoardSlug(slug string) error {
+	if len(slug) > 32 || !boardSlugPattern.MatchString(slug) {
+		return errors.New("Slug may only contain lowercase letters, numbers and dashes, and can be at most 32 characters")
+	}
+
+	return nil
+}
+
+// GetBoards returns every board, the default board first
+func (manager *CategoryManager) GetBoards() []Board {
+	rows, err := manager.db.Query(`
+		SELECT id, slug, title, is_default
+		FROM boards
+		ORDER BY is_default DESC, title ASC, id ASC
+	`)
+
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var boards []Board
+	for rows.Next() {
+		var board Board
+		if err := rows.Scan(&board.ID, &board.Slug, &board.Title, &board.IsDefault); err != nil {
+			return nil
+		}
+
+		boards = append(boards, board)
+	}
+
+	return boards
+}
+
+func (manager *CategoryManager) getBoard(where string, args ...any) *Board {
+	row := manager.db.QueryRow(`SELECT id, slug, title, is_default FROM boards `+where, args...)
+
+	var board Board
+	if err := row.Scan(&board.ID, &board.Slug, &board.Title, &board.IsDefault); err != nil {
+		return nil
+	}
+
+	return &board
+}
+
+// Get Board by ID, returns nil if not found
+func (manager *CategoryManager) GetBoard(id int64) *Board {
+	return manager.getBoard(`WHERE id = ?`, id)
+}
+
+// Get Board by slug, returns nil if not found
+func (manager *CategoryManager) GetBoardBySlug(slug string) *Board {
+	return manager.getBoard(`WHERE slug = ?`, slug)
+}
+
+// GetDefaultBoard returns the board served at /
+func (manager *CategoryManager) GetDefaultBoard() *Board {
+	return manager.getBoard(`WHERE is_default = 1`)
+}
+
+func (manager *CategoryManager) CreateBoard(actor audit.Actor, board Board) (*Board, error) {
+	tx, err := manager.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := checkBoardSlug(tx, board.Slug, 0); err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRow(`INSERT INTO boards (slug, title) VALUES (?, ?) RETURNING id`, board.Slug, board.Title).Scan(&board.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if board.IsDefault {
+		if err := setDefaultBoard(tx, board.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := audit.Record(tx, actor, audit.ActionCreate, audit.EntityBoard, board.ID, nil, board); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &board, nil
+}
+
+// UpdateBoard saves the slug, title and default flag of an existing board. The default board can only
+// stop being the default by making another board the default.
+func (manager *CategoryManager) UpdateBoard(actor audit.Actor, board Board) error {
+	tx, err := manager.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := lookupBoard(tx, board.ID)
+	if err != nil {
+		return err
+	}
+
+	wasDefault := before.IsDefault
+	if wasDefault && !board.IsDefault {
+		return ErrNoDefaultBoard
+	}
+
+	if err := checkBoardSlug(tx, board.Slug, board.ID); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE boards SET slug = ?, title = ? WHERE id = ?`, board.Slug, board.Title, board.ID)
+	if err != nil {
+		return err
+	}
+
+	if board.IsDefault && !wasDefault {
+		if err := setDefaultBoard(tx, board.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := audit.Record(tx, actor, audit.ActionUpdate, audit.EntityBoard, board.ID, before, board); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func lookupBoard(q querier, id int64) (*Board, error) {
+	var board Board
+	err := q.QueryRow(`SELECT id, slug, title, is_default FROM boards WHERE id = ?`, id).
+		Scan(&board.ID, &board.Slug, &board.Title, &board.IsDefault)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBoardNotFound
+		}
+		return nil, err
+	}
+
+	return &board, nil
+}
+
+func checkBoardSlug(tx *sql.Tx, slug string, boardID int64) error {
+	var taken bool
+	err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM boards WHERE slug = ? AND id != ?)`, slug, boardID).Scan(&taken)
+	if err != nil {
+		return err
+	}
+
+	if taken {
+		return ErrBoardSlugTaken
+	}
+
+	return nil
+}
+
+func setDefaultBoard(tx *sql.Tx, id int64) error {
+	// the old default has to be cleared first, the unique index is checked row by row
+	if _, err := tx.Exec(`UPDATE boards SET is_default = 0 WHERE is_default = 1 AND id != ?`, id); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(`UPDATE boards SET is_default = 1 WHERE id = ?`, id)
+	return err
+}
+
+// DeleteBoard deletes a board, its categories are moved to the trash
+func (manager *CategoryManager) DeleteBoard(actor audit.Actor, id int64) error {
+	tx, err := manager.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	board, err := lookupBoard(tx, id)
+	if err != nil {
+		return err
+	}
+
+	if board.IsDefault {
+		return ErrDefaultBoard
+	}
+
+	if err := trashBoard(tx, actor, board); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// trashBoard deletes a board that is not the default one after moving its categories, with their links, to the trash.
+// Boards have no trash of their own, so the categories are moved to the default board and are restored there
+func trashBoard(tx *sql.Tx, actor audit.Actor, board *Board) error {
+	categoryIDs, err := queryIDs(tx, `SELECT id FROM categories WHERE board_id = ? AND deleted_at IS NULL`, board.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE categories
+		SET board_id = (SELECT id FROM boards WHERE is_default = 1), deleted_at = COALESCE(deleted_at, ?)
+		WHERE board_id = ?
+	`, time.Now().UTC().Format(time.RFC3339), board.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, categoryID := range categoryIDs {
+		category, err := lookupCategory(tx, categoryID)
+		if err != nil {
+			return err
+		}
+
+		if err := audit.Record(tx, actor, audit.ActionDelete, audit.EntityCategory, categoryID, category, nil); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM boards WHERE id = ?", board.ID); err != nil {
+		return err
+	}
+
+	return audit.Record(tx, actor, audit.ActionDelete, audit.EntityBoard, board.ID, board, nil)
+}
+
+// GetCategories returns every category on the board along with its links
+func (manager *CategoryManager) GetCategories(boardID int64) []Category {
+	rows, err := manager.db.Query(`
+		SELECT id, board_id, name, icon, position, sort, visibility
+		FROM categories 
+		WHERE board_id = ? AND deleted_at IS NULL
+		ORDER BY position ASC, id ASC
+	`, boardID)
+
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var categories []Category
+	for rows.Next() {
+		var cat Category
+
+		if err := rows.Scan(&cat.ID, &cat.BoardID, &cat.Name, &cat.Icon, &cat.Position, &cat.Sort, &cat.Visibility); err != nil {
+			return nil
+		}
+
+		categories = append(categories, cat)
+	}
+
+	for i, cat := range categories {
+		categories[i].Links = manager.GetLinks(cat.ID)
+	}
+
+	return categories
+}
+
+// Get Category by ID, returns nil if not found
+func (manager *CategoryManager) GetCategory(id int64) *Category {
+	row := manager.db.QueryRow(`SELECT id, board_id, name, icon, position, sort, visibility FROM categories WHERE id = ? AND deleted_at IS NULL`, id)
+
+	var cat Category
+	if err := row.Scan(&cat.ID, &cat.BoardID, &cat.Name, &cat.Icon, &cat.Position, &cat.Sort, &cat.Visibility); err != nil {
+		return nil
+	}
+
+	return &cat
+}
+
+func (manager *CategoryManager) CreateCategory(actor audit.Actor, category Category) (*Category, error) {
+	tx, err := manager.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := insertCategory(tx, actor, &category); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+// insertCategory adds a category to the end of its board, filling in its ID and position
+func insertCategory(tx *sql.Tx, actor audit.Actor, category *Category) error {
+	var err error
+	insertCategoryStmt, err = tx.Prepare(`
+		INSERT INTO categories (board_id, name, icon, sort, visibility, position) 
+		VALUES (?, ?, ?, ?, ?, (SELECT COALESCE(MAX(position), -1) + 1 FROM categories WHERE board_id = ?)) RETURNING id, position`)
+
+	if err != nil {
+		return err
+	}
+
+	defer insertCategoryStmt.Close()
+
+	if err := insertCategoryStmt.QueryRow(category.BoardID, category.Name, category.Icon, category.Sort, category.Visibility, category.BoardID).Scan(&category.ID, &category.Position); err != nil {
+		return err
+	}
+
+	return audit.Record(tx, actor, audit.ActionCreate, audit.EntityCategory, category.ID, nil, category)
+}
+
+// lookupCategory returns a category without its links, even if it is in the trash
+func lookupCategory(q querier, id int64) (*Category, error) {
+	var cat Category
+	err := q.QueryRow(`SELECT id, board_id, name, icon, position, sort, visibility FROM categories WHERE id = ?`, id).
+		Scan(&cat.ID, &cat.BoardID, &cat.Name, &cat.Icon, &cat.Position, &cat.Sort, &cat.Visibility)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, err
+	}
+
+	return &cat, nil
+}
+
+// DeleteCategory moves a category, and with it all of its links, to the trash
+func (manager *CategoryManager) DeleteCategory(actor audit.Actor, id int64) error {
+	tx, err := manager.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := trash(tx, "categories", id); err != nil {
+		return err
+	}
+
+	category, err := lookupCategory(tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := audit.Record(tx, actor, audit.ActionDelete, audit.EntityCategory, id, category, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RestoreCategory takes a category out of the trash. Its links kept their keywords and aliases while it was in there, so
+// no other link can have taken them
+func (manager *CategoryManager) RestoreCategory(actor audit.Actor, id int64) error {
+	tx, err := manager.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := restore(tx, "categories", id); err != nil {
+		return err
+	}
+
+	category, err := lookupCategory(tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := audit.Record(tx, actor, audit.ActionRestore, audit.EntityCategory, id, nil, category); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// PurgeCategory permanently deletes a category in the trash, along with its links and their icons
+func (manager *CategoryManager) PurgeCategory(actor audit.Actor, id int64) error {
+	tx, err := manager.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM categories WHERE id = ? AND deleted_at IS NOT NULL)`, id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return ErrNotInTrash
+	}
+
+	category, err := lookupCategory(tx, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(`
+				SELECT icon FROM categories WHERE id = ?
+				UNION
+				SELECT icon FROM links WHERE category_id = ?
+			`, id, id)
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	var icons []string
+	for rows.Next() {
+		var icon string
+		if err := rows.Scan(&icon); err != nil {
+			return err
+		}
+		icons = append(icons, icon)
+	}
+
+	// links are deleted by the foreign key cascade
+	_, err = tx.Exec("DELETE FROM categories WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	if err := deleteOrphanedTags(tx); err != nil {
+		return err
+	}
+
+	if err := audit.Record(tx, actor, audit.ActionPurge, audit.EntityCategory, id, category, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, icon := range icons {
+		if icon == "" {
+			slog.Debug("blank icon")
+			continue
+		}
+
+		if err := os.Remove(filepath.Join("public/", icon)); err != nil {
+			// dont error to the user if the icon doesnt exist, just log it
+			slog.Error("Failed to delete icon", "icon", icon, "error", err)
+		}
+	}
+
+	return nil
+}
+
+func (manager *CategoryManager) GetLink(id int64) *Link {
+	row := manager.db.QueryRow(`
+		SELECT id, category_id, name, description, icon, url, position, pinned, visibility, keyword, search_url, alias
+		FROM links
+		WHERE id = ? AND deleted_at IS NULL
+			AND category_id IN (SELECT id FROM categories WHERE deleted_at IS NULL)
+	`, id)
+
+	var link Link
+	if err := row.Scan(&link.ID, &link.CategoryID, &link.Name, &link.Description, &link.Icon, &link.URL, &link.Position, &link.Pinned, &link.Visibility, &link.Keyword, &link.SearchURL, &link.Alias); err != nil {
+		return nil
+	}
+
+	tags, err := getTags(manager.db, `WHERE link_tags.link_id = ?`, id)
+	if err != nil {
+		return nil
+	}
+	link.Tags = tags[link.ID]
+
+	return &link
+}
+
+// lookupLink returns a link along with its tags, even if it is in the trash
+func lookupLink(q querier, id int64) (*Link, error) {
+	var link Link
+	err := q.QueryRow(`SELECT id, category_id, name, description, icon, url, position, pinned, visibility, keyword, search_url, alias FROM links WHERE id = ?`, id).
+		Scan(&link.ID, &link.CategoryID, &link.Name, &link.Description, &link.Icon, &link.URL, &link.Position, &link.Pinned, &link.Visibility, &link.Keyword, &link.SearchURL, &link.Alias)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrLinkNotFound
+		}
+		return nil, err
+	}
+
+	tags, err := getTags(q, `WHERE link_tags.link_id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	link.Tags = tags[link.ID]
+
+	return &link, nil
+}
+
+// GetLinks returns the links in a category along with how often they were used, in the category's sort order
+func (manager *CategoryManager) GetLinks(categoryID int64) []Link {
+	rows, err := manager.db.Query(`
+		SELECT links.id, links.category_id, links.name, links.description, links.icon, links.url, links.position,
+			links.pinned, links.visibility, links.keyword, links.search_url, links.alias, COALESCE(clicks.count, 0), COALESCE(clicks.last_used, ''),
+			link_health.status, link_health.latency_ms, link_health.error, link_health.checked_at
+		FROM links 
+		JOIN categories ON categories.id = links.category_id
+		LEFT JOIN link_health ON link_health.link_id = links.id
+		LEFT JOIN (
+			SELECT link_id, COUNT(*) AS count, MAX(clicked_at) AS last_used
+			FROM link_clicks
+			GROUP BY link_id
+		) clicks ON clicks.link_id = links.id
+		WHERE links.category_id = ? AND links.deleted_at IS NULL
+		ORDER BY CASE WHEN categories.sort = 'most_used' THEN COALESCE(clicks.count, 0) END DESC,
+			links.position ASC, links.id ASC
+	`, categoryID)
+
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var links []Link
+	for rows.Next() {
+		var link Link
+		var healthStatus, healthLatency sql.NullInt64
+		var healthError, healthCheckedAt sql.NullString
+		if err := rows.Scan(&link.ID, &link.CategoryID, &link.Name, &link.Description,
+			&link.Icon, &link.URL, &link.Position, &link.Pinned, &link.Visibility, &link.Keyword, &link.SearchURL, &link.Alias, &link.Clicks, &link.LastUsed,
+			&healthStatus, &healthLatency, &healthError, &healthCheckedAt); err != nil {
+			return nil
+		}
+
+		// links that havent been checked yet dont get a status
+		if healthCheckedAt.Valid {
+			link.Health = &LinkHealth{
+				Status:    int(healthStatus.Int64),
+				LatencyMs: healthLatency.Int64,
+				Error:     healthError.String,
+				CheckedAt: healthCheckedAt.String,
+			}
+		}
+
+		links = append(links, link)
+	}
+
+	tags, err := getTags(manager.db, `WHERE links.category_id = ?`, categoryID)
+	if err != nil {
+		return nil
+	}
+
+	for i := range links {
+		links[i].Tags = tags[links[i].ID]
+	}
+
+	return links
+}
+
+// GetPinnedLinks returns the pinned links on the board, in the order of the favorites row. Private links, and
+// links in private categories, are left out unless includePrivate is set.
+func (manager *CategoryManager) GetPinnedLinks(boardID int64, includePrivate bool) []Link {
+	rows, err := manager.db.Query(`
+		SELECT links.id, links.category_id, links.name, links.description, links.icon, links.url, links.position,
+			links.visibility
+		FROM links
+		JOIN categories ON categories.id = links.category_id
+		WHERE categories.board_id = ? AND links.pinned = 1
+			AND links.deleted_at IS NULL AND categories.deleted_at IS NULL
+			AND (? OR (links.visibility = 'public' AND categories.visibility = 'public'))
+		ORDER BY links.pinned_position ASC, links.id ASC
+	`, boardID, includePrivate)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var links []Link
+	for rows.Next() {
+		link := Link{Pinned: true}
+		if err := rows.Scan(&link.ID, &link.CategoryID, &link.Name, &link.Description,
+			&link.Icon, &link.URL, &link.Position, &link.Visibility); err != nil {
+			return nil
+		}
+		links = append(links, link)
+	}
+
+	return links
+}
+
+// SetPinned pins or unpins a link, newly pinned links go at the end of the favorites row of their board
+func (manager *CategoryManager) SetPinned(actor audit.Actor, id int64, pinned bool) (*Link, error) {
+	tx, err := manager.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	before, err := lookupLink(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if before.Pinned == pinned {
+		return before, nil
+	}
+
+	if pinned {
+		_, err = tx.Exec(`
+			UPDATE links SET pinned = 1, pinned_position = (
+				SELECT COALESCE(MAX(other.pinned_position), -1) + 1
+				FROM links other
+				JOIN categories ON categories.id = other.category_id
+				WHERE other.pinned = 1
+					AND categories.board_id = (SELECT board_id FROM categories WHERE id = links.category_id)
+			)
+			WHERE id = ?
+		`, id)
+	} else {
+		_, err = tx.Exec(`UPDATE links SET pinned = 0 WHERE id = ?`, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := lookupLink(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := audit.Record(tx, actor, audit.ActionUpdate, audit.EntityLink, id, before, after); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// SetIcon replaces the icon of a link, removing the old file is left to the caller
+func (manager *CategoryManager) SetIcon(actor audit.Actor, id int64, icon string) (*Link, error) {
+	tx, err := manager.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	before, err := lookupLink(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(`UPDATE links SET icon = ? WHERE id = ?`, icon, id); err != nil {
+		return nil, err
+	}
+
+	after, err := lookupLink(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := audit.Record(tx, actor, audit.ActionUpdate, audit.EntityLink, id, before, after); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// getTags returns the tag names of every link matched by the where clause, keyed by link ID
+func getTags(q querier, where string, args ...any) (map[int64][]string, error) {
+	rows, err := q.Query(`
+		SELECT link_tags.link_id, tags.name
+		FROM link_tags
+		JOIN tags ON tags.id = link_tags.tag_id
+		JOIN links ON links.id = link_tags.link_id
+		`+where+`
+		ORDER BY tags.name ASC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make(map[int64][]string)
+	for rows.Next() {
+		var linkID int64
+		var name string
+		if err := rows.Scan(&linkID, &name); err != nil {
+			return nil, err
+		}
+		tags[linkID] = append(tags[linkID], name)
+	}
+
+	return tags, rows.Err()
+}
+
+// GetBoardTags returns the name of every tag that is on at least one link on the board, tags only used by
+// private links are left out unless includePrivate is set
+func (manager *CategoryManager) GetBoardTags(boardID int64, includePrivate bool) []string {
+	rows, err := manager.db.Query(`
+		SELECT DISTINCT tags.name
+		FROM tags
+		JOIN link_tags ON link_tags.tag_id = tags.id
+		JOIN links ON links.id = link_tags.link_id
+		JOIN categories ON categories.id = links.category_id
+		WHERE categories.board_id = ? AND categories.deleted_at IS NULL AND links.deleted_at IS NULL
+			AND (? OR (links.visibility = 'public' AND categories.visibility = 'public'))
+		ORDER BY tags.name ASC
+	`, boardID, includePrivate)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var tags []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil
+		}
+		tags = append(tags, name)
+	}
+
+	return tags
+}
+
+// setLinkTags replaces the tags on a link, creating any tags that dont exist yet and removing tags
+// that are no longer used by any link
+func setLinkTags(tx *sql.Tx, linkID int64, tags []string) error {
+	if _, err := tx.Exec(`DELETE FROM link_tags WHERE link_id = ?`, linkID); err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		var tagID int64
+		err := tx.QueryRow(`
+			INSERT INTO tags (name) VALUES (?)
+			ON CONFLICT (name) DO UPDATE SET name = excluded.name
+			RETURNING id
+		`, tag).Scan(&tagID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`INSERT INTO link_tags (link_id, tag_id) VALUES (?, ?)`, linkID, tagID); err != nil {
+			return err
+		}
+	}
+
+	return deleteOrphanedTags(tx)
+}
+
+// deleteOrphanedTags deletes the tags no link has anymore. Removing a tag from a link, or deleting a link for good, has
+// to be followed by it, since nothing else removes the tag itself
+func deleteOrphanedTags(tx *sql.Tx) error {
+	_, err := tx.Exec(`DELETE FROM tags WHERE id NOT IN (SELECT tag_id FROM link_tags)`)
+	return err
+}
+
+// ParseTags turns a comma separated list of tags into a clean list of lowercase, unique tags
+func ParseTags(raw string) ([]string, error) {
+	tags := []string{}
+	seen := make(map[string]bool)
+
+	for _, tag := range strings.Split(raw, ",") {
+		tag = strings.ToLower(strings.Join(strings.Fields(tag), " "))
+		if tag == "" || seen[tag] {
+			continue
+		}
+
+		if len(tag) > 30 {
+			return nil, fmt.Errorf("Tag %q is too long. Maximum length is 30 characters", tag)
+		}
+
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	if len(tags) > 10 {
+		return nil, errors.New("Too many tags. A link can have at most 10 tags")
+	}
+
+	return tags, nil
+}
+
+// keywords and bangs are typed into the search bar, so they cannot have spaces
+var keywordPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// ParseKeyword cleans up a link keyword or a bang, named by what in errors, and checks that it can be typed into the
+// search bar. An empty keyword is valid, it means the link has none
+func ParseKeyword(raw string, what string) (string, error) {
+	keyword := strings.ToLower(strings.TrimSpace(raw))
+	if keyword == "" {
+		return "", nil
+	}
+
+	if len(keyword) > 20 {
+		return "", fmt.Errorf("%s is too long. Maximum length is 20 characters", what)
+	}
+
+	if !keywordPattern.MatchString(keyword) {
+		return "", fmt.Errorf("%s may only contain letters, numbers, dots, dashes and underscores", what)
+	}
+
+	return keyword, nil
+}
+
+// validateSearchURL checks that a search URL has a place for the search terms, an empty one is valid
+func validateSearchURL(rawURL string) error {
+	if rawURL == "" {
+		return nil
+	}
+
+	if !strings.Contains(rawURL, "%s") {
+		return errors.New("Search URL needs a %s where the search terms go, e.g. https://example.com/search?q=%s")
+	}
+
+	return validateLinkURL(strings.ReplaceAll(rawURL, "%s", "passport"))
+}
+
+// expandSearchURL puts the search terms in place of every %s in a search URL
+func expandSearchURL(searchURL string, terms string) string {
+	return strings.ReplaceAll(searchURL, "%s", url.QueryEscape(terms))
+}
+
+// keywordOwner returns the name of the link, other than exceptID, that already uses a keyword, or an empty string if it
+// is free. Like aliases, links in the trash give up their keywords but links in a category in the trash keep them, and
+// the unique links_keyword index enforces the same in the database
+func keywordOwner(q querier, keyword string, exceptID int64) (string, error) {
+	var name string
+	err := q.QueryRow(`
+		SELECT name FROM links
+		WHERE keyword = ? AND id != ? AND deleted_at IS NULL
+		LIMIT 1
+	`, keyword, exceptID).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+
+	return name, err
+}
+
+// FindKeyword returns the link with a keyword, on any board, or nil if there is none. Private links, and links in
+// private categories, are left out unless includePrivate is set
+func (manager *CategoryManager) FindKeyword(keyword string, includePrivate bool) *Link {
+	var id int64
+	err := manager.db.QueryRow(`
+		SELECT links.id FROM links
+		JOIN categories ON categories.id = links.category_id
+		WHERE links.keyword = ? AND links.deleted_at IS NULL AND categories.deleted_at IS NULL
+			AND (? OR (links.visibility = ? AND categories.visibility = ?))
+		ORDER BY links.id ASC
+		LIMIT 1
+	`, keyword, includePrivate, VisibilityPublic, VisibilityPublic).Scan(&id)
+	if err != nil {
+		return nil
+	}
+
+	return manager.GetLink(id)
+}
+
+// GetKeywordLinks returns every link that has a keyword, in alphabetical order of their keywords
+func (manager *CategoryManager) GetKeywordLinks() []Link {
+	ids, err := queryIDs(manager.db, `
+		SELECT links.id FROM links
+		JOIN categories ON categories.id = links.category_id
+		WHERE links.keyword != '' AND links.deleted_at IS NULL AND categories.deleted_at IS NULL
+		ORDER BY links.keyword ASC, links.id ASC
+	`)
+	if err != nil {
+		return nil
+	}
+
+	var links []Link
+	for _, id := range ids {
+		if link := manager.GetLink(id); link != nil {
+			links = append(links, *link)
+		}
+	}
+
+	return links
+}
+
+// aliases are a path segment of their own, and start with a letter so /go/<id> still finds links by their ID
+var aliasPattern = regexp.MustCompile(`^[a-z][a-z0-9._-]*$`)
+
+// the first path segments passport's own pages use, a bare /<alias> could never reach a link with one of these
+var reservedAliases = []string{"admin", "api", "assets", "b", "favicon.ico", "go", "search", "uploads"}
+
+// ParseAlias cleans up a link alias and checks that it can be used as a go link. An empty alias is valid, it means the
+// link has none
+func ParseAlias(raw string) (string, error) {
+	alias := strings.ToLower(strings.Trim(strings.TrimSpace(raw), "/"))
+	if alias == "" {
+		return "", nil
+	}
+
+	if len(alias) > 40 {
+		return "", errors.New("Alias is too long. Maximum length is 40 characters")
+	}
+
+	if !aliasPattern.MatchString(alias) {
+		return "", errors.New("Alias has to start with a letter and may only contain letters, numbers, dots, dashes and underscores")
+	}
+
+	if slices.Contains(reservedAliases, alias) {
+		return "", fmt.Errorf("Alias %q is used by passport itself", alias)
+	}
+
+	return alias, nil
+}
+
+// aliasOwner returns the name of the link, other than exceptID, that already uses an alias, or an empty string if it is
+// free. Links in the trash give up their aliases, but links in a category in the trash keep them, so restoring the
+// category cant clash with another link. The unique links_alias index enforces the same in the database
+func aliasOwner(q querier, alias string, exceptID int64) (string, error) {
+	var name string
+	err := q.QueryRow(`
+		SELECT name FROM links
+		WHERE alias = ? AND id != ? AND deleted_at IS NULL
+		LIMIT 1
+	`, alias, exceptID).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+
+	return name, err
+}
+
+// TakenError is returned when a link is given a keyword or alias another link already uses
+type TakenError struct {
+	// Keyword or Alias
+	What  string
+	Owner string
+}
+
+func (err *TakenError) Error() string {
+	return fmt.Sprintf("%s is already used by %s", err.What, err.Owner)
+}
+
+// checkTaken returns a *TakenError if another link already uses the keyword or alias of link
+func checkTaken(q querier, link *Link) error {
+	if link.Keyword != "" {
+		owner, err := keywordOwner(q, link.Keyword, link.ID)
+		if err != nil {
+			return err
+		}
+
+		if owner != "" {
+			return &TakenError{What: "Keyword", Owner: owner}
+		}
+	}
+
+	if link.Alias != "" {
+		owner, err := aliasOwner(q, link.Alias, link.ID)
+		if err != nil {
+			return err
+		}
+
+		if owner != "" {
+			return &TakenError{What: "Alias", Owner: owner}
+		}
+	}
+
+	return nil
+}
+
+// getAliasLinks returns the links with an alias, in alphabetical order of their aliases. Private links, and links in
+// private categories, are left out unless includePrivate is set
+func (manager *CategoryManager) getAliasLinks(alias string, includePrivate bool) []Link {
+	ids, err := queryIDs(manager.db, `
+		SELECT links.id FROM links
+		JOIN categories ON categories.id = links.category_id
+		WHERE links.alias != '' AND (? = '' OR links.alias = ?) AND links.deleted_at IS NULL AND categories.deleted_at IS NULL
+			AND (? OR (links.visibility = ? AND categories.visibility = ?))
+		ORDER BY links.alias ASC, links.id ASC
+	`, alias, alias, includePrivate, VisibilityPublic, VisibilityPublic)
+	if err != nil {
+		return nil
+	}
+
+	var links []Link
+	for _, id := range ids {
+		if link := manager.GetLink(id); link != nil {
+			links = append(links, *link)
+		}
+	}
+
+	return links
+}
+
+// FindAlias returns the link with an alias, on any board, or nil if there is none
+func (manager *CategoryManager) FindAlias(alias string, includePrivate bool) *Link {
+	links := manager.getAliasLinks(alias, includePrivate)
+	if len(links) == 0 {
+		return nil
+	}
+
+	return &links[0]
+}
+
+// SuggestAliases returns up to five links with an alias close to one that doesnt exist, closest first, for when a go
+// link was mistyped
+func (manager *CategoryManager) SuggestAliases(alias string, includePrivate bool) []Link {
+	type suggestion struct {
+		link     Link
+		distance int
+	}
+
+	var suggestions []suggestion
+	for _, link := range manager.getAliasLinks("", includePrivate) {
+		distance := editDistance(alias, link.Alias)
+		if distance > max(2, len(alias)/3) && !strings.Contains(link.Alias, alias) && !strings.Contains(alias, link.Alias) {
+			continue
+		}
+
+		suggestions = append(suggestions, suggestion{link: link, distance: distance})
+	}
+
+	// the links are already in alphabetical order, which breaks ties
+	slices.SortStableFunc(suggestions, func(a, b suggestion) int {
+		return cmp.Compare(a.distance, b.distance)
+	})
+
+	var links []Link
+	for _, suggestion := range suggestions[:min(len(suggestions), 5)] {
+		links = append(links, suggestion.link)
+	}
+
+	return links
+}
+
+// editDistance is the number of single character insertions, deletions and substitutions it takes to turn a into b
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+// aliasTarget adds what followed the alias in a go link, the rest of the path and the query, onto the link's URL, so
+// /go/gh/juls0730/passport opens the passport repository when gh is https://github.com
+func aliasTarget(linkURL string, rest string, query string) string {
+	if rest == "" && query == "" {
+		return linkURL
+	}
+
+	target, err := url.Parse(linkURL)
+	if err != nil {
+		return linkURL
+	}
+
+	if rest != "" {
+		target = target.JoinPath(rest)
+	}
+
+	if query != "" {
+		if target.RawQuery != "" {
+			target.RawQuery += "&"
+		}
+		target.RawQuery += query
+	}
+
+	return target.String()
+}
+
+// SearchResult is a link found by SearchLinks, along with where it is
+type SearchResult struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Icon        string `json:"icon"`
+	URL         string `json:"url"`
+	Category    string `json:"category"`
+	Board       string `json:"board"`
+	BoardTitle  string `json:"board_title"`
+	// where opening the result goes, through /go when clicks are tracked
+	Href   string `json:"href"`
+	score  int
+	clicks int64
+}
+
+// SearchLinks fuzzy matches every word of a query against the names, descriptions, URLs and category names of the links
+// on every board, and returns at most limit of them, the best matches first. Names count the most, and links that are
+// used more often win ties. Private links, and links in private categories, are left out unless includePrivate is set
+func (manager *CategoryManager) SearchLinks(query string, includePrivate bool, limit int) ([]SearchResult, error) {
+	terms := strings.Fields(query)
+	if len(terms) == 0 {
+		return []SearchResult{}, nil
+	}
+
+	rows, err := manager.db.Query(`
+		SELECT links.id, links.name, links.description, links.icon, links.url, categories.name, boards.slug, boards.title,
+			COALESCE(clicks.count, 0)
+		FROM links
+		JOIN categories ON categories.id = links.category_id
+		JOIN boards ON boards.id = categories.board_id
+		LEFT JOIN (
+			SELECT link_id, COUNT(*) AS count
+			FROM link_clicks
+			GROUP BY link_id
+		) clicks ON clicks.link_id = links.id
+		WHERE links.deleted_at IS NULL AND categories.deleted_at IS NULL
+			AND (? OR (links.visibility = ? AND categories.visibility = ?))
+	`, includePrivate, VisibilityPublic, VisibilityPublic)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []SearchResult{}
+	for rows.Next() {
+		var result SearchResult
+		if err := rows.Scan(&result.ID, &result.Name, &result.Description, &result.Icon, &result.URL, &result.Category,
+			&result.Board, &result.BoardTitle, &result.clicks); err != nil {
+			return nil, err
+		}
+
+		// the scheme and www. are on nearly every URL, so they would only get in the way
+		address := strings.TrimPrefix(result.URL, "https://")
+		address = strings.TrimPrefix(strings.TrimPrefix(address, "http://"), "www.")
+
+		for _, term := range terms {
+			score := max(
+				fuzzy.Score(term, result.Name)*3,
+				fuzzy.Score(term, result.Category)*2,
+				fuzzy.Score(term, address)*2,
+			)
+
+			// descriptions are whole sentences, letters scattered over one would match almost anything
+			if strings.Contains(strings.ToLower(result.Description), strings.ToLower(term)) {
+				score = max(score, fuzzy.Score(term, result.Description))
+			}
+
+			// every word has to match something
+			if score == 0 {
+				result.score = 0
+				break
+			}
+			result.score += score
+		}
+
+		if result.score > 0 {
+			results = append(results, result)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(results, func(a, b SearchResult) int {
+		return cmp.Or(
+			cmp.Compare(b.score, a.score),
+			cmp.Compare(b.clicks, a.clicks),
+			cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)),
+			cmp.Compare(a.ID, b.ID),
+		)
+	})
+
+	return results[:min(len(results), limit)], nil
+}
+
+// Bang is a DuckDuckGo style shortcut, !bang anywhere in a search sends the rest of it to URL in place of %s
+type Bang struct {
+	ID int64 `json:"id"`
+	// without the !
+	Bang string `json:"bang"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+var (
+	ErrBangNotFound = errors.New("bang not found")
+	ErrBangTaken    = errors.New("a bang with that name already exists")
+)
+
+// ValidateBang cleans up a bang and checks every field of it
+func ValidateBang(bang *Bang) error {
+	bang.Name = strings.TrimSpace(bang.Name)
+	bang.URL = strings.TrimSpace(bang.URL)
+
+	keyword, err := ParseKeyword(strings.TrimPrefix(strings.TrimSpace(bang.Bang), "!"), "Bang")
+	if err != nil {
+		return err
+	}
+	bang.Bang = keyword
+
+	if bang.Bang == "" || bang.Name == "" || bang.URL == "" {
+		return errors.New("Bang, name and URL are required")
+	}
+
+	if len(bang.Name) > 50 {
+		return errors.New("Name is too long. Maximum length is 50 characters")
+	}
+
+	return validateSearchURL(bang.URL)
+}
+
+// GetBangs returns every bang, in alphabetical order
+func (manager *CategoryManager) GetBangs() []Bang {
+	rows, err := manager.db.Query(`SELECT id, bang, name, url FROM bangs ORDER BY bang ASC`)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	bangs := []Bang{}
+	for rows.Next() {
+		var bang Bang
+		if err := rows.Scan(&bang.ID, &bang.Bang, &bang.Name, &bang.URL); err != nil {
+			return nil
+		}
+		bangs = append(bangs, bang)
+	}
+
+	return bangs
+}
+
+// GetBang returns the bang with the given name, without the !, or nil if there is none
+func (manager *CategoryManager) GetBang(name string) *Bang {
+	var bang Bang
+	err := manager.db.QueryRow(`SELECT id, bang, name, url FROM bangs WHERE bang = ?`, name).
+		Scan(&bang.ID, &bang.Bang, &bang.Name, &bang.URL)
+	if err != nil {
+		return nil
+	}
+
+	return &bang
+}
+
+func lookupBang(q querier, id int64) (*Bang, error) {
+	var bang Bang
+	err := q.QueryRow(`SELECT id, bang, name, url FROM bangs WHERE id = ?`, id).
+		Scan(&bang.ID, &bang.Bang, &bang.Name, &bang.URL)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBangNotFound
+		}
+		return nil, err
+	}
+
+	return &bang, nil
+}
+
+func checkBang(tx *sql.Tx, bang string, id int64) error {
+	var taken bool
+	err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM bangs WHERE bang = ? AND id != ?)`, bang, id).Scan(&taken)
+	if err != nil {
+		return err
+	}
+
+	if taken {
+		return ErrBangTaken
+	}
+
+	return nil
+}
+
+func (manager *CategoryManager) CreateBang(actor audit.Actor, bang Bang) (*Bang, error) {
+	tx, err := manager.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := checkBang(tx, bang.Bang, 0); err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRow(`INSERT INTO bangs (bang, name, url) VALUES (?, ?, ?) RETURNING id`, bang.Bang, bang.Name, bang.URL).Scan(&bang.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := audit.Record(tx, actor, audit.ActionCreate, audit.EntityBang, bang.ID, nil, bang); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &bang, nil
+}
+
+func (manager *CategoryManager) UpdateBang(actor audit.Actor, bang Bang) error {
+	tx, err := manager.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := lookupBang(tx, bang.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := checkBang(tx, bang.Bang, bang.ID); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE bangs SET bang = ?, name = ?, url = ? WHERE id = ?`, bang.Bang, bang.Name, bang.URL, bang.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := audit.Record(tx, actor, audit.ActionUpdate, audit.EntityBang, bang.ID, before, bang); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (manager *CategoryManager) DeleteBang(actor audit.Actor, id int64) error {
+	tx, err := manager.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	bang, err := lookupBang(tx, id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM bangs WHERE id = ?`, id); err != nil {
+		return err
+	}
+
+	if err := audit.Record(tx, actor, audit.ActionDelete, audit.EntityBang, id, bang, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SearchProvider is a site the search bar can search, %s in URL is replaced by the search terms. The default one is
+// used unless another one is picked next to the search bar, or named in the search with @shortcut
+type SearchProvider struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	// without the @
+	Shortcut string `json:"shortcut"`
+	URL      string `json:"url"`
+	// where suggestions for what is being typed come from, empty for none
+	SuggestURL string `json:"suggest_url"`
+	Icon       string `json:"icon"`
+	IsDefault  bool   `json:"is_default"`
+}
+
+var (
+	ErrSearchProviderNotFound  = errors.New("search provider not found")
+	ErrSearchProviderTaken     = errors.New("a search provider with that shortcut already exists")
+	ErrDefaultSearchProvider   = errors.New("the default search provider cannot be deleted, make another provider the default first")
+	ErrNoDefaultSearchProvider = errors.New("there must be a default search provider, make another provider the default instead")
+)
+
+// ValidateSearchProvider cleans up a search provider and checks every field of it apart from its icon
+func ValidateSearchProvider(provider *SearchProvider) error {
+	provider.Name = strings.TrimSpace(provider.Name)
+	provider.URL = strings.TrimSpace(provider.URL)
+	provider.SuggestURL = strings.TrimSpace(provider.SuggestURL)
+
+	shortcut, err := ParseKeyword(strings.TrimPrefix(strings.TrimSpace(provider.Shortcut), "@"), "Shortcut")
+	if err != nil {
+		return err
+	}
+	provider.Shortcut = shortcut
+
+	if provider.Name == "" || provider.Shortcut == "" || provider.URL == "" {
+		return errors.New("Name, shortcut and URL are required")
+	}
+
+	if len(provider.Name) > 50 {
+		return errors.New("Name is too long. Maximum length is 50 characters")
+	}
+
+	if err := validateSearchURL(provider.URL); err != nil {
+		return err
+	}
+
+	if provider.SuggestURL != "" && !strings.Contains(provider.SuggestURL, "%s") {
+		return errors.New("Suggestion URL needs a %s where the search terms go, e.g. https://duckduckgo.com/ac/?type=list&q=%s")
+	}
+
+	return validateSearchURL(provider.SuggestURL)
+}
+
+// siteURL returns the front page of the site a search URL belongs to, where searching for nothing goes
+func siteURL(searchURL string) string {
+	parsed, err := url.Parse(searchURL)
+	if err != nil {
+		return searchURL
+	}
+
+	return parsed.Scheme + "://" + parsed.Host + "/"
+}
+
+const searchProviderColumns = `id, name, shortcut, url, suggest_url, icon, is_default`
+
+// search suggestions are only fetched for queries up to this long, anything longer is not being typed by hand
+const maxSuggestionQuery = 200
+
+func scanSearchProvider(row interface{ Scan(...any) error }) (*SearchProvider, error) {
+	var provider SearchProvider
+	if err := row.Scan(&provider.ID, &provider.Name, &provider.Shortcut, &provider.URL, &provider.SuggestURL, &provider.Icon, &provider.IsDefault); err != nil {
+		return nil, err
+	}
+
+	return &provider, nil
+}
+
+// GetSearchProviders returns every search provider, the default one first
+func (manager *CategoryManager) GetSearchProviders() []SearchProvider {
+	rows, err := manager.db.Query(`SELECT ` + searchProviderColumns + ` FROM search_providers ORDER BY is_default DESC, name ASC, id ASC`)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	providers := []SearchProvider{}
+	for rows.Next() {
+		provider, err := scanSearchProvider(rows)
+		if err != nil {
+			return nil
+		}
+		providers = append(providers, *provider)
+	}
+
+	return providers
+}
+
+// GetSearchProvider returns the search provider with the given shortcut, without the @, or nil if there is none
+func (manager *CategoryManager) GetSearchProvider(shortcut string) *SearchProvider {
+	provider, err := scanSearchProvider(manager.db.QueryRow(`SELECT `+searchProviderColumns+` FROM search_providers WHERE shortcut = ?`, shortcut))
+	if err != nil {
+		return nil
+	}
+
+	return provider
+}
+
+// GetDefaultSearchProvider returns the default search provider, or nil if there are no search providers at all
+func (manager *CategoryManager) GetDefaultSearchProvider() *SearchProvider {
+	provider, err := scanSearchProvider(manager.db.QueryRow(`SELECT ` + searchProviderColumns + ` FROM search_providers WHERE is_default = 1`))
+	if err != nil {
+		return nil
+	}
+
+	return provider
+}
+
+func lookupSearchProvider(q querier, id int64) (*SearchProvider, error) {
+	provider, err := scanSearchProvider(q.QueryRow(`SELECT `+searchProviderColumns+` FROM search_providers WHERE id = ?`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSearchProviderNotFound
+		}
+		return nil, err
+	}
+
+	return provider, nil
+}
+
+func checkSearchProvider(tx *sql.Tx, shortcut string, id int64) error {
+	var taken bool
+	err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM search_providers WHERE shortcut = ? AND id != ?)`, shortcut, id).Scan(&taken)
+	if err != nil {
+		return err
+	}
+
+	if taken {
+		return ErrSearchProviderTaken
+	}
+
+	return nil
+}
+
+func setDefaultSearchProvider(tx *sql.Tx, id int64) error {
+	// the old default has to be cleared first, the unique index is checked row by row
+	if _, err := tx.Exec(`UPDATE search_providers SET is_default = 0 WHERE is_default = 1 AND id != ?`, id); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(`UPDATE search_providers SET is_default = 1 WHERE id = ?`, id)
+	return err
+}
+
+// CreateSearchProvider adds a search provider, the first one is always the default
+func (manager *CategoryManager) CreateSearchProvider(actor audit.Actor, provider SearchProvider) (*SearchProvider, error) {
+	tx, err := manager.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := checkSearchProvider(tx, provider.Shortcut, 0); err != nil {
+		return nil, err
+	}
+
+	var first bool
+	if err := tx.QueryRow(`SELECT NOT EXISTS(SELECT 1 FROM search_providers)`).Scan(&first); err != nil {
+		return nil, err
+	}
+	provider.IsDefault = provider.IsDefault || first
+
+	err = tx.QueryRow(`INSERT INTO search_providers (name, shortcut, url, suggest_url, icon) VALUES (?, ?, ?, ?, ?) RETURNING id`,
+		provider.Name, provider.Shortcut, provider.URL, provider.SuggestURL, provider.Icon).Scan(&provider.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if provider.IsDefault {
+		if err := setDefaultSearchProvider(tx, provider.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := audit.Record(tx, actor, audit.ActionCreate, audit.EntitySearchProvider, provider.ID, nil, provider); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &provider, nil
+}
+
+// UpdateSearchProvider saves a search provider, keeping its icon if it has no new one. Like boards, the default provider
+// can only stop being the default by making another provider the default
+func (manager *CategoryManager) UpdateSearchProvider(actor audit.Actor, provider SearchProvider) error {
+	tx, err := manager.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := lookupSearchProvider(tx, provider.ID)
+	if err != nil {
+		return err
+	}
+
+	if before.IsDefault && !provider.IsDefault {
+		return ErrNoDefaultSearchProvider
+	}
+
+	if err := checkSearchProvider(tx, provider.Shortcut, provider.ID); err != nil {
+		return err
+	}
+
+	if provider.Icon == "" {
+		provider.Icon = before.Icon
+	}
+
+	_, err = tx.Exec(`UPDATE search_providers SET name = ?, shortcut = ?, url = ?, suggest_url = ?, icon = ? WHERE id = ?`,
+		provider.Name, provider.Shortcut, provider.URL, provider.SuggestURL, provider.Icon, provider.ID)
+	if err != nil {
+		return err
+	}
+
+	if provider.IsDefault && !before.IsDefault {
+		if err := setDefaultSearchProvider(tx, provider.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := audit.Record(tx, actor, audit.ActionUpdate, audit.EntitySearchProvider, provider.ID, before, provider); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if before.Icon != "" && before.Icon != provider.Icon {
+		if err := os.Remove(filepath.Join("public/", before.Icon)); err != nil {
+			slog.Error("Failed to delete icon", "icon", before.Icon, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// DeleteSearchProvider deletes a search provider along with its icon, the default one cannot be deleted
+func (manager *CategoryManager) DeleteSearchProvider(actor audit.Actor, id int64) error {
+	tx, err := manager.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	provider, err := lookupSearchProvider(tx, id)
+	if err != nil {
+		return err
+	}
+
+	if provider.IsDefault {
+		return ErrDefaultSearchProvider
+	}
+
+	if _, err := tx.Exec(`DELETE FROM search_providers WHERE id = ?`, id); err != nil {
+		return err
+	}
+
+	if err := audit.Record(tx, actor, audit.ActionDelete, audit.EntitySearchProvider, id, provider, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if provider.Icon != "" {
+		if err := os.Remove(filepath.Join("public/", provider.Icon)); err != nil {
+			slog.Error("Failed to delete icon", "icon", provider.Icon, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// searchProviderIcon saves the icon of the site a search provider searches, or the default link icon if the site has
+// none that can be found
+func (app *App) searchProviderIcon(searchURL string) (string, error) {
+	icon, err := app.fetchIcon(siteURL(searchURL))
+	if err != nil {
+		slog.Info("Failed to fetch icon, using the default icon", "url", searchURL, "error", err)
+		return saveIcon(strings.NewReader(defaultLinkIcon), "image/svg+xml")
+	}
+
+	return icon, nil
+}
+
+// searchLinks finds the links matching a query like SearchLinks, with the address each of them should be opened at
+func (app *App) searchLinks(query string, includePrivate bool, limit int) ([]SearchResult, error) {
+	results, err := app.CategoryManager.SearchLinks(query, includePrivate, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		results[i].Href = results[i].URL
+		if app.Config.TrackClicks {
+			results[i].Href = fmt.Sprintf("/go/%d", results[i].ID)
+		}
+	}
+
+	return results, nil
+}
+
+// seedSearchProvider turns PASSPORT_SEARCH_PROVIDER into the default search provider while there are none, it was the
+// only search provider before they were kept in the database
+func (app *App) seedSearchProvider() error {
+	if app.Config.SearchProvider.URL == "" || len(app.CategoryManager.GetSearchProviders()) > 0 {
+		return nil
+	}
+
+	parsed, err := url.Parse(app.Config.SearchProvider.URL)
+	if err != nil || parsed.Host == "" {
+		return fmt.Errorf("PASSPORT_SEARCH_PROVIDER %q is not a valid URL", app.Config.SearchProvider.URL)
+	}
+
+	// %s has to stay as it is, so it cannot go through url.Values
+	if parsed.RawQuery != "" {
+		parsed.RawQuery += "&"
+	}
+	parsed.RawQuery += url.QueryEscape(app.Config.SearchProvider.Query) + "=%s"
+
+	host := strings.TrimPrefix(parsed.Hostname(), "www.")
+	shortcut, err := ParseKeyword(strings.Split(host, ".")[0], "Shortcut")
+	if err != nil || shortcut == "" {
+		shortcut = "web"
+	}
+
+	provider := SearchProvider{Name: host, Shortcut: shortcut, URL: parsed.String(), IsDefault: true}
+	if err := ValidateSearchProvider(&provider); err != nil {
+		return fmt.Errorf("PASSPORT_SEARCH_PROVIDER: %w", err)
+	}
+
+	provider.Icon, err = app.searchProviderIcon(provider.URL)
+	if err != nil {
+		return err
+	}
+
+	if _, err := app.CategoryManager.CreateSearchProvider(audit.Actor{}, provider); err != nil {
+		os.Remove(filepath.Join("public/", provider.Icon))
+		return err
+	}
+
+	slog.Info("Added the search provider from PASSPORT_SEARCH_PROVIDER", "name", provider.Name, "shortcut", provider.Shortcut)
+	return nil
+}
+
+// searchTarget works out where a search from the search bar goes. A bang anywhere in the query searches the rest of it
+// on the bang's site, and an @shortcut anywhere in it with the search provider it names. Otherwise a link's keyword at
+// the start opens the link, or searches the rest of the query through the link's search URL, and everything else goes
+// to the search provider picked next to the search bar, or the default one
+func (app *App) searchTarget(query string, picked string, isAdmin bool) string {
+	words := strings.Fields(query)
+
+	if bang, terms := findShortcut(words, '!', app.CategoryManager.GetBang); bang != nil {
+		if terms == "" {
+			// a bang on its own goes to the site itself
+			return siteURL(bang.URL)
+		}
+
+		return expandSearchURL(bang.URL, terms)
+	}
+
+	if provider, terms := findShortcut(words, '@', app.CategoryManager.GetSearchProvider); provider != nil {
+		if terms == "" {
+			return siteURL(provider.URL)
+		}
+
+		return expandSearchURL(provider.URL, terms)
+	}
+
+	if len(words) > 0 {
+		if link := app.CategoryManager.FindKeyword(strings.ToLower(words[0]), isAdmin); link != nil {
+			terms := strings.Join(words[1:], " ")
+
+			if terms == "" {
+				if app.Config.TrackClicks {
+					if err := app.CategoryManager.RecordClick(link.ID); err != nil {
+						slog.Error("Failed to record click", "link", link.ID, "error", err)
+					}
+				}
+
+				return link.URL
+			}
+
+			if link.SearchURL != "" {
+				return expandSearchURL(link.SearchURL, terms)
+			}
+		}
+	}
+
+	provider := app.CategoryManager.GetSearchProvider(picked)
+	if provider == nil {
+		provider = app.CategoryManager.GetDefaultSearchProvider()
+	}
+
+	// without any search provider there is nowhere to search
+	if provider == nil {
+		return "/"
+	}
+
+	return expandSearchURL(provider.URL, strings.TrimSpace(query))
+}
+
+// findShortcut looks through words for the first one that starts with prefix and names something lookup knows, like
+// !w for a bang or @ddg for a search provider, and returns it along with the rest of the words
+func findShortcut[T any](words []string, prefix byte, lookup func(name string) *T) (*T, string) {
+	for i, word := range words {
+		if len(word) < 2 || word[0] != prefix {
+			continue
+		}
+
+		if found := lookup(strings.ToLower(word[1:])); found != nil {
+			return found, strings.Join(slices.Delete(slices.Clone(words), i, i+1), " ")
+		}
+	}
+
+	return nil, ""
+}
+
+// RecordClick counts a click on a link, it only stores when the click happened
+func (manager *CategoryManager) RecordClick(linkID int64) error {
+	_, err := manager.db.Exec(`INSERT INTO link_clicks (link_id, clicked_at) VALUES (?, ?)`,
+		linkID, time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+// FilterPublic drops private categories, and private links from the categories that are left
+func FilterPublic(categories []Category) []Category {
+	var filtered []Category
+	for _, category := range categories {
+		if category.Visibility == VisibilityPrivate {
+			continue
+		}
+
+		var links []Link
+		for _, link := range category.Links {
+			if link.Visibility != VisibilityPrivate {
+				links = append(links, link)
+			}
+		}
+
+		category.Links = links
+		filtered = append(filtered, category)
+	}
+
+	return filtered
+}
+
+// FilterByTag returns only the links with the given tag, dropping any categories left empty
+func FilterByTag(categories []Category, tag string) []Category {
+	var filtered []Category
+	for _, category := range categories {
+		var links []Link
+		for _, link := range category.Links {
+			if slices.Contains(link.Tags, tag) {
+				links = append(links, link)
+			}
+		}
+
+		if len(links) == 0 {
+			continue
+		}
+
+		category.Links = links
+		filtered = append(filtered, category)
+	}
+
+	return filtered
+}
+
+func (manager *CategoryManager) CreateLink(db *sql.DB, actor audit.Actor, link Link) (*Link, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := insertLink(tx, actor, &link); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &link, nil
+}
+
+// insertLink adds a link to the end of its category, filling in its ID and position
+func insertLink(tx *sql.Tx, actor audit.Actor, link *Link) error {
+	// checked in the same transaction as the insert, so two links created at once cant both take a keyword or alias
+	if err := checkTaken(tx, link); err != nil {
+		return err
+	}
+
+	var err error
+	insertLinkStmt, err = tx.Prepare(`
+		INSERT INTO links (category_id, name, description, icon, url, visibility, keyword, search_url, alias, position) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, (SELECT COALESCE(MAX(position), -1) + 1 FROM links WHERE category_id = ?)) RETURNING id, position`)
+	if err != nil {
+		return err
+	}
+
+	defer insertLinkStmt.Close()
+
+	if err := insertLinkStmt.QueryRow(link.CategoryID, link.Name, link.Description, link.Icon, link.URL, link.Visibility, link.Keyword, link.SearchURL, link.Alias, link.CategoryID).Scan(&link.ID, &link.Position); err != nil {
+		return err
+	}
+
+	if err := setLinkTags(tx, link.ID, link.Tags); err != nil {
+		return err
+	}
+
+	return audit.Record(tx, actor, audit.ActionCreate, audit.EntityLink, link.ID, nil, link)
+}
+
+// icons for imported folders and bookmarks that did not come with one, every category and link gets its own copy since
+// the file is deleted along with them
+const (
+	defaultCategoryIcon = `<svg xmlns="http://www.w3.org/2000/svg" width="24" height="24" viewBox="0 0 24 24"><path fill="none" stroke="currentColor" stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M5 4h4l3 3h7a2 2 0 0 1 2 2v8a2 2 0 0 1-2 2H5a2 2 0 0 1-2-2V6a2 2 0 0 1 2-2" /></svg>`
+	defaultLinkIcon     = `<svg xmlns="http://www.w3.org/2000/svg" width="24" height="24" viewBox="0 0 24 24"><path fill="none" stroke="currentColor" stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M3 12a9 9 0 1 0 18 0a9 9 0 0 0-18 0m.6-3h16.8M3.6 15h16.8M11.5 3a17 17 0 0 0 0 18m1-18a17 17 0 0 1 0 18" /></svg>`
+)
+
+// ImportPlan is what importing a bookmark file would add to a board, it is shown as a preview before anything is written
+type ImportPlan struct {
+	Categories []ImportCategory `json:"categories"`
+	// how many links would be added, duplicates and skipped bookmarks are not counted
+	Links      int `json:"links"`
+	Duplicates int `json:"duplicates"`
+	Skipped    int `json:"skipped"`
+}
+
+type ImportCategory struct {
+	Name string `json:"name"`
+	// the category on the board with the same name that the links are added to, 0 if a new one is created
+	ExistingID int64        `json:"existing_id,omitempty"`
+	HasIcon    bool         `json:"has_icon"`
+	Links      []ImportLink `json:"links"`
+	icon       string
+}
+
+type ImportLink struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	URL         string   `json:"url"`
+	Tags        []string `json:"tags"`
+	HasIcon     bool     `json:"has_icon"`
+	// the URL is already on the board, or earlier in the file
+	Duplicate bool `json:"duplicate"`
+	// why the bookmark cannot be imported, empty if it can
+	Skip string `json:"skip,omitempty"`
+	icon string
+}
+
+// iconDataURI reads an uploaded icon into a data URI, or returns an empty string if the file is gone
+func iconDataURI(icon string) string {
+	data, err := os.ReadFile(filepath.Join("public/", icon))
+	if err != nil {
+		return ""
+	}
+
+	contentType := "image/webp"
+	if filepath.Ext(icon) == ".svg" {
+		contentType = "image/svg+xml"
+	}
+
+	return bookmarks.EncodeIcon(data, contentType)
+}
+
+// ExportBookmarks returns every category as a folder of bookmarks. With more than one board, each board gets a folder
+// holding its categories
+func (manager *CategoryManager) ExportBookmarks() []bookmarks.Folder {
+	boards := manager.GetBoards()
+
+	var folders []bookmarks.Folder
+	for _, board := range boards {
+		var categories []bookmarks.Folder
+		for _, category := range manager.GetCategories(board.ID) {
+			folder := bookmarks.Folder{Name: category.Name}
+			for _, link := range category.Links {
+				folder.Bookmarks = append(folder.Bookmarks, bookmarks.Bookmark{
+					Title:       link.Name,
+					URL:         link.URL,
+					Description: link.Description,
+					Tags:        link.Tags,
+					Icon:        iconDataURI(link.Icon),
+				})
+			}
+
+			categories = append(categories, folder)
+		}
+
+		if len(boards) == 1 {
+			return categories
+		}
+
+		folders = append(folders, bookmarks.Folder{Name: board.Title, Folders: categories})
+	}
+
+	return folders
+}
+
+// normalizeURL makes URLs that only differ in the case of their host, or a trailing slash, compare equal
+func normalizeURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Host = strings.ToLower(parsed.Host)
+	parsed.Path = strings.TrimSuffix(parsed.Path, "/")
+
+	return parsed.String()
+}
+
+// the format of a browser's bookmark export, the other formats are the dashboards in importers.Formats
+const importFormatBookmarks = "bookmarks"
+
+// parseImport reads a file to import in the given format, or works out the format if it is empty, and returns what is
+// in it along with the format
+func parseImport(data []byte, format string) ([]bookmarks.Folder, string, error) {
+	if format == "" || format == importFormatBookmarks {
+		folders, err := bookmarks.Parse(bytes.NewReader(data))
+		if format != "" || !errors.Is(err, bookmarks.ErrNotBookmarks) {
+			return folders, importFormatBookmarks, err
+		}
+	}
+
+	folders, format, err := importers.Parse(data, format)
+	if errors.Is(err, importers.ErrUnknownFormat) {
+		return nil, "", errors.New("file is not a bookmark export, or a Homer, Dashy, Heimdall or homepage configuration")
+	}
+
+	return folders, format, err
+}
+
+// PlanImport works out where the bookmarks would go on the board. Folders are matched to categories by name, and links
+// already on the board are marked as duplicates
+func (manager *CategoryManager) PlanImport(boardID int64, folders []bookmarks.Folder) *ImportPlan {
+	plan := &ImportPlan{Categories: []ImportCategory{}}
+
+	existing := map[string]int64{}
+	seen := map[string]bool{}
+	for _, category := range manager.GetCategories(boardID) {
+		existing[strings.ToLower(category.Name)] = category.ID
+		for _, link := range category.Links {
+			seen[normalizeURL(link.URL)] = true
+		}
+	}
+
+	indexes := map[string]int{}
+	for _, folder := range folders {
+		name := folder.Name
+		if name == "" {
+			name = "Bookmarks"
+		}
+		name = truncateText(name, 50)
+
+		key := strings.ToLower(name)
+		i, ok := indexes[key]
+		if !ok {
+			i = len(plan.Categories)
+			indexes[key] = i
+			plan.Categories = append(plan.Categories, ImportCategory{Name: name, ExistingID: existing[key], Links: []ImportLink{}})
+		}
+
+		if plan.Categories[i].icon == "" && plan.Categories[i].ExistingID == 0 {
+			plan.Categories[i].icon = folder.Icon
+			plan.Categories[i].HasIcon = folder.Icon != ""
+		}
+
+		for _, bookmark := range folder.Bookmarks {
+			link := ImportLink{
+				Name:        bookmark.Title,
+				Description: truncateText(bookmark.Description, 150),
+				URL:         bookmark.URL,
+				Tags:        []string{},
+				HasIcon:     bookmark.Icon != "",
+				icon:        bookmark.Icon,
+			}
+
+			if link.Name == "" {
+				link.Name = bookmark.URL
+			}
+			link.Name = truncateText(link.Name, 50)
+
+			// browsers allow longer and more tags than we do, keep the ones that fit
+			for _, tag := range bookmark.Tags {
+				if len(tag) <= 30 && len(link.Tags) < 10 {
+					link.Tags = append(link.Tags, tag)
+				}
+			}
+			link.Tags, _ = ParseTags(strings.Join(link.Tags, ","))
+
+			// bookmarklets and browser internal queries are rejected along with anything else that isnt a web page
+			switch {
+			case validateLinkURL(bookmark.URL) != nil:
+				link.Skip = "Unsupported URL"
+			case seen[normalizeURL(bookmark.URL)]:
+				link.Duplicate = true
+			}
+
+			switch {
+			case link.Skip != "":
+				plan.Skipped++
+			case link.Duplicate:
+				plan.Duplicates++
+			default:
+				seen[normalizeURL(bookmark.URL)] = true
+				plan.Links++
+			}
+
+			plan.Categories[i].Links = append(plan.Categories[i].Links, link)
+		}
+	}
+
+	return plan
+}
+
+// ApplyImport adds everything in the plan to the board in one transaction, duplicates are only added when asked to. Icons
+// that are not data URIs are fetched with icons, which also looks up the site's icon for links without one, browser
+// bookmarks pass nil since they carry their icons with them
+func (manager *CategoryManager) ApplyImport(actor audit.Actor, boardID int64, plan *ImportPlan, visibility string, includeDuplicates bool, icons *dashboardIcons) (categories int, links int, err error) {
+	var saved []string
+	saveImportIcon := func(icon string, linkURL string, fallback string) (string, error) {
+		icon, err := importIcon(icon, linkURL, icons, fallback)
+		if err == nil {
+			saved = append(saved, icon)
+		}
+		return icon, err
+	}
+
+	// nothing refers to the icons saved so far if the import fails
+	defer func() {
+		if err != nil {
+			for _, icon := range saved {
+				os.Remove(filepath.Join("public/", icon))
+			}
+		}
+	}()
+
+	type importedCategory struct {
+		ImportCategory
+		icon  string
+		links []ImportLink
+		icons []string
+	}
+
+	// icons can take a while to fetch, so they are all saved before the transaction starts
+	var imported []importedCategory
+	for _, planned := range plan.Categories {
+		category := importedCategory{ImportCategory: planned}
+		for _, link := range planned.Links {
+			if link.Skip == "" && (!link.Duplicate || includeDuplicates) {
+				category.links = append(category.links, link)
+			}
+		}
+
+		if len(category.links) == 0 {
+			continue
+		}
+
+		if planned.ExistingID == 0 {
+			category.icon, err = saveImportIcon(planned.icon, "", defaultCategoryIcon)
+			if err != nil {
+				return 0, 0, err
+			}
+		}
+
+		for _, link := range category.links {
+			icon, err := saveImportIcon(link.icon, link.URL, defaultLinkIcon)
+			if err != nil {
+				return 0, 0, err
+			}
+			category.icons = append(category.icons, icon)
+		}
+
+		imported = append(imported, category)
+	}
+
+	tx, err := manager.db.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	for _, planned := range imported {
+		categoryID := planned.ExistingID
+		if categoryID == 0 {
+			category := Category{
+				BoardID:    boardID,
+				Name:       planned.Name,
+				Icon:       planned.icon,
+				Sort:       SortManual,
+				Visibility: visibility,
+			}
+			if err := insertCategory(tx, actor, &category); err != nil {
+				return 0, 0, err
+			}
+
+			categoryID = category.ID
+			categories++
+		}
+
+		for i, bookmark := range planned.links {
+			link := Link{
+				CategoryID:  categoryID,
+				Name:        bookmark.Name,
+				Description: bookmark.Description,
+				Icon:        planned.icons[i],
+				URL:         bookmark.URL,
+				Tags:        bookmark.Tags,
+				Visibility:  visibility,
+			}
+			if err := insertLink(tx, actor, &link); err != nil {
+				return 0, 0, err
+			}
+
+			links++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, err
+	}
+
+	return categories, links, nil
+}
+
+// importIcon saves a copy of the icon a bookmark or folder was imported with. Browsers export icons as data URIs, other
+// dashboards refer to them by URL or by name in dashboard icons, those are only fetched when icons is set, and only
+// from the web, since their paths point at files on the other dashboard's server. When that fails, links use their
+// site's icon, and anything else that cannot be found gets the fallback
+func importIcon(icon string, linkURL string, icons *dashboardIcons, fallback string) (string, error) {
+	favicon := &services.Favicon{Data: []byte(fallback), ContentType: "image/svg+xml"}
+
+	if strings.HasPrefix(icon, "data:") {
+		data, contentType, err := bookmarks.DecodeIcon(icon)
+		if err == nil {
+			data, contentType, err = services.ConvertIcon(data, contentType)
+		}
+
+		if err != nil {
+			slog.Debug("Failed to import bookmark icon", "url", linkURL, "error", err)
+		} else {
+			favicon = &services.Favicon{Data: data, ContentType: contentType}
+		}
+	} else if icons != nil {
+		var sources []string
+		if kind, _ := dashboard.ParseIcon(icon); kind == dashboard.IconURL || kind == dashboard.IconName {
+			if source, err := icons.source(icon, ""); err == nil {
+				sources = append(sources, source)
+			}
+		}
+
+		if linkURL != "" {
+			sources = append(sources, "favicon:"+linkURL)
+		}
+
+		for _, source := range sources {
+			fetched, err := icons.fetch(source)
+			if err != nil {
+				slog.Debug("Failed to fetch imported icon", "source", source, "error", err)
+				continue
+			}
+
+			favicon = fetched
+			// icon sets like Material Design Icons leave the colour out, which would draw them black on the dark
+			// theme, so they get the text colour like the default icons
+			if favicon.ContentType == "image/svg+xml" && !bytes.Contains(favicon.Data, []byte("fill")) {
+				data := bytes.Replace(favicon.Data, []byte("<svg"), []byte(`<svg fill="currentColor"`), 1)
+				favicon = &services.Favicon{Data: data, ContentType: favicon.ContentType, URL: favicon.URL}
+			}
+			break
+		}
+	}
+
+	return saveIcon(bytes.NewReader(favicon.Data), favicon.ContentType)
+}
+
+// icon names are file names in the dashboard icons collection
+var iconNamePattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// everything that cannot be part of the file name of an exported icon
+var nonSlugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// how long fetching the icons of another dashboard's links may take during an import, the ones that are not fetched by
+// then get the default icon
+const maxImportIconTime = 45 * time.Second
+
+// dashboardIcons fetches the icons declared in a dashboard file or another dashboard's configuration, every source is
+// fetched at most once per sync or import no matter how many categories and links use it
+type dashboardIcons struct {
+	// once it is done, icons that still have to be fetched from the web fail
+	ctx context.Context
+	// icon paths are relative to the directory of the dashboard file
+	dir      string
+	iconURL  string
+	favicons *services.FaviconFinder
+	fetched  map[string]*services.Favicon
+	failed   map[string]error
+}
+
+func newDashboardIcons(ctx context.Context, dir string, iconURL string, favicons *services.FaviconFinder) *dashboardIcons {
+	return &dashboardIcons{
+		ctx:      ctx,
+		dir:      dir,
+		iconURL:  strings.TrimRight(iconURL, "/"),
+		favicons: favicons,
+		fetched:  map[string]*services.Favicon{},
+		failed:   map[string]error{},
+	}
+}
+
+// source identifies where the icon declared for a category or link comes from, it is stored alongside the icon so the
+// icon is only fetched again when the declaration changes. Icon files are identified by their contents, so editing one
+// is noticed too. Links without an icon use their site's icon, categories the default one
+func (icons *dashboardIcons) source(icon string, linkURL string) (string, error) {
+	kind, value := dashboard.ParseIcon(icon)
+
+	switch kind {
+	case dashboard.IconPath:
+		path := value
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(icons.dir, path)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+
+		sum := sha256.Sum256(data)
+		return "path:" + path + "#" + hex.EncodeToString(sum[:8]), nil
+	case dashboard.IconURL:
+		return "url:" + value, nil
+	case dashboard.IconName:
+		if !iconNamePattern.MatchString(value) {
+			return "", fmt.Errorf("%q is not a valid icon name", value)
+		}
+
+		return "name:" + value, nil
+	}
+
+	if linkURL != "" {
+		return "favicon:" + linkURL, nil
+	}
+
+	return "default", nil
+}
+
+func (icons *dashboardIcons) fetch(source string) (*services.Favicon, error) {
+	if favicon, ok := icons.fetched[source]; ok {
+		return favicon, nil
+	}
+
+	if err, ok := icons.failed[source]; ok {
+		return nil, err
+	}
+
+	var favicon *services.Favicon
+	var err error
+
+	kind, value, _ := strings.Cut(source, ":")
+	switch kind {
+	case "path":
+		path := value[:strings.LastIndex(value, "#")]
+
+		var data []byte
+		data, err = os.ReadFile(path)
+		if err == nil {
+			var contentType string
+			data, contentType, err = services.ConvertIcon(data, mime.TypeByExtension(filepath.Ext(path)))
+			favicon = &services.Favicon{Data: data, ContentType: contentType, URL: path}
+		}
+	case "url":
+		favicon, err = icons.favicons.Download(icons.ctx, value)
+	case "name":
+		// the collection has most icons as SVGs, the rest only as PNGs
+		favicon, err = icons.favicons.Download(icons.ctx, fmt.Sprintf("%s/svg/%s.svg", icons.iconURL, value))
+		if err != nil {
+			favicon, err = icons.favicons.Download(icons.ctx, fmt.Sprintf("%s/png/%s.png", icons.iconURL, value))
+		}
+	case "favicon":
+		favicon, err = icons.favicons.Find(icons.ctx, value)
+	default:
+		err = fmt.Errorf("unknown icon source %q", source)
+	}
+
+	if err != nil {
+		icons.failed[source] = err
+		return nil, err
+	}
+
+	icons.fetched[source] = favicon
+	return favicon, nil
+}
+
+// resolve returns the icon a category or link should have, and the source to store with it. The current icon is kept
+// if its source did not change, otherwise a new copy is saved. Icons that cannot be fetched are replaced by fallback
+// until they can be, apart from icon files, which are an error in the dashboard file
+func (icons *dashboardIcons) resolve(source string, current string, currentSource string, fallback string) (string, string, error) {
+	if currentSource == source && iconExists(current) {
+		return current, source, nil
+	}
+
+	favicon := &services.Favicon{Data: []byte(fallback), ContentType: "image/svg+xml"}
+	if source != "default" {
+		fetched, err := icons.fetch(source)
+		if err != nil {
+			if strings.HasPrefix(source, "path:") {
+				return "", "", err
+			}
+
+			slog.Warn("Failed to fetch icon, using the default icon for now", "source", source, "error", err)
+			source = "fallback:" + source
+			if currentSource == source && iconExists(current) {
+				return current, source, nil
+			}
+		} else {
+			favicon = fetched
+		}
+	}
+
+	icon, err := saveIcon(bytes.NewReader(favicon.Data), favicon.ContentType)
+	if err != nil {
+		return "", "", err
+	}
+
+	return icon, source, nil
+}
+
+// iconExists reports whether an icon is an uploaded file that is still there
+func iconExists(icon string) bool {
+	if !strings.HasPrefix(icon, "/uploads/") {
+		return false
+	}
+
+	_, err := os.Stat(filepath.Join("public/", icon))
+	return err == nil
+}
+
+// normalizeDashboard checks every value in a dashboard file the same way the admin dashboard would, and fills in the
+// defaults for what was left out
+func normalizeDashboard(file *dashboard.File) error {
+	// keywords and aliases work across boards, so each can only be used once in the whole file
+	keywords := map[string]string{}
+	aliases := map[string]string{}
+
+	for i := range file.Boards {
+		board := &file.Boards[i]
+		board.Slug = strings.TrimSpace(board.Slug)
+		board.Title = strings.TrimSpace(board.Title)
+
+		if err := ValidateBoardSlug(board.Slug); err != nil {
+			return fmt.Errorf("board %q: %w", board.Slug, err)
+		}
+
+		if len(board.Title) > 50 {
+			return fmt.Errorf("board %q: Title is too long. Maximum length is 50 characters", board.Slug)
+		}
+
+		for j := range board.Categories {
+			category := &board.Categories[j]
+			category.Name = strings.TrimSpace(category.Name)
+
+			if len(category.Name) > 50 {
+				return fmt.Errorf("board %q, category %q: Name is too long. Maximum length is 50 characters", board.Slug, category.Name)
+			}
+
+			if category.Sort == "" {
+				category.Sort = SortManual
+			}
+
+			if category.Visibility == "" {
+				category.Visibility = VisibilityPublic
+			}
+
+			if err := ValidateSort(category.Sort); err != nil {
+				return fmt.Errorf("board %q, category %q: %w", board.Slug, category.Name, err)
+			}
+
+			if err := ValidateVisibility(category.Visibility); err != nil {
+				return fmt.Errorf("board %q, category %q: %w", board.Slug, category.Name, err)
+			}
+
+			for k := range category.Links {
+				link := &category.Links[k]
+				link.Name = strings.TrimSpace(link.Name)
+				link.Description = strings.TrimSpace(link.Description)
+				link.URL = strings.TrimSpace(link.URL)
+
+				where := fmt.Sprintf("board %q, category %q, link %q", board.Slug, category.Name, link.Name)
+
+				if len(link.Name) > 50 {
+					return fmt.Errorf("%s: Name is too long. Maximum length is 50 characters", where)
+				}
+
+				if len(link.Description) > 150 {
+					return fmt.Errorf("%s: Description is too long. Maximum length is 150 characters", where)
+				}
+
+				if err := validateLinkURL(link.URL); err != nil {
+					return fmt.Errorf("%s: %w", where, err)
+				}
+
+				tags, err := ParseTags(strings.Join(link.Tags, ","))
+				if err != nil {
+					return fmt.Errorf("%s: %w", where, err)
+				}
+				link.Tags = tags
+
+				if link.Visibility == "" {
+					link.Visibility = VisibilityPublic
+				}
+
+				if err := ValidateVisibility(link.Visibility); err != nil {
+					return fmt.Errorf("%s: %w", where, err)
+				}
+
+				keyword, err := ParseKeyword(link.Keyword, "Keyword")
+				if err != nil {
+					return fmt.Errorf("%s: %w", where, err)
+				}
+				link.Keyword = keyword
+
+				if keyword != "" {
+					if keywords[keyword] != "" {
+						return fmt.Errorf("%s: Keyword is already used by %s", where, keywords[keyword])
+					}
+					keywords[keyword] = link.Name
+				}
+
+				link.SearchURL = strings.TrimSpace(link.SearchURL)
+				if err := validateSearchURL(link.SearchURL); err != nil {
+					return fmt.Errorf("%s: %w", where, err)
+				}
+
+				alias, err := ParseAlias(link.Alias)
+				if err != nil {
+					return fmt.Errorf("%s: %w", where, err)
+				}
+				link.Alias = alias
+
+				if alias != "" {
+					if aliases[alias] != "" {
+						return fmt.Errorf("%s: Alias is already used by %s", where, aliases[alias])
+					}
+					aliases[alias] = link.Name
+				}
+			}
+		}
+	}
+
+	bangs := map[string]bool{}
+	for i := range file.Bangs {
+		declared := &file.Bangs[i]
+		bang := Bang{Bang: declared.Bang, Name: declared.Name, URL: declared.URL}
+		if err := ValidateBang(&bang); err != nil {
+			return fmt.Errorf("bang %q: %w", declared.Bang, err)
+		}
+
+		// !g and G are the same bang once cleaned up
+		if bangs[bang.Bang] {
+			return fmt.Errorf("bang %q is declared twice", bang.Bang)
+		}
+		bangs[bang.Bang] = true
+
+		declared.Bang, declared.Name, declared.URL = bang.Bang, bang.Name, bang.URL
+	}
+
+	shortcuts := map[string]bool{}
+	for i := range file.SearchProviders {
+		declared := &file.SearchProviders[i]
+		provider := SearchProvider{Name: declared.Name, Shortcut: declared.Shortcut, URL: declared.URL, SuggestURL: declared.SuggestURL}
+		if err := ValidateSearchProvider(&provider); err != nil {
+			return fmt.Errorf("search provider %q: %w", declared.Shortcut, err)
+		}
+
+		if shortcuts[provider.Shortcut] {
+			return fmt.Errorf("search provider %q is declared twice", provider.Shortcut)
+		}
+		shortcuts[provider.Shortcut] = true
+
+		declared.Name, declared.Shortcut, declared.URL, declared.SuggestURL = provider.Name, provider.Shortcut, provider.URL, provider.SuggestURL
+	}
+
+	return nil
+}
+
+// ApplyDashboard makes the boards, categories and links match a dashboard file, and returns how many were created,
+// changed or removed. What the file leaves out goes to the trash rather than being deleted for good, see
+// dashboard.NewPlan. Every change is logged before it is made, and recorded in the audit log as made by passport itself
+func (manager *CategoryManager) ApplyDashboard(file *dashboard.File, icons *dashboardIcons) (int, error) {
+	if err := normalizeDashboard(file); err != nil {
+		return 0, err
+	}
+
+	state, err := manager.dashboardState()
+	if err != nil {
+		return 0, err
+	}
+
+	plan := dashboard.NewPlan(file, state)
+
+	// icons saved for this sync are removed again if it fails, the icons they replace once it succeeds
+	var saved, replaced []string
+	committed := false
+	defer func() {
+		if !committed {
+			for _, icon := range saved {
+				os.Remove(filepath.Join("public/", icon))
+			}
+		}
+	}()
+
+	resolve := func(declared string, linkURL string, current string, currentSource string, fallback string) (string, string, error) {
+		source, err := icons.source(declared, linkURL)
+		if err != nil {
+			return "", "", err
+		}
+
+		icon, source, err := icons.resolve(source, current, currentSource, fallback)
+		if err != nil {
+			return "", "", err
+		}
+
+		if icon != current {
+			saved = append(saved, icon)
+			if current != "" {
+				replaced = append(replaced, current)
+			}
+		}
+
+		return icon, source, nil
+	}
+
+	// the icons are fetched before the transaction starts, so it is not held open while waiting on other sites
+	for _, board := range plan.Boards {
+		for _, category := range board.Categories {
+			var currentIcon, currentSource string
+			if category.Existing != nil {
+				currentIcon, currentSource = category.Existing.Icon, category.Existing.IconSource
+			}
+
+			category.Category.Icon, category.Category.IconSource, err = resolve(category.DeclaredIcon, "", currentIcon, currentSource, defaultCategoryIcon)
+			if err != nil {
+				return 0, fmt.Errorf("board %q, category %q: icon: %w", board.Board.Slug, category.Category.Name, err)
+			}
+
+			for _, link := range category.Links {
+				var currentIcon, currentSource string
+				if link.Existing != nil {
+					currentIcon, currentSource = link.Existing.Icon, link.Existing.IconSource
+				}
+
+				link.Link.Icon, link.Link.IconSource, err = resolve(link.DeclaredIcon, link.Link.URL, currentIcon, currentSource, defaultLinkIcon)
+				if err != nil {
+					return 0, fmt.Errorf("board %q, category %q, link %q: icon: %w", board.Board.Slug, category.Category.Name, link.Link.Name, err)
+				}
+			}
+		}
+	}
+
+	for _, provider := range plan.SearchProviders {
+		var currentIcon, currentSource string
+		if provider.Existing != nil {
+			currentIcon, currentSource = provider.Existing.Icon, provider.Existing.IconSource
+		}
+
+		provider.Provider.Icon, provider.Provider.IconSource, err = resolve(provider.DeclaredIcon, siteURL(provider.Provider.URL), currentIcon, currentSource, defaultLinkIcon)
+		if err != nil {
+			return 0, fmt.Errorf("search provider %q: icon: %w", provider.Provider.Shortcut, err)
+		}
+	}
+
+	for _, change := range plan.Describe() {
+		slog.Info("Applying dashboard file", "change", change)
+	}
+
+	tx, err := manager.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	store := &dashboardStore{tx: tx}
+	changes, err := plan.Apply(store)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	committed = true
+
+	for _, icon := range append(replaced, store.removed...) {
+		if icon == "" {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join("public/", icon)); err != nil && !os.IsNotExist(err) {
+			slog.Error("Failed to delete icon", "icon", icon, "error", err)
+		}
+	}
+
+	return changes, nil
+}
+
+// dashboardState reads everything a dashboard file is applied to
+func (manager *CategoryManager) dashboardState() (*dashboard.State, error) {
+	state := &dashboard.State{}
+
+	for _, board := range manager.GetBoards() {
+		state.Boards = append(state.Boards, dashboard.BoardRecord{ID: board.ID, Slug: board.Slug, Title: board.Title, Default: board.IsDefault})
+	}
+
+	categoryRows, err := manager.db.Query(`
+		SELECT id, board_id, name, icon, position, sort, visibility, COALESCE(icon_source, ''), deleted_at IS NOT NULL
+		FROM categories
+		ORDER BY position ASC, id ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer categoryRows.Close()
+
+	for categoryRows.Next() {
+		var cat dashboard.CategoryRecord
+		if err := categoryRows.Scan(&cat.ID, &cat.BoardID, &cat.Name, &cat.Icon, &cat.Position, &cat.Sort, &cat.Visibility, &cat.IconSource, &cat.Deleted); err != nil {
+			return nil, err
+		}
+		state.Categories = append(state.Categories, cat)
+	}
+	if err := categoryRows.Err(); err != nil {
+		return nil, err
+	}
+
+	linkRows, err := manager.db.Query(`
+		SELECT id, category_id, name, description, icon, url, position, pinned, pinned_position, visibility, keyword,
+			search_url, alias, COALESCE(icon_source, ''), deleted_at IS NOT NULL
+		FROM links
+		ORDER BY position ASC, id ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer linkRows.Close()
+
+	tags, err := getTags(manager.db, "")
+	if err != nil {
+		return nil, err
+	}
+
+	for linkRows.Next() {
+		var link dashboard.LinkRecord
+		if err := linkRows.Scan(&link.ID, &link.CategoryID, &link.Name, &link.Description, &link.Icon, &link.URL, &link.Position,
+			&link.Pinned, &link.PinnedPosition, &link.Visibility, &link.Keyword, &link.SearchURL, &link.Alias, &link.IconSource, &link.Deleted); err != nil {
+			return nil, err
+		}
+
+		link.Tags = tags[link.ID]
+		if link.Tags == nil {
+			link.Tags = []string{}
+		}
+		state.Links = append(state.Links, link)
+	}
+	if err := linkRows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, bang := range manager.GetBangs() {
+		state.Bangs = append(state.Bangs, dashboard.BangRecord{ID: bang.ID, Bang: bang.Bang, Name: bang.Name, URL: bang.URL})
+	}
+
+	providerRows, err := manager.db.Query(`SELECT ` + searchProviderColumns + `, COALESCE(icon_source, '') FROM search_providers`)
+	if err != nil {
+		return nil, err
+	}
+	defer providerRows.Close()
+
+	for providerRows.Next() {
+		var provider dashboard.SearchProviderRecord
+		if err := providerRows.Scan(&provider.ID, &provider.Name, &provider.Shortcut, &provider.URL, &provider.SuggestURL, &provider.Icon,
+			&provider.Default, &provider.IconSource); err != nil {
+			return nil, err
+		}
+		state.SearchProviders = append(state.SearchProviders, provider)
+	}
+
+	return state, providerRows.Err()
+}
+
+// dashboardStore makes the changes of a dashboard plan in a transaction, recording each one in the audit log as made by
+// passport itself
+type dashboardStore struct {
+	tx    *sql.Tx
+	actor audit.Actor
+	// icons that are no longer used by anything once the transaction is committed
+	removed []string
+}
+
+func (store *dashboardStore) CreateBoard(record dashboard.BoardRecord) (int64, error) {
+	board := Board{Slug: record.Slug, Title: record.Title}
+	if err := store.tx.QueryRow(`INSERT INTO boards (slug, title) VALUES (?, ?) RETURNING id`, board.Slug, board.Title).Scan(&board.ID); err != nil {
+		return 0, err
+	}
+
+	return board.ID, audit.Record(store.tx, store.actor, audit.ActionCreate, audit.EntityBoard, board.ID, nil, board)
+}
+
+func (store *dashboardStore) UpdateBoard(before dashboard.BoardRecord, after dashboard.BoardRecord) error {
+	if _, err := store.tx.Exec(`UPDATE boards SET title = ? WHERE id = ?`, after.Title, after.ID); err != nil {
+		return err
+	}
+
+	return audit.Record(store.tx, store.actor, audit.ActionUpdate, audit.EntityBoard, after.ID, boardFromRecord(before), boardFromRecord(after))
+}
+
+func (store *dashboardStore) SetDefaultBoard(id int64) error {
+	return setDefaultBoard(store.tx, id)
+}
+
+func (store *dashboardStore) RemoveBoard(record dashboard.BoardRecord) error {
+	board := boardFromRecord(record)
+	return trashBoard(store.tx, store.actor, &board)
+}
+
+func (store *dashboardStore) CreateCategory(record dashboard.CategoryRecord) (int64, error) {
+	category := categoryFromRecord(record)
+	err := store.tx.QueryRow(`
+		INSERT INTO categories (board_id, name, icon, icon_source, position, sort, visibility)
+		VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id
+	`, category.BoardID, category.Name, category.Icon, record.IconSource, category.Position, category.Sort, category.Visibility).Scan(&category.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	return category.ID, audit.Record(store.tx, store.actor, audit.ActionCreate, audit.EntityCategory, category.ID, nil, category)
+}
+
+func (store *dashboardStore) UpdateCategory(before dashboard.CategoryRecord, after dashboard.CategoryRecord) error {
+	_, err := store.tx.Exec(`
+		UPDATE categories
+		SET name = ?, icon = ?, icon_source = ?, position = ?, sort = ?, visibility = ?, deleted_at = NULL
+		WHERE id = ?
+	`, after.Name, after.Icon, after.IconSource, after.Position, after.Sort, after.Visibility, after.ID)
+	if err != nil {
+		return err
+	}
+
+	return audit.Record(store.tx, store.actor, audit.ActionUpdate, audit.EntityCategory, after.ID, categoryFromRecord(before), categoryFromRecord(after))
+}
+
+func (store *dashboardStore) TrashCategory(record dashboard.CategoryRecord) error {
+	if err := trash(store.tx, "categories", record.ID); err != nil {
+		return err
+	}
+
+	return audit.Record(store.tx, store.actor, audit.ActionDelete, audit.EntityCategory, record.ID, categoryFromRecord(record), nil)
+}
+
+func (store *dashboardStore) CreateLink(record dashboard.LinkRecord) (int64, error) {
+	link := linkFromRecord(record)
+	err := store.tx.QueryRow(`
+		INSERT INTO links (category_id, name, description, icon, icon_source, url, position, pinned, pinned_position,
+			visibility, keyword, search_url, alias)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id
+	`, link.CategoryID, link.Name, link.Description, link.Icon, record.IconSource, link.URL, link.Position,
+		link.Pinned, record.PinnedPosition, link.Visibility, link.Keyword, link.SearchURL, link.Alias).Scan(&link.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := setLinkTags(store.tx, link.ID, link.Tags); err != nil {
+		return 0, err
+	}
+
+	return link.ID, audit.Record(store.tx, store.actor, audit.ActionCreate, audit.EntityLink, link.ID, nil, link)
+}
+
+func (store *dashboardStore) UpdateLink(before dashboard.LinkRecord, after dashboard.LinkRecord) error {
+	_, err := store.tx.Exec(`
+		UPDATE links
+		SET category_id = ?, name = ?, description = ?, icon = ?, icon_source = ?, url = ?, position = ?, pinned = ?,
+			pinned_position = ?, visibility = ?, keyword = ?, search_url = ?, alias = ?, deleted_at = NULL
+		WHERE id = ?
+	`, after.CategoryID, after.Name, after.Description, after.Icon, after.IconSource, after.URL, after.Position, after.Pinned,
+		after.PinnedPosition, after.Visibility, after.Keyword, after.SearchURL, after.Alias, after.ID)
+	if err != nil {
+		return err
+	}
+
+	if !slices.Equal(before.Tags, after.Tags) {
+		if err := setLinkTags(store.tx, after.ID, after.Tags); err != nil {
+			return err
+		}
+	}
+
+	return audit.Record(store.tx, store.actor, audit.ActionUpdate, audit.EntityLink, after.ID, linkFromRecord(before), linkFromRecord(after))
+}
+
+func (store *dashboardStore) TrashLink(record dashboard.LinkRecord) error {
+	if err := trash(store.tx, "links", record.ID); err != nil {
+		return err
+	}
+
+	return audit.Record(store.tx, store.actor, audit.ActionDelete, audit.EntityLink, record.ID, linkFromRecord(record), nil)
+}
+
+func (store *dashboardStore) CreateBang(record dashboard.BangRecord) (int64, error) {
+	bang := bangFromRecord(record)
+	if err := store.tx.QueryRow(`INSERT INTO bangs (bang, name, url) VALUES (?, ?, ?) RETURNING id`, bang.Bang, bang.Name, bang.URL).Scan(&bang.ID); err != nil {
+		return 0, err
+	}
+
+	return bang.ID, audit.Record(store.tx, store.actor, audit.ActionCreate, audit.EntityBang, bang.ID, nil, bang)
+}
+
+func (store *dashboardStore) UpdateBang(before dashboard.BangRecord, after dashboard.BangRecord) error {
+	if _, err := store.tx.Exec(`UPDATE bangs SET name = ?, url = ? WHERE id = ?`, after.Name, after.URL, after.ID); err != nil {
+		return err
+	}
+
+	return audit.Record(store.tx, store.actor, audit.ActionUpdate, audit.EntityBang, after.ID, bangFromRecord(before), bangFromRecord(after))
+}
+
+func (store *dashboardStore) DeleteBang(record dashboard.BangRecord) error {
+	if _, err := store.tx.Exec(`DELETE FROM bangs WHERE id = ?`, record.ID); err != nil {
+		return err
+	}
+
+	return audit.Record(store.tx, store.actor, audit.ActionDelete, audit.EntityBang, record.ID, bangFromRecord(record), nil)
+}
+
+func (store *dashboardStore) CreateSearchProvider(record dashboard.SearchProviderRecord) (int64, error) {
+	provider := searchProviderFromRecord(record)
+	err := store.tx.QueryRow(`
+		INSERT INTO search_providers (name, shortcut, url, suggest_url, icon, icon_source, is_default)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`, provider.Name, provider.Shortcut, provider.URL, provider.SuggestURL, provider.Icon, record.IconSource,
+		provider.IsDefault).Scan(&provider.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	return provider.ID, audit.Record(store.tx, store.actor, audit.ActionCreate, audit.EntitySearchProvider, provider.ID, nil, provider)
+}
+
+func (store *dashboardStore) UpdateSearchProvider(before dashboard.SearchProviderRecord, after dashboard.SearchProviderRecord) error {
+	_, err := store.tx.Exec(`
+		UPDATE search_providers
+		SET name = ?, url = ?, suggest_url = ?, icon = ?, icon_source = ?, is_default = ?
+		WHERE id = ?
+	`, after.Name, after.URL, after.SuggestURL, after.Icon, after.IconSource, after.Default, after.ID)
+	if err != nil {
+		return err
+	}
+
+	return audit.Record(store.tx, store.actor, audit.ActionUpdate, audit.EntitySearchProvider, after.ID,
+		searchProviderFromRecord(before), searchProviderFromRecord(after))
+}
+
+// search providers have no trash, so unlike categories and links they are deleted for good
+func (store *dashboardStore) DeleteSearchProvider(record dashboard.SearchProviderRecord) error {
+	if _, err := store.tx.Exec(`DELETE FROM search_providers WHERE id = ?`, record.ID); err != nil {
+		return err
+	}
+
+	store.removed = append(store.removed, record.Icon)
+	return audit.Record(store.tx, store.actor, audit.ActionDelete, audit.EntitySearchProvider, record.ID, searchProviderFromRecord(record), nil)
+}
+
+// the audit log records the same shapes as the rest of passport does
+
+func boardFromRecord(record dashboard.BoardRecord) Board {
+	return Board{ID: record.ID, Slug: record.Slug, Title: record.Title, IsDefault: record.Default}
+}
+
+func categoryFromRecord(record dashboard.CategoryRecord) Category {
+	return Category{
+		ID:         record.ID,
+		BoardID:    record.BoardID,
+		Name:       record.Name,
+		Icon:       record.Icon,
+		Position:   record.Position,
+		Sort:       record.Sort,
+		Visibility: record.Visibility,
+	}
+}
+
+func linkFromRecord(record dashboard.LinkRecord) Link {
+	return Link{
+		ID:          record.ID,
+		CategoryID:  record.CategoryID,
+		Name:        record.Name,
+		Description: record.Description,
+		Icon:        record.Icon,
+		URL:         record.URL,
+		Position:    record.Position,
+		Tags:        record.Tags,
+		Pinned:      record.Pinned,
+		Visibility:  record.Visibility,
+		Keyword:     record.Keyword,
+		SearchURL:   record.SearchURL,
+		Alias:       record.Alias,
+	}
+}
+
+func bangFromRecord(record dashboard.BangRecord) Bang {
+	return Bang{ID: record.ID, Bang: record.Bang, Name: record.Name, URL: record.URL}
+}
+
+func searchProviderFromRecord(record dashboard.SearchProviderRecord) SearchProvider {
+	return SearchProvider{
+		ID:         record.ID,
+		Name:       record.Name,
+		Shortcut:   record.Shortcut,
+		URL:        record.URL,
+		SuggestURL: record.SuggestURL,
+		Icon:       record.Icon,
+		IsDefault:  record.Default,
+	}
+}
+
+// ExportDashboard describes every board, category and link as a dashboard file. Icons that came from a dashboard file
+// are declared the same way again, every other icon is copied into dir/icons
+func (manager *CategoryManager) ExportDashboard(dir string) (*dashboard.File, error) {
+	sources := map[string]string{}
+	for _, table := range []string{"categories", "links", "search_providers"} {
+		rows, err := manager.db.Query(fmt.Sprintf(`SELECT id, icon_source FROM %s WHERE icon_source IS NOT NULL`, table))
+		if err != nil {
+			return nil, err
+		}
+
+		for rows.Next() {
+			var id int64
+			var source string
+			if err := rows.Scan(&id, &source); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			sources[fmt.Sprintf("%s:%d", table, id)] = source
+		}
+		rows.Close()
+	}
+
+	usedNames := map[string]bool{}
+	exportIcon := func(icon string, source string, name string) (string, error) {
+		kind, value, _ := strings.Cut(strings.TrimPrefix(source, "fallback:"), ":")
+		switch kind {
+		case "url", "name":
+			return value, nil
+		case "favicon", "default":
+			return "", nil
+		}
+
+		if !iconExists(icon) {
+			return "", nil
+		}
+
+		data, err := os.ReadFile(filepath.Join("public/", icon))
+		if err != nil {
+			return "", err
+		}
+
+		base := strings.Trim(nonSlugPattern.ReplaceAllString(strings.ToLower(name), "-"), "-")
+		if base == "" {
+			base = "icon"
+		}
+
+		fileName := base + filepath.Ext(icon)
+		for i := 2; usedNames[fileName]; i++ {
+			fileName = fmt.Sprintf("%s-%d%s", base, i, filepath.Ext(icon))
+		}
+		usedNames[fileName] = true
+
+		if err := os.MkdirAll(filepath.Join(dir, "icons"), 0755); err != nil {
+			return "", err
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, "icons", fileName), data, 0644); err != nil {
+			return "", err
+		}
+
+		return "icons/" + fileName, nil
+	}
+
+	file := &dashboard.File{}
+	for _, board := range manager.GetBoards() {
+		declaredBoard := dashboard.Board{
+			Slug:    board.Slug,
+			Title:   board.Title,
+			Default: board.IsDefault,
+		}
+
+		for _, category := range manager.GetCategories(board.ID) {
+			icon, err := exportIcon(category.Icon, sources[fmt.Sprintf("categories:%d", category.ID)], category.Name)
+			if err != nil {
+				return nil, err
+			}
+
+			declared := dashboard.Category{
+				Name: category.Name,
+				Icon: icon,
+			}
+
+			// defaults are left out to keep the file short
+			if category.Sort != SortManual {
+				declared.Sort = category.Sort
+			}
+
+			if category.Visibility != VisibilityPublic {
+				declared.Visibility = category.Visibility
+			}
+
+			// links are declared in their manual order, most used categories are sorted when shown
+			links := manager.GetLinks(category.ID)
+			sort.SliceStable(links, func(i, j int) bool {
+				return links[i].Position < links[j].Position
+			})
+
+			for _, link := range links {
+				icon, err := exportIcon(link.Icon, sources[fmt.Sprintf("links:%d", link.ID)], link.Name)
+				if err != nil {
+					return nil, err
+				}
+
+				declaredLink := dashboard.Link{
+					Name:        link.Name,
+					URL:         link.URL,
+					Description: link.Description,
+					Icon:        icon,
+					Tags:        link.Tags,
+					Pinned:      link.Pinned,
+					Keyword:     link.Keyword,
+					SearchURL:   link.SearchURL,
+					Alias:       link.Alias,
+				}
+
+				if link.Visibility != VisibilityPublic {
+					declaredLink.Visibility = link.Visibility
+				}
+
+				declared.Links = append(declared.Links, declaredLink)
+			}
+
+			declaredBoard.Categories = append(declaredBoard.Categories, declared)
+		}
+
+		file.Boards = append(file.Boards, declaredBoard)
+	}
+
+	for _, bang := range manager.GetBangs() {
+		file.Bangs = append(file.Bangs, dashboard.Bang{Bang: bang.Bang, Name: bang.Name, URL: bang.URL})
+	}
+
+	for _, provider := range manager.GetSearchProviders() {
+		icon, err := exportIcon(provider.Icon, sources[fmt.Sprintf("search_providers:%d", provider.ID)], provider.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		file.SearchProviders = append(file.SearchProviders, dashboard.SearchProvider{
+			Name:       provider.Name,
+			Shortcut:   provider.Shortcut,
+			URL:        provider.URL,
+			SuggestURL: provider.SuggestURL,
+			Icon:       icon,
+			Default:    provider.IsDefault,
+		})
+	}
+
+	return file, nil
+}
+
+// DeleteLink moves a link to the trash
+func (manager *CategoryManager) DeleteLink(actor audit.Actor, id int64) error {
+	tx, err := manager.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := trash(tx, "links", id); err != nil {
+		return err
+	}
+
+	link, err := lookupLink(tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := audit.Record(tx, actor, audit.ActionDelete, audit.EntityLink, id, link, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RestoreLink takes a link out of the trash. Links in a category that is still in the trash cant be restored
+// on their own, the category has to be restored first. A link whose keyword or alias was taken by another link while it
+// was in the trash is restored without it
+func (manager *CategoryManager) RestoreLink(actor audit.Actor, id int64) error {
+	tx, err := manager.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var categoryInTrash bool
+	err = tx.QueryRow(`
+		SELECT categories.deleted_at IS NOT NULL
+		FROM links
+		JOIN categories ON categories.id = links.category_id
+		WHERE links.id = ?
+	`, id).Scan(&categoryInTrash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotInTrash
+		}
+		return err
+	}
+
+	if categoryInTrash {
+		return ErrCategoryInTrash
+	}
+
+	if err := releaseTaken(tx, id); err != nil {
+		return err
+	}
+
+	if err := restore(tx, "links", id); err != nil {
+		return err
+	}
+
+	link, err := lookupLink(tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := audit.Record(tx, actor, audit.ActionRestore, audit.EntityLink, id, nil, link); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// releaseTaken clears the keyword and alias of a link in the trash when another link has taken them since, so the link
+// can be restored without them
+func releaseTaken(tx *sql.Tx, id int64) error {
+	var keyword, alias string
+	if err := tx.QueryRow(`SELECT keyword, alias FROM links WHERE id = ?`, id).Scan(&keyword, &alias); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotInTrash
+		}
+		return err
+	}
+
+	if keyword != "" {
+		owner, err := keywordOwner(tx, keyword, id)
+		if err != nil {
+			return err
+		}
+
+		if owner != "" {
+			slog.Info("Restored link loses its keyword to another link", "link", id, "keyword", keyword, "owner", owner)
+			if _, err := tx.Exec(`UPDATE links SET keyword = '' WHERE id = ?`, id); err != nil {
+				return err
+			}
+		}
+	}
+
+	if alias != "" {
+		owner, err := aliasOwner(tx, alias, id)
+		if err != nil {
+			return err
+		}
+
+		if owner != "" {
+			slog.Info("Restored link loses its alias to another link", "link", id, "alias", alias, "owner", owner)
+			if _, err := tx.Exec(`UPDATE links SET alias = '' WHERE id = ?`, id); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// PurgeLink permanently deletes a link in the trash along with its icon
+func (manager *CategoryManager) PurgeLink(actor audit.Actor, id int64) error {
+	tx, err := manager.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var icon string
+	if err := tx.QueryRow("SELECT icon FROM links WHERE id = ? AND deleted_at IS NOT NULL", id).Scan(&icon); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotInTrash
+		}
+		return err
+	}
+
+	link, err := lookupLink(tx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM links WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	if err := deleteOrphanedTags(tx); err != nil {
+		return err
+	}
+
+	if err := audit.Record(tx, actor, audit.ActionPurge, audit.EntityLink, id, link, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if icon != "" {
+		if err := os.Remove(filepath.Join("public/", icon)); err != nil {
+			slog.Error("Failed to delete icon", "icon", icon, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// CheckIntegrity reports rows that break a foreign key, which can only exist in databases created before
+// foreign keys were enforced. If repair is true the offending rows, and the icons of orphaned links, are deleted.
+func (manager *CategoryManager) CheckIntegrity(repair bool) error {
+	rows, err := manager.db.Query(`PRAGMA foreign_key_check`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type violation struct {
+		table string
+		rowID int64
+	}
+
+	var violations []violation
+	for rows.Next() {
+		var v violation
+		var parent string
+		var fkID int64
+		if err := rows.Scan(&v.table, &v.rowID, &parent, &fkID); err != nil {
+			return err
+		}
+
+		slog.Warn("Found row referencing a missing parent", "table", v.table, "rowid", v.rowID, "parent", parent)
+		violations = append(violations, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	if !repair {
+		slog.Warn("Database contains orphaned rows, set PASSPORT_REPAIR_ORPHANS=true to delete them", "count", len(violations))
+		return nil
+	}
+
+	tx, err := manager.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var icons []string
+	for _, v := range violations {
+		if v.table == "links" {
+			var icon string
+			if err := tx.QueryRow(`SELECT icon FROM links WHERE rowid = ?`, v.rowID).Scan(&icon); err != nil {
+				return err
+			}
+			icons = append(icons, icon)
+		}
+
+		if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE rowid = ?", v.table), v.rowID); err != nil {
+			return err
+		}
+	}
+
+	if err := deleteOrphanedTags(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, icon := range icons {
+		if icon == "" {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join("public/", icon)); err != nil {
+			slog.Error("Failed to delete icon", "icon", icon, "error", err)
+		}
+	}
+
+	slog.Info("Deleted orphaned rows", "count", len(violations))
+
+	return nil
+}
+
+var (
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrLinkNotFound     = errors.New("link not found")
+	ErrNotInTrash       = errors.New("item is not in the trash")
+	ErrCategoryInTrash  = errors.New("the link's category is in the trash, restore the category first")
+)
+
+// trash marks a category or link as deleted without touching its row, so it can be restored later
+func trash(tx *sql.Tx, table string, id int64) error {
+	result, err := tx.Exec(fmt.Sprintf("UPDATE %s SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL", table),
+		time.Now().UTC().Format(time.RFC3339), id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		if table == "categories" {
+			return ErrCategoryNotFound
+		}
+		return ErrLinkNotFound
+	}
+
+	return nil
+}
+
+func restore(tx *sql.Tx, table string, id int64) error {
+	result, err := tx.Exec(fmt.Sprintf("UPDATE %s SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL", table), id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrNotInTrash
+	}
+
+	return nil
+}
+
+type TrashedCategory struct {
+	Category
+	BoardTitle string
+	LinkCount  int64
+	DeletedAt  string
+}
+
+type TrashedLink struct {
+	Link
+	CategoryName    string
+	CategoryInTrash bool
+	DeletedAt       string
+}
+
+// GetTrash returns everything in the trash, most recently deleted first
+func (manager *CategoryManager) GetTrash() ([]TrashedCategory, []TrashedLink, error) {
+	rows, err := manager.db.Query(`
+		SELECT categories.id, categories.board_id, categories.name, categories.icon, categories.deleted_at, boards.title,
+			(SELECT COUNT(*) FROM links WHERE links.category_id = categories.id)
+		FROM categories
+		JOIN boards ON boards.id = categories.board_id
+		WHERE categories.deleted_at IS NOT NULL
+		ORDER BY categories.deleted_at DESC, categories.id DESC
+	`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var categories []TrashedCategory
+	for rows.Next() {
+		var cat TrashedCategory
+		if err := rows.Scan(&cat.ID, &cat.BoardID, &cat.Name, &cat.Icon, &cat.DeletedAt, &cat.BoardTitle, &cat.LinkCount); err != nil {
+			return nil, nil, err
+		}
+		cat.DeletedAt = formatTime(cat.DeletedAt)
+		categories = append(categories, cat)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	linkRows, err := manager.db.Query(`
+		SELECT links.id, links.category_id, links.name, links.description, links.icon, links.url, links.deleted_at,
+			categories.name, categories.deleted_at IS NOT NULL
+		FROM links
+		JOIN categories ON categories.id = links.category_id
+		WHERE links.deleted_at IS NOT NULL
+		ORDER BY links.deleted_at DESC, links.id DESC
+	`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer linkRows.Close()
+
+	var links []TrashedLink
+	for linkRows.Next() {
+		var link TrashedLink
+		if err := linkRows.Scan(&link.ID, &link.CategoryID, &link.Name, &link.Description, &link.Icon, &link.URL,
+			&link.DeletedAt, &link.CategoryName, &link.CategoryInTrash); err != nil {
+			return nil, nil, err
+		}
+		link.DeletedAt = formatTime(link.DeletedAt)
+		links = append(links, link)
+	}
+
+	return categories, links, linkRows.Err()
+}
+
+func formatTime(deletedAt string) string {
+	parsed, err := time.Parse(time.RFC3339, deletedAt)
+	if err != nil {
+		return deletedAt
+	}
+
+	return parsed.Local().Format("Jan 2, 2006 15:04")
+}
+
+// PurgeTrash permanently deletes everything that was put in the trash before the given time
+func (manager *CategoryManager) PurgeTrash(actor audit.Actor, before time.Time) (int, error) {
+	cutoff := before.UTC().Format(time.RFC3339)
+	purged := 0
+
+	// links go first, purging a category takes its links with it
+	linkIDs, err := queryIDs(manager.db, `SELECT id FROM links WHERE deleted_at IS NOT NULL AND deleted_at <= ?`, cutoff)
+	if err != nil {
+		return purged, err
+	}
+
+	for _, id := range linkIDs {
+		if err := manager.PurgeLink(actor, id); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+
+	categoryIDs, err := queryIDs(manager.db, `SELECT id FROM categories WHERE deleted_at IS NOT NULL AND deleted_at <= ?`, cutoff)
+	if err != nil {
+		return purged, err
+	}
+
+	for _, id := range categoryIDs {
+		if err := manager.PurgeCategory(actor, id); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+func queryIDs(q querier, query string, args ...any) ([]int64, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// trashWorker purges anything that has been in the trash for longer than retention, once an hour
+func (manager *CategoryManager) trashWorker(retention time.Duration) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		// purges made by passport itself are logged without a session or ip
+		purged, err := manager.PurgeTrash(audit.Actor{}, time.Now().Add(-retention))
+		if err != nil {
+			slog.Error("Failed to purge trash", "error", err)
+		} else if purged > 0 {
+			slog.Info("Purged expired items from the trash", "count", purged)
+		}
+
+		<-ticker.C
+	}
+}
+
+// healthWorker checks every link straight away, and then once every interval
+func (manager *CategoryManager) healthWorker(checker *services.HealthChecker, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := manager.CheckHealth(checker); err != nil {
+			slog.Error("Failed to check links", "error", err)
+		}
+
+		<-ticker.C
+	}
+}
+
+// CheckHealth checks every link that isnt in the trash and stores the results
+func (manager *CategoryManager) CheckHealth(checker *services.HealthChecker) error {
+	rows, err := manager.db.Query(`
+		SELECT links.id, links.url
+		FROM links
+		JOIN categories ON categories.id = links.category_id
+		WHERE links.deleted_at IS NULL AND categories.deleted_at IS NULL
+	`)
+	if err != nil {
+		return err
+	}
+
+	var targets []services.HealthTarget
+	for rows.Next() {
+		var target services.HealthTarget
+		if err := rows.Scan(&target.ID, &target.URL); err != nil {
+			rows.Close()
+			return err
+		}
+		targets = append(targets, target)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// checking can take a while, so dont hold a transaction open while it runs
+	results := checker.CheckAll(targets)
+
+	tx, err := manager.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// the select skips links that were purged while they were being checked
+	stmt, err := tx.Prepare(`
+		INSERT INTO link_health (link_id, status, latency_ms, error, checked_at)
+		SELECT id, ?, ?, ?, ? FROM links WHERE id = ?
+		ON CONFLICT (link_id) DO UPDATE SET
+			status = excluded.status,
+			latency_ms = excluded.latency_ms,
+			error = excluded.error,
+			checked_at = excluded.checked_at
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	down := 0
+	for _, result := range results {
+		_, err := stmt.Exec(result.Status, result.Latency.Milliseconds(), result.Error,
+			result.CheckedAt.UTC().Format(time.RFC3339), result.ID)
+		if err != nil {
+			return err
+		}
+
+		health := LinkHealth{Status: result.Status, Error: result.Error}
+		if health.State() == "down" {
+			down++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	slog.Info("Checked links", "count", len(results), "down", down)
+
+	return nil
+}
+
+// MoveLinks moves the given links into another category, appending them to the end of it in
+// the order given. The links keep their IDs and icons.
+func (manager *CategoryManager) MoveLinks(actor audit.Actor, ids []int64, categoryID int64) error {
+	tx, err := manager.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before := make([]*Link, len(ids))
+	for i, id := range ids {
+		if before[i], err = lookupLink(tx, id); err != nil {
+			if errors.Is(err, ErrLinkNotFound) {
+				return fmt.Errorf("%w: %d", ErrLinkNotFound, id)
+			}
+			return err
+		}
+	}
+
+	if err := moveLinks(tx, ids, categoryID); err != nil {
+		return err
+	}
+
+	for i, id := range ids {
+		after, err := lookupLink(tx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := audit.Record(tx, actor, audit.ActionMove, audit.EntityLink, id, before[i], after); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func moveLinks(tx *sql.Tx, ids []int64, categoryID int64) error {
+	var exists bool
+	if err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM categories WHERE id = ? AND deleted_at IS NULL)`, categoryID).Scan(&exists); err != nil {
+		return err
+	}
+
+	if !exists {
+		return ErrCategoryNotFound
+	}
+
+	for _, id := range ids {
+		result, err := tx.Exec(`
+			UPDATE links
+			SET category_id = ?, position = (SELECT COALESCE(MAX(position), -1) + 1 FROM links WHERE category_id = ?)
+			WHERE id = ? AND deleted_at IS NULL
+		`, categoryID, categoryID, id)
+		if err != nil {
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if affected == 0 {
+			return fmt.Errorf("%w: %d", ErrLinkNotFound, id)
+		}
+	}
+
+	return nil
+}
+
+var ErrInvalidOrder = errors.New("order must contain every item exactly once")
+
+// ReorderCategories sets the position of every category on the board to its index in ids
+func (manager *CategoryManager) ReorderCategories(actor audit.Actor, boardID int64, ids []int64) error {
+	tx, err := manager.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkOrder(tx, ids, `SELECT id FROM categories WHERE board_id = ? AND deleted_at IS NULL`, boardID); err != nil {
+		return err
+	}
+
+	before, err := queryIDs(tx, `SELECT id FROM categories WHERE board_id = ? AND deleted_at IS NULL ORDER BY position ASC, id ASC`, boardID)
+	if err != nil {
+		return err
+	}
+
+	if err := writePositions(tx, "categories", ids); err != nil {
+		return err
+	}
+
+	// the order is logged against the board the categories are on
+	if err := audit.Record(tx, actor, audit.ActionReorder, audit.EntityBoard, boardID, before, ids); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReorderLinks sets the position of every link in the category to its index in ids
+func (manager *CategoryManager) ReorderLinks(actor audit.Actor, categoryID int64, ids []int64) error {
+	tx, err := manager.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkOrder(tx, ids, `SELECT id FROM links WHERE category_id = ? AND deleted_at IS NULL`, categoryID); err != nil {
+		return err
+	}
+
+	before, err := queryIDs(tx, `SELECT id FROM links WHERE category_id = ? AND deleted_at IS NULL ORDER BY position ASC, id ASC`, categoryID)
+	if err != nil {
+		return err
+	}
+
+	if err := writePositions(tx, "links", ids); err != nil {
+		return err
+	}
+
+	// the order is logged against the category the links are in
+	if err := audit.Record(tx, actor, audit.ActionReorder, audit.EntityCategory, categoryID, before, ids); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReorderPinned sets the position of every pinned link on the board to its index in ids
+func (manager *CategoryManager) ReorderPinned(actor audit.Actor, boardID int64, ids []int64) error {
+	tx, err := manager.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	pinnedQuery := `
+		SELECT links.id
+		FROM links
+		JOIN categories ON categories.id = links.category_id
+		WHERE categories.board_id = ? AND links.pinned = 1
+			AND links.deleted_at IS NULL AND categories.deleted_at IS NULL`
+
+	if err := checkOrder(tx, ids, pinnedQuery, boardID); err != nil {
+		return err
+	}
+
+	before, err := queryIDs(tx, pinnedQuery+` ORDER BY links.pinned_position ASC, links.id ASC`, boardID)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`UPDATE links SET pinned_position = ? WHERE id = ?`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for position, id := range ids {
+		if _, err := stmt.Exec(position, id); err != nil {
+			return err
+		}
+	}
+
+	// logged against the board, keyed so it cant be mistaken for the order of its categories
+	if err := audit.Record(tx, actor, audit.ActionReorder, audit.EntityBoard, boardID,
+		map[string][]int64{"pinned": before}, map[string][]int64{"pinned": ids}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// checkOrder makes sure ids is a permutation of the ids returned by query, so a reorder cant
+// drop, duplicate or steal items
+func checkOrder(tx *sql.Tx, ids []int64, query string, args ...any) error {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	existing := make(map[int64]bool)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		existing[id] = false
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(ids) != len(existing) {
+		return ErrInvalidOrder
+	}
+
+	for _, id := range ids {
+		seen, ok := existing[id]
+		if !ok || seen {
+			return ErrInvalidOrder
+		}
+		existing[id] = true
+	}
+
+	return nil
+}
+
+func writePositions(tx *sql.Tx, table string, ids []int64) error {
+	stmt, err := tx.Prepare(fmt.Sprintf("UPDATE %s SET position = ? WHERE id = ?", table))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for position, id := range ids {
+		if _, err := stmt.Exec(position, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var WeatherIcons = map[string]string{
+	"clear-day":           `<svg aria-label="Clear day" xmlns="http://www.w3.org/2000/svg" width="32" height="32" viewBox="0 0 32 32"><path fill="currentColor" d="M16 12.005a4 4 0 1 1-4 4a4.005 4.005 0 0 1 4-4m0-2a6 6 0 1 0 6 6a6 6 0 0 0-6-6M5.394 6.813L6.81 5.399l3.505 3.506L8.9 10.319zM2 15.005h5v2H2zm3.394 10.193L8.9 21.692l1.414 1.414l-3.505 3.506zM15 25.005h2v5h-2zm6.687-1.9l1.414-1.414l3.506 3.506l-1.414 1.414zm3.313-8.1h5v2h-5zm-3.313-6.101l3.506-3.506l1.414 1.414l-3.506 3.506zM15 2.005h2v5h-2z"/></svg>`,
+	"clear-night":         `<svg aria-label="Clear night" xmlns="http://www.w3.org/2000/svg" width="32" height="32" viewBox="0 0 32 32"><path fill="currentColor" d="M13.503 5.414a15.076 15.076 0 0 0 11.593 18.194a11.1 11.1 0 0 1-7.975 3.39c-.138 0-.278.005-.418 0a11.094 11.094 0 0 1-3.2-21.584M14.98 3a1 1 0 0 0-.175.016a13.096 13.096 0 0 0 1.825 25.981c.164.006.328 0 .49 0a13.07 13.07 0 0 0 10.703-5.555a1.01 1.01 0 0 0-.783-1.565A13.08 13.08 0 0 1 15.89 4.38A1.015 1.015 0 0 0 14.98 3"/></svg>`,
+	"partly-cloudy-day":   `<svg aria-label="Partly cloudy day" xmlns="http://www.w3.org/2000/svg" width="32" height="32" viewBox="0 0 32 32"><path fill="currentColor" d="M27 15h4v2h-4zm-4-7.413l3-3L27.415 6l-3 3zM15 1h2v4h-2zM4.586 26l3-3l1.415 1.415l-3 3zM4.585 6L6 4.587l3 3l-1.414 1.415z"/><path fill="currentColor" d="M1 15h4v2H1zm25.794 5.342a6.96 6.96 0 0 0-1.868-3.267A9 9 0 0 0 25 16a9 9 0 1 0-14.585 7.033A4.977 4.977 0 0 0 15 30h10a4.995 4.995 0 0 0 1.794-9.658M9 16a6.996 6.996 0 0 1 13.985-.297A6.9 6.9 0 0 0 20 15a7.04 7.04 0 0 0-6.794 5.342a5 5 0 0 0-1.644 1.048A6.97 6.97 0 0 1 9 16m16 12H15a2.995 2.995 0 0 1-.696-5.908l.658-.157l.099-.67a4.992 4.992 0 0 1 9.878 0l.099.67l.658.156A2.995 2.995 0 0 1 25 28"/></svg>`,
+	"partly-cloudy-night": `<svg aria-label="Partly cloudy night" xmlns="http://www.w3.org/2000/svg" width="32" height="32" viewBox="0 0 32 32"><path fill="currentColor" d="M30 19a4.97 4.97 0 0 0-3.206-4.658A6.971 6.971 0 0 0 13.758 12.9a13.14 13.14 0 0 1 .131-8.52A1.015 1.015 0 0 0 12.98 3a1 1 0 0 0-.175.016a13.096 13.096 0 0 0 1.825 25.981c.164.006.328 0 .49 0a13.04 13.04 0 0 0 10.29-5.038A4.99 4.99 0 0 0 30 19m-15.297 7.998a11.095 11.095 0 0 1-3.2-21.584a15.2 15.2 0 0 0 .844 9.367A4.988 4.988 0 0 0 15 24h7.677a11.1 11.1 0 0 1-7.556 2.998c-.138 0-.278.004-.418 0M25 22H15a2.995 2.995 0 0 1-.696-5.908l.658-.157l.099-.67a4.992 4.992 0 0 1 9.878 0l.099.67l.658.157A2.995 2.995 0 0 1 25 22"/></svg>`,
+	"mostly-cloudy-day":   `<svg aria-label="Mostly cloudy day" xmlns="http://www.w3.org/2000/svg" width="32" height="32" viewBox="0 0 32 32"><path fill="currentColor" d="M21.743 18.692a6 6 0 0 0 1.057-1.086a5.998 5.998 0 1 0-10.733-4.445A7.56 7.56 0 0 0 6.35 18.25A5.993 5.993 0 0 0 8 30.005h11a5.985 5.985 0 0 0 2.743-11.313M18 10.005a4.004 4.004 0 0 1 4 4a3.96 3.96 0 0 1-.8 2.4a4 4 0 0 1-.94.891a7.54 7.54 0 0 0-6.134-4.24A4 4 0 0 1 18 10.006m1 18H8a3.993 3.993 0 0 1-.673-7.93l.663-.112l.146-.656a5.496 5.496 0 0 1 10.729 0l.146.656l.662.112a3.993 3.993 0 0 1-.673 7.93m7-15.001h4v2h-4zM22.95 7.64l2.828-2.827l1.415 1.414l-2.829 2.828zM17 2.005h2v4h-2zM8.808 6.227l1.414-1.414l2.829 2.828l-1.415 1.414z"/></svg>`,
+	"mostly-cloudy-night": `<svg aria-label="Mostly cloudy night" xmlns="http://www.w3.org/2000/svg" width="32" height="32" viewBox="0 0 32 32"><path fill="currentColor" d="M29.844 15.035a1.52 1.52 0 0 0-1.23-.866a5.36 5.36 0 0 1-3.41-1.716a6.47 6.47 0 0 1-1.286-6.392a1.6 1.6 0 0 0-.299-1.546a1.45 1.45 0 0 0-1.36-.493l-.019.003a7.93 7.93 0 0 0-6.22 7.431A7.4 7.4 0 0 0 13.5 11a7.55 7.55 0 0 0-7.15 5.244A5.993 5.993 0 0 0 8 28h11a5.977 5.977 0 0 0 5.615-8.088a7.5 7.5 0 0 0 5.132-3.357a1.54 1.54 0 0 0 .097-1.52M19 26H8a3.993 3.993 0 0 1-.673-7.93l.663-.112l.145-.656a5.496 5.496 0 0 1 10.73 0l.145.656l.663.113A3.993 3.993 0 0 1 19 26m4.465-8.001h-.021a5.96 5.96 0 0 0-2.795-1.755a7.5 7.5 0 0 0-2.6-3.677c-.01-.101-.036-.197-.041-.3a6.08 6.08 0 0 1 3.79-6.05a8.46 8.46 0 0 0 1.94 7.596a7.4 7.4 0 0 0 3.902 2.228a5.43 5.43 0 0 1-4.175 1.958"/></svg>`,
+	"light-rain":          `<svg aria-label="Light rain" xmlns="http://www.w3.org/2000/svg" width="32" height="32" viewBox="0 0 32 32"><path fill="currentColor" d="M11 30a1 1 0 0 1-.894-1.447l2-4a1 1 0 1 1 1.788.894l-2 4A1 1 0 0 1 11 30"/><path fill="currentColor" d="M24.8 9.136a8.994 8.994 0 0 0-17.6 0A6.497 6.497 0 0 0 8.5 22h10.881l-1.276 2.553a1 1 0 0 0 1.789.894L21.618 22H23.5a6.497 6.497 0 0 0 1.3-12.864M23.5 20h-15a4.498 4.498 0 0 1-.356-8.981l.816-.064l.099-.812a6.994 6.994 0 0 1 13.883 0l.099.812l.815.064A4.498 4.498 0 0 1 23.5 20"/></svg>`,
+	"rain":                `<svg aria-label="Rain" xmlns="http://www.w3.org/2000/svg" width="32" height="32" viewBox="0 0 32 32"><path fill="currentColor" d="M23.5 22h-15A6.5 6.5 0 0 1 7.2 9.14a9 9 0 0 1 17.6 0A6.5 6.5 0 0 1 23.5 22M16 4a7 7 0 0 0-6.94 6.14L9 11h-.86a4.5 4.5 0 0 0 .36 9h15a4.5 4.5 0 0 0 .36-9H23l-.1-.82A7 7 0 0 0 16 4m-2 26a.93.93 0 0 1-.45-.11a1 1 0 0 1-.44-1.34l2-4a1 1 0 1 1 1.78.9l-2 4A1 1 0 0 1 14 30m6 0a.93.93 0 0 1-.45-.11a1 1 0 0 1-.44-1.34l2-4a1 1 0 1 1 1.78.9l-2 4A1 1 0 0 1 20 30M8 30a.93.93 0 0 1-.45-.11a1 1 0 0 1-.44-1.34l2-4a1 1 0 1 1 1.78.9l-2 4A1 1 0 0 1 8 30"/></svg>`,
+	"thunder":             `<svg aria-label="Thunder" xmlns="http://www.w3.org/2000/svg" width="32" height="32" viewBox="0 0 32 32"><path fill="currentColor" d="M21 30a1 1 0 0 1-.894-1.447l2-4a1 1 0 1 1 1.788.894l-2 4A1 1 0 0 1 21 30M9 32a1 1 0 0 1-.894-1.447l2-4a1 1 0 1 1 1.788.894l-2 4A1 1 0 0 1 9 32m6.901-1.504l-1.736-.992L17.31 24h-6l4.855-8.496l1.736.992L14.756 22h6.001z"/><path fill="currentColor" d="M24.8 9.136a8.994 8.994 0 0 0-17.6 0a6.493 6.493 0 0 0 .23 12.768l-1.324 2.649a1 1 0 1 0 1.789.894l2-4a1 1 0 0 0-.447-1.341A1 1 0 0 0 9 20.01V20h-.5a4.498 4.498 0 0 1-.356-8.981l.816-.064l.099-.812a6.994 6.994 0 0 1 13.883 0l.099.812l.815.064A4.498 4.498 0 0 1 23.5 20H23v2h.5a6.497 6.497 0 0 0 1.3-12.864"/></svg>`,
+	"snow":                `<svg aria-label="Snow" xmlns="http://www.w3.org/2000/svg" width="32" height="32" viewBox="0 0 32 32"><path fill="currentColor" d="M23.5 22h-15A6.5 6.5 0 0 1 7.2 9.14a9 9 0 0 1 17.6 0A6.5 6.5 0 0 1 23.5 22M16 4a7 7 0 0 0-6.94 6.14L9 11h-.86a4.5 4.5 0 0 0 .36 9h15a4.5 4.5 0 0 0 .36-9H23l-.1-.82A7 7 0 0 0 16 4m-4 21.05L10.95 24L9.5 25.45L8.05 24L7 25.05l1.45 1.45L7 27.95L8.05 29l1.45-1.45L10.95 29L12 27.95l-1.45-1.45zm14 0L24.95 24l-1.45 1.45L22.05 24L21 25.05l1.45 1.45L21 27.95L22.05 29l1.45-1.45L24.95 29L26 27.95l-1.45-1.45zm-7 2L17.95 26l-1.45 1.45L15.05 26L14 27.05l1.45 1.45L14 29.95L15.05 31l1.45-1.45L17.95 31L19 29.95l-1.45-1.45z"/></svg>`,
+	"mist":                `<svg aria-label="Mist" xmlns="http://www.w3.org/2000/svg" width="32" height="32" viewBox="0 0 32 32"><path fill="currentColor" d="M24.8 11.138a8.994 8.994 0 0 0-17.6 0A6.53 6.53 0 0 0 2 17.5V19a1 1 0 0 0 1 1h12a1 1 0 0 0 0-2H4v-.497a4.52 4.52 0 0 1 4.144-4.482l.816-.064l.099-.812a6.994 6.994 0 0 1 13.883 0l.099.813l.815.063A4.496 4.496 0 0 1 23.5 22H7a1 1 0 0 0 0 2h16.5a6.496 6.496 0 0 0 1.3-12.862"/><rect width="18" height="2" x="2" y="26" fill="currentColor" rx="1"/></svg>`,
+}
+
+func getWeatherIcon(iconId string) string {
+	switch iconId {
+	case "01d":
+		return WeatherIcons["clear-day"]
+	case "01n":
+		return WeatherIcons["clear-night"]
+	case "02d", "03d":
+		return WeatherIcons["partly-cloudy-day"]
+	case "02n", "03n":
+		return WeatherIcons["partly-cloudy-night"]
+	case "04d":
+		return WeatherIcons["mostly-cloudy-day"]
+	case "04n":
+		return WeatherIcons["mostly-cloudy-night"]
+	case "09d", "09n":
+		return WeatherIcons["light-rain"]
+	case "10d", "10n":
+		return WeatherIcons["rain"]
+	case "11d", "11n":
+		return WeatherIcons["thunder"]
+	case "13d", "13n":
+		return WeatherIcons["snow"]
+	case "50d", "50n":
+		return WeatherIcons["mist"]
+	default:
+		return ""
+	}
+}
+
+func init() {
+	godotenv.Load()
+}
+
+func main() {
+	if err := os.MkdirAll("public/uploads", 0755); err != nil {
+		log.Fatal(err)
+	}
+
+	dbPath, err := filepath.Abs("passport.db")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if len(os.Args) > 1 {
+		if err := runCommand(dbPath, os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	app, err := NewApp(dbPath, dbOptions)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer app.Close()
+
+	go func() {
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
+		<-c
+		app.Close()
+		os.Exit(0)
+	}()
+
+	templatesDir, err := fs.Sub(embeddedAssets, "templates")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assetsDir, err := fs.Sub(embeddedAssets, "assets")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	engine := handlebars.NewFileSystem(http.FS(templatesDir), ".hbs")
+
+	engine.AddFunc("embedFile", func(fileToEmbed string) string {
+		content, err := fs.ReadFile(embeddedAssets, fileToEmbed)
+		if err != nil {
+			return ""
+		}
+
+		fileExtension := filepath.Ext(fileToEmbed)
+
+		switch fileExtension {
+		case ".js":
+			return fmt.Sprintf("<script>%s</script>", content)
+		case ".css":
+			return fmt.Sprintf("<style>%s</style>", content)
+		default:
+			return string(content)
+		}
+	})
+
+	engine.AddFunc("join", func(items []string, separator string) string {
+		return strings.Join(items, separator)
+	})
+
+	engine.AddFunc("eq", func(a, b any) bool {
+		return a == b
+	})
+
+	engine.AddFunc("queryEscape", url.QueryEscape)
+
+	engine.AddFunc("devContent", func() string {
+		if app.Config.DevMode {
+			return devContent
+		}
+		return ""
+	})
+
+	router := fiber.New(fiber.Config{
+		Views: engine,
+		// bookmark exports carry every favicon inline, so they get much larger than an icon upload
+		BodyLimit: 32 * 1024 * 1024,
+	})
+
+	router.Use(helmet.New(helmet.ConfigDefault))
+
+	// redirect /favicon.ico to /assets/favicon.ico
+	router.Get("/favicon.ico", func(c fiber.Ctx) error {
+		return c.Redirect().To("/assets/favicon.ico")
+	})
+
+	router.Use(compress.New(compress.Config{
+		Level: compress.LevelBestSpeed,
+	}))
+
+	router.Use(gonify.New(gonify.Config{
+		// browsers only import bookmark files that look exactly like the ones they export
+		Next: func(c fiber.Ctx) bool {
+			return c.Path() == "/api/export/bookmarks.html"
+		},
+		MinifySVG:  !app.DevMode,
+		MinifyCSS:  !app.DevMode,
+		MinifyJS:   !app.DevMode,
+		MinifyHTML: !app.DevMode,
+	}))
+
+	// icons include SVGs uploaded by admins and fetched from other sites, which would run their scripts on our origin if
+	// opened directly. Without scripts or outside requests they can only draw themselves
+	router.Use("/uploads", func(c fiber.Ctx) error {
+		c.Set("Content-Security-Policy", "default-src 'none'; img-src data:; style-src 'unsafe-inline'")
+		c.Set("X-Content-Type-Options", "nosniff")
+		return c.Next()
+	})
+
+	router.Use("/", static.New("./public", static.Config{
+		Browse: false,
+		MaxAge: 31536000,
+	}))
+
+	router.Use("/assets", static.New("", static.Config{
+		FS:     assetsDir,
+		Browse: false,
+		MaxAge: 31536000,
+	}))
+
+	// the boards need to know if the visitor is logged in to show private links
+	router.Use(middleware.AdminMiddleware(app.db))
+
+	renderBoard := func(c fiber.Ctx, board *Board) error {
+		if board == nil {
+			return fiber.NewError(fiber.StatusNotFound, "Board not found")
+		}
+
+		c.Response().Header.Set("Link", "</assets/fonts/InstrumentSans-VariableFont_wdth,wght.woff2>; rel=preload; as=font; type=font/woff2; crossorigin")
+
+		// private categories and links are only shown to admins
+		isAdmin := c.Locals("IsAdmin") != nil
+
+		categories := app.CategoryManager.GetCategories(board.ID)
+		if !isAdmin {
+			categories = FilterPublic(categories)
+		}
+
+		tag := strings.ToLower(strings.TrimSpace(c.Query("tag")))
+		if tag != "" {
+			categories = FilterByTag(categories, tag)
+		}
+
+		boards := app.CategoryManager.GetBoards()
+		providers := app.CategoryManager.GetSearchProviders()
+
+		renderData := fiber.Map{
+			"Board":         board,
+			"BoardPath":     board.Path(),
+			"Boards":        boards,
+			"ShowBoards":    len(boards) > 1,
+			"Categories":    categories,
+			"Tags":          app.CategoryManager.GetBoardTags(board.ID, isAdmin),
+			"ActiveTag":     tag,
+			"TrackClicks":   app.Config.TrackClicks,
+			"Pinned":        app.CategoryManager.GetPinnedLinks(board.ID, isAdmin),
+			"Providers":     providers,
+			"ShowProviders": len(providers) > 1,
+		}
+
+		if app.Config.WeatherAPIKey != "" {
+			weather := app.WeatherManager.GetWeather()
+
+			renderData["WeatherData"] = fiber.Map{
+				"Temp": weather.Temperature,
+				"Desc": weather.WeatherText,
+				"Icon": getWeatherIcon(weather.Icon),
+			}
+		}
+
+		if app.Config.UptimeAPIKey != "" {
+			renderData["UptimeData"] = app.UptimeManager.GetUptime()
+		}
+
+		return c.Render("views/index", renderData)
+	}
+
+	router.Get("/", func(c fiber.Ctx) error {
+		return renderBoard(c, app.CategoryManager.GetDefaultBoard())
+	})
+
+	router.Get("/b/:slug", func(c fiber.Ctx) error {
+		return renderBoard(c, app.CategoryManager.GetBoardBySlug(c.Params("slug")))
+	})
+
+	router.Get("/admin/login", func(c fiber.Ctx) error {
+		if c.Locals("IsAdmin") != nil {
+			return c.Redirect().To("/admin")
+		}
+
+		return c.Render("views/admin/login", fiber.Map{})
+	})
+
+	router.Post("/admin/login", func(c fiber.Ctx) error {
+		if c.Locals("IsAdmin") != nil {
+			return c.Redirect().To("/admin")
+		}
+
+		var loginData struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+		}
+		if err := c.Bind().JSON(&loginData); err != nil {
+			return err
+		}
+
+		// possible vulnerable to timing attacks
+		if loginData.Username != app.Config.Admin.Username || loginData.Password != app.Config.Admin.Password {
+			return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"message": "Invalid username or password"})
+		}
+
+		// Create new session
+		sessionID := uuid.NewString()
+		expiresAt := time.Now().Add(time.Hour * 24 * 7)
+		_, err := app.db.Exec(`
+			INSERT INTO sessions (session_id, expires_at) 
+			VALUES (?, ?)
+		`, sessionID, expiresAt)
+		if err != nil {
+			return err
+		}
+
+		// Set cookie
+		c.Cookie(&fiber.Cookie{
+			Name:    "SessionToken",
+			Value:   sessionID,
+			Expires: expiresAt,
+		})
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Logged in successfully"})
+	})
+
+	// redirects to the link with an alias, passing on whatever followed the alias, or shows the aliases that come close
+	// if there is none
+	followAlias := func(c fiber.Ctx, alias string, rest string) error {
+		isAdmin := c.Locals("IsAdmin") != nil
+		alias = strings.ToLower(alias)
+
+		link := app.CategoryManager.FindAlias(alias, isAdmin)
+		if link == nil {
+			return c.Status(fiber.StatusNotFound).Render("views/notfound", fiber.Map{
+				"Alias":       alias,
+				"Suggestions": app.CategoryManager.SuggestAliases(alias, isAdmin),
+				"Rest":        rest,
+			})
+		}
+
+		if app.Config.TrackClicks {
+			if err := app.CategoryManager.RecordClick(link.ID); err != nil {
+				slog.Error("Failed to record click", "link", link.ID, "error", err)
+			}
+		}
+
+		if unescaped, err := url.PathUnescape(rest); err == nil {
+			rest = unescaped
+		}
+
+		c.Set("Referrer-Policy", "no-referrer")
+		c.Set("Cache-Control", "no-store")
+
+		return c.Redirect().Status(fiber.StatusFound).To(aliasTarget(link.URL, rest, string(c.Request().URI().QueryString())))
+	}
+
+	// redirects to a link, counting the click if click tracking is on. The redirect works either way so
+	// bookmarked /go links keep working after tracking is turned off.
+	router.Get("/go/:linkID", func(c fiber.Ctx) error {
+		id, err := strconv.ParseInt(c.Params("linkID"), 10, 64)
+		if err != nil {
+			return followAlias(c, c.Params("linkID"), "")
+		}
+
+		link := app.CategoryManager.GetLink(id)
+		if link == nil {
+			return fiber.NewError(fiber.StatusNotFound, "Link not found")
+		}
+
+		// private links look like they dont exist to anyone who isnt logged in
+		if c.Locals("IsAdmin") == nil {
+			category := app.CategoryManager.GetCategory(link.CategoryID)
+			if category == nil || category.Visibility == VisibilityPrivate || link.Visibility == VisibilityPrivate {
+				return fiber.NewError(fiber.StatusNotFound, "Link not found")
+			}
+		}
+
+		if app.Config.TrackClicks {
+			if err := app.CategoryManager.RecordClick(link.ID); err != nil {
+				slog.Error("Failed to record click", "link", link.ID, "error", err)
+			}
+		}
+
+		// dont leak the dashboard's address to the site being opened
+		c.Set("Referrer-Policy", "no-referrer")
+		c.Set("Cache-Control", "no-store")
+
+		return c.Redirect().Status(fiber.StatusFound).To(link.URL)
+	})
+
+	// /go/gh/juls0730/passport goes to the rest of the path on the site of the link with the alias gh
+	router.Get("/go/:alias/*", func(c fiber.Ctx) error {
+		return followAlias(c, c.Params("alias"), c.Params("*"))
+	})
+
+	// the search bar goes through here so bangs and keywords work, anything else ends up at the search provider
+	router.Get("/search", func(c fiber.Ctx) error {
+		target := app.searchTarget(c.Query("q"), c.Query("provider"), c.Locals("IsAdmin") != nil)
+
+		// searches are as private as the links they open
+		c.Set("Referrer-Policy", "no-referrer")
+		c.Set("Cache-Control", "no-store")
+
+		return c.Redirect().Status(fiber.StatusFound).To(target)
+	})
+
+	renderAdmin := func(c fiber.Ctx, board *Board) error {
+		if c.Locals("IsAdmin") == nil {
+			return c.Redirect().To("/admin/login")
+		}
+
+		if board == nil {
+			return fiber.NewError(fiber.StatusNotFound, "Board not found")
+		}
+
+		return c.Render("views/admin/index", fiber.Map{
+			"Board":         board,
+			"BoardPath":     board.Path(),
+			"Boards":        app.CategoryManager.GetBoards(),
+			"Categories":    app.CategoryManager.GetCategories(board.ID),
+			"Pinned":        app.CategoryManager.GetPinnedLinks(board.ID, true),
+			"IsAdmin":       true,
+			"ReadOnly":      app.DashboardFile != "",
+			"DashboardFile": filepath.Base(app.DashboardFile),
+		})
+	}
+
+	router.Get("/admin", func(c fiber.Ctx) error {
+		return renderAdmin(c, app.CategoryManager.GetDefaultBoard())
+	})
+
+	router.Get("/admin/b/:slug", func(c fiber.Ctx) error {
+		return renderAdmin(c, app.CategoryManager.GetBoardBySlug(c.Params("slug")))
+	})
+
+	router.Get("/admin/audit", func(c fiber.Ctx) error {
+		if c.Locals("IsAdmin") == nil {
+			return c.Redirect().To("/admin/login")
+		}
+
+		filter, page, err := parseAuditQuery(c)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+
+		entries, total, err := audit.List(app.db, filter)
+		if err != nil {
+			return err
+		}
+
+		// keep the filters when moving between pages
+		pageURL := func(page int) string {
+			query := url.Values{}
+			for key, value := range c.Queries() {
+				if value != "" {
+					query.Set(key, value)
+				}
+			}
+			query.Set("page", strconv.Itoa(page))
+
+			return "/admin/audit?" + query.Encode()
+		}
+
+		renderData := fiber.Map{
+			"Entries": entries,
+			"Total":   total,
+			"Page":    page,
+			"Filter": fiber.Map{
+				"Action":     filter.Action,
+				"EntityType": filter.EntityType,
+				"EntityID":   c.Query("entity_id"),
+				"SessionID":  c.Query("session_id"),
+				"IP":         filter.IP,
+				"Since":      c.Query("since"),
+				"Until":      c.Query("until"),
+			},
+			"Actions":     []string{audit.ActionCreate, audit.ActionUpdate, audit.ActionDelete, audit.ActionRestore, audit.ActionPurge, audit.ActionReorder, audit.ActionMove},
+			"EntityTypes": []string{audit.EntityBoard, audit.EntityCategory, audit.EntityLink, audit.EntityBang, audit.EntitySearchProvider},
+		}
+
+		if page > 1 {
+			renderData["PrevPage"] = pageURL(page - 1)
+		}
+
+		if filter.Offset+len(entries) < total {
+			renderData["NextPage"] = pageURL(page + 1)
+		}
+
+		return c.Render("views/admin/audit", renderData)
+	})
+
+	router.Get("/admin/trash", func(c fiber.Ctx) error {
+		if c.Locals("IsAdmin") == nil {
+			return c.Redirect().To("/admin/login")
+		}
+
+		categories, links, err := app.CategoryManager.GetTrash()
+		if err != nil {
+			return err
+		}
+
+		return c.Render("views/admin/trash", fiber.Map{
+			"Categories":    categories,
+			"Links":         links,
+			"HasTrash":      len(categories) > 0 || len(links) > 0,
+			"RetentionDays": app.Config.TrashRetentionDays,
+		})
+	})
+
+	router.Get("/admin/search", func(c fiber.Ctx) error {
+		if c.Locals("IsAdmin") == nil {
+			return c.Redirect().To("/admin/login")
+		}
+
+		var bangsReadOnly, providersReadOnly bool
+		if app.DashboardFile != "" {
+			bangsReadOnly, providersReadOnly = app.dashboardSections()
+		}
+
+		return c.Render("views/admin/search", fiber.Map{
+			"Bangs":             app.CategoryManager.GetBangs(),
+			"Providers":         app.CategoryManager.GetSearchProviders(),
+			"Keywords":          app.CategoryManager.GetKeywordLinks(),
+			"BangsReadOnly":     bangsReadOnly,
+			"ProvidersReadOnly": providersReadOnly,
+			"ReadOnly":          bangsReadOnly && providersReadOnly,
+			"DashboardFile":     filepath.Base(app.DashboardFile),
+		})
+	})
+
+	router.Get("/admin/import", func(c fiber.Ctx) error {
+		if c.Locals("IsAdmin") == nil {
+			return c.Redirect().To("/admin/login")
+		}
+
+		board := app.CategoryManager.GetDefaultBoard()
+		if id, err := strconv.ParseInt(c.Query("board"), 10, 64); err == nil {
+			if selected := app.CategoryManager.GetBoard(id); selected != nil {
+				board = selected
+			}
+		}
+
+		return c.Render("views/admin/import", fiber.Map{
+			"Boards":        app.CategoryManager.GetBoards(),
+			"Board":         board,
+			"ReadOnly":      app.DashboardFile != "",
+			"DashboardFile": filepath.Base(app.DashboardFile),
+		})
+	})
+
+	// the launcher on the boards searches links as the visitor types, so unlike the rest of the API this is public
+	router.Get("/api/search", func(c fiber.Ctx) error {
+		limit := 10
+		if raw := c.Query("limit"); raw != "" {
+			value, err := strconv.Atoi(raw)
+			if err != nil || value < 1 || value > 50 {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Limit must be between 1 and 50",
+				})
+			}
+			limit = value
+		}
+
+		results, err := app.searchLinks(c.Query("q"), c.Locals("IsAdmin") != nil, limit)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"message": fmt.Sprintf("Failed to search links: %v", err),
+			})
+		}
+
+		// searches can include private links, so they shouldnt be kept around
+		c.Set("Cache-Control", "no-store")
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"results": results,
+		})
+	})
+
+	// visitors can ask for suggestions too, but each of their requests can end up at the search provider, so they only
+	// get so many
+	suggestLimiter := limiter.New(limiter.Config{
+		Next: func(c fiber.Ctx) bool {
+			return c.Locals("IsAdmin") != nil
+		},
+		Max:        60,
+		Expiration: time.Minute,
+		LimitReached: func(c fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"message": "Too many requests, try again in a minute"})
+		},
+	})
+
+	// suggestions for the search bar, the links that match what is being typed along with what the search provider
+	// suggests. The provider is asked with a short timeout, if it is slow or down only the links are suggested
+	router.Get("/api/suggest", suggestLimiter, func(c fiber.Ctx) error {
+		query := strings.TrimSpace(c.Query("q"))
+
+		provider := app.CategoryManager.GetSearchProvider(c.Query("provider"))
+		if provider == nil {
+			provider = app.CategoryManager.GetDefaultSearchProvider()
+		}
+
+		// the query is read the way searching it would be: a bang anywhere in it searches another site, so there is
+		// nothing to show for it, and an @shortcut anywhere in it searches the rest of it with that provider
+		words := strings.Fields(query)
+		terms, shortcut := query, ""
+		if bang, _ := findShortcut(words, '!', app.CategoryManager.GetBang); bang != nil {
+			terms = ""
+		} else if shortcutProvider, rest := findShortcut(words, '@', app.CategoryManager.GetSearchProvider); shortcutProvider != nil {
+			provider, terms, shortcut = shortcutProvider, rest, shortcutProvider.Shortcut
+		}
+
+		links := []SearchResult{}
+		if terms != "" {
+			var err error
+			links, err = app.searchLinks(terms, c.Locals("IsAdmin") != nil, 5)
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to search links: %v", err),
+				})
+			}
+		}
+
+		suggestions := []string{}
+		if terms != "" && len(terms) <= maxSuggestionQuery && provider != nil && provider.SuggestURL != "" {
+			fetched, err := app.suggestions.Fetch(expandSearchURL(provider.SuggestURL, terms))
+			if err != nil {
+				slog.Debug("Failed to fetch search suggestions", "provider", provider.Shortcut, "error", err)
+			}
+
+			seen := map[string]bool{strings.ToLower(terms): true}
+			for _, suggestion := range fetched {
+				suggestion = strings.TrimSpace(suggestion)
+				key := strings.ToLower(suggestion)
+				if suggestion == "" || seen[key] {
+					continue
+				}
+				seen[key] = true
+
+				suggestions = append(suggestions, suggestion)
+				if len(suggestions) == 8 {
+					break
+				}
+			}
+		}
+
+		// the links can include private ones, so they shouldnt be kept around
+		c.Set("Cache-Control", "no-store")
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"query":       query,
+			"links":       links,
+			"suggestions": suggestions,
+			// the search provider an @shortcut in the query picked, so picking a suggestion searches it there too
+			"shortcut": shortcut,
+		})
+	})
+
+	api := router.Group("/api")
+	{
+		// the other API routes require admin auth, the boards themselves are rendered on the server
+		api.Use(func(c fiber.Ctx) error {
+			if c.Locals("IsAdmin") == nil {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Unauthorized"})
+			}
+
+			// the dashboard file is the only place changes to what it manages can come from, anything else would be
+			// overwritten by it
+			if app.DashboardFile != "" && c.Method() != fiber.MethodGet && app.dashboardManages(c.Path()) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"message": fmt.Sprintf("Passport is managed by %s, edit it to make changes", filepath.Base(app.DashboardFile)),
+				})
+			}
+
+			return c.Next()
+		})
+
+		api.Post("/board", func(c fiber.Ctx) error {
+			var req struct {
+				Slug      string `form:"slug"`
+				Title     string `form:"title"`
+				IsDefault bool   `form:"default"`
+			}
+			if err := c.Bind().Form(&req); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Failed to parse request",
+				})
+			}
+
+			req.Slug = strings.TrimSpace(req.Slug)
+			req.Title = strings.TrimSpace(req.Title)
+
+			if req.Slug == "" || req.Title == "" {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Slug and title are required",
+				})
+			}
+
+			if err := ValidateBoardSlug(req.Slug); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": err.Error(),
+				})
+			}
+
+			if len(req.Title) > 50 {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Title is too long. Maximum length is 50 characters",
+				})
+			}
+
+			board, err := app.CategoryManager.CreateBoard(actorFrom(c), Board{
+				Slug:      req.Slug,
+				Title:     req.Title,
+				IsDefault: req.IsDefault,
+			})
+			if err != nil {
+				if errors.Is(err, ErrBoardSlugTaken) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "A board with that slug already exists",
+					})
+				}
+
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to create board: %v", err),
+				})
+			}
+
+			return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+				"message": "Board created successfully",
+				"board":   board,
+			})
+		})
+
+		api.Patch("/board/:id", func(c fiber.Ctx) error {
+			var req struct {
+				Slug      string `form:"slug"`
+				Title     string `form:"title"`
+				IsDefault bool   `form:"default"`
+			}
+			if err := c.Bind().Form(&req); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Failed to parse request",
+				})
+			}
+
+			id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to parse board ID: %v", err),
+				})
+			}
+
+			board := app.CategoryManager.GetBoard(id)
+			if board == nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Board not found",
+				})
+			}
+
+			if slug := strings.TrimSpace(req.Slug); slug != "" {
+				if err := ValidateBoardSlug(slug); err != nil {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": err.Error(),
+					})
+				}
+				board.Slug = slug
+			}
+
+			if title := strings.TrimSpace(req.Title); title != "" {
+				if len(title) > 50 {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "Title is too long. Maximum length is 50 characters",
+					})
+				}
+				board.Title = title
+			}
+
+			// unchecked checkboxes arent sent, so only touch the default flag when it was
+			if formHas(c, "default") {
+				board.IsDefault = req.IsDefault
+			}
+
+			err = app.CategoryManager.UpdateBoard(actorFrom(c), *board)
+			if err != nil {
+				if errors.Is(err, ErrBoardSlugTaken) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "A board with that slug already exists",
+					})
+				}
+
+				if errors.Is(err, ErrNoDefaultBoard) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "There must be a default board, make another board the default instead",
+					})
+				}
+
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to update board: %v", err),
+				})
+			}
+
+			return c.Status(fiber.StatusOK).JSON(fiber.Map{
+				"message": "Board updated successfully",
+				"board":   board,
+			})
+		})
+
+		api.Delete("/board/:id", func(c fiber.Ctx) error {
+			id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to parse board ID: %v", err),
+				})
+			}
+
+			err = app.CategoryManager.DeleteBoard(actorFrom(c), id)
+			if err != nil {
+				if errors.Is(err, ErrBoardNotFound) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "Board not found",
+					})
+				}
+
+				if errors.Is(err, ErrDefaultBoard) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "The default board cannot be deleted, make another board the default first",
+					})
+				}
+
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to delete board: %v", err),
+				})
+			}
+
+			return c.SendStatus(fiber.StatusOK)
+		})
+
+		api.Post("/category", func(c fiber.Ctx) error {
+			var req struct {
+				Name       string `form:"name"`
+				BoardID    int64  `form:"board_id"`
+				Sort       string `form:"sort"`
+				Visibility string `form:"visibility"`
+			}
+			if err := c.Bind().Form(&req); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Failed to parse request",
+				})
+			}
+
+			if req.Name == "" {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Name is required",
+				})
+			}
+
+			req.Name = strings.TrimSpace(req.Name)
+
+			if len(req.Name) > 50 {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Name is too long. Maximum length is 50 characters",
+				})
+			}
+
+			if req.Sort == "" {
+				req.Sort = SortManual
+			}
+
+			if err := ValidateSort(req.Sort); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": err.Error(),
+				})
+			}
+
+			if req.Visibility == "" {
+				req.Visibility = VisibilityPublic
+			}
+
+			if err := ValidateVisibility(req.Visibility); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": err.Error(),
+				})
+			}
+
+			// categories created without a board go on the default board
+			board := app.CategoryManager.GetDefaultBoard()
+			if req.BoardID != 0 {
+				board = app.CategoryManager.GetBoard(req.BoardID)
+			}
+
+			if board == nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Board not found",
+				})
+			}
+
+			file, err := c.FormFile("icon")
+			if err != nil || file == nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Icon is required",
+				})
+			}
+
+			if file.Size > 5*1024*1024 {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "File size too large. Maximum size is 5MB",
+				})
+			}
+
+			contentType := file.Header.Get("Content-Type")
+			if contentType != "image/svg+xml" {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Only SVGs are supported for category icons!",
+				})
+			}
+
+			iconPath, err := UploadFile(file, contentType, c)
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": "Failed to upload file, please try again!",
+				})
+			}
+
+			category, err := app.CategoryManager.CreateCategory(actorFrom(c), Category{
+				BoardID:    board.ID,
+				Name:       req.Name,
+				Icon:       iconPath,
+				Sort:       req.Sort,
+				Visibility: req.Visibility,
+				Links:      []Link{},
+			})
+
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to create category: %v", err),
+				})
+			}
+
+			return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+				"message":  "Category created successfully",
+				"category": category,
+			})
+		})
+
+		reorderCategories := func(c fiber.Ctx, boardID int64) error {
+			var req struct {
+				IDs []int64 `json:"ids"`
+			}
+			if err := c.Bind().JSON(&req); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Failed to parse request",
+				})
+			}
+
+			err := app.CategoryManager.ReorderCategories(actorFrom(c), boardID, req.IDs)
+			if err != nil {
+				if errors.Is(err, ErrInvalidOrder) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "Invalid order: " + err.Error(),
+					})
+				}
+
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to reorder categories: %v", err),
+				})
+			}
+
+			return c.Status(fiber.StatusOK).JSON(fiber.Map{
+				"message": "Categories reordered successfully",
+			})
+		}
+
+		api.Put("/board/:id/category/order", func(c fiber.Ctx) error {
+			boardID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to parse board ID: %v", err),
+				})
+			}
+
+			if app.CategoryManager.GetBoard(boardID) == nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Board not found",
+				})
+			}
+
+			return reorderCategories(c, boardID)
+		})
+
+		// from before there were boards, it orders the categories of the default board
+		api.Put("/category/order", func(c fiber.Ctx) error {
+			board := app.CategoryManager.GetDefaultBoard()
+			if board == nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Board not found",
+				})
+			}
+
+			return reorderCategories(c, board.ID)
+		})
+
+		api.Put("/board/:id/pinned/order", func(c fiber.Ctx) error {
+			var req struct {
+				IDs []int64 `json:"ids"`
+			}
+			if err := c.Bind().JSON(&req); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Failed to parse request",
+				})
+			}
+
+			boardID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to parse board ID: %v", err),
+				})
+			}
+
+			if app.CategoryManager.GetBoard(boardID) == nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Board not found",
+				})
+			}
+
+			err = app.CategoryManager.ReorderPinned(actorFrom(c), boardID, req.IDs)
+			if err != nil {
+				if errors.Is(err, ErrInvalidOrder) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "Invalid order: " + err.Error(),
+					})
+				}
+
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to reorder pinned links: %v", err),
+				})
+			}
+
+			return c.Status(fiber.StatusOK).JSON(fiber.Map{
+				"message": "Pinned links reordered successfully",
+			})
+		})
+
+		api.Put("/category/:id/link/order", func(c fiber.Ctx) error {
+			var req struct {
+				IDs []int64 `json:"ids"`
+			}
+			if err := c.Bind().JSON(&req); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Failed to parse request",
+				})
+			}
+
+			categoryID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to parse category ID: %v", err),
+				})
+			}
+
+			if app.CategoryManager.GetCategory(categoryID) == nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Category not found",
+				})
+			}
+
+			err = app.CategoryManager.ReorderLinks(actorFrom(c), categoryID, req.IDs)
+			if err != nil {
+				if errors.Is(err, ErrInvalidOrder) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "Invalid order: " + err.Error(),
+					})
+				}
+
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to reorder links: %v", err),
+				})
+			}
+
+			return c.Status(fiber.StatusOK).JSON(fiber.Map{
+				"message": "Links reordered successfully",
+			})
+		})
+
+		// moves one or more links from any category into this one
+		api.Put("/category/:id/link/move", func(c fiber.Ctx) error {
+			var req struct {
+				IDs []int64 `json:"ids"`
+			}
+			if err := c.Bind().JSON(&req); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Failed to parse request",
+				})
+			}
+
+			if len(req.IDs) == 0 {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "At least one link ID is required",
+				})
+			}
+
+			categoryID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to parse category ID: %v", err),
+				})
+			}
+
+			err = app.CategoryManager.MoveLinks(actorFrom(c), req.IDs, categoryID)
+			if err != nil {
+				if errors.Is(err, ErrCategoryNotFound) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "Category not found",
+					})
+				}
+
+				if errors.Is(err, ErrLinkNotFound) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "Link not found",
+					})
+				}
+
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to move links: %v", err),
+				})
+			}
+
+			return c.Status(fiber.StatusOK).JSON(fiber.Map{
+				"message": "Links moved successfully",
+			})
+		})
+
+		api.Post("/category/:id/link", func(c fiber.Ctx) error {
+			var req struct {
+				Name        string `form:"name"`
+				Description string `form:"description"`
+				URL         string `form:"url"`
+				Tags        string `form:"tags"`
+				Visibility  string `form:"visibility"`
+				Keyword     string `form:"keyword"`
+				SearchURL   string `form:"search_url"`
+				Alias       string `form:"alias"`
+			}
+			if err := c.Bind().Form(&req); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Failed to parse request",
+				})
+			}
+
+			if req.Name == "" || req.URL == "" {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Name and URL are required",
+				})
+			}
+
+			req.Name = strings.TrimSpace(req.Name)
+			req.URL = strings.TrimSpace(req.URL)
+			if req.Description != "" {
+				req.Description = strings.TrimSpace(req.Description)
+			}
+
+			if err := validateLinkURL(req.URL); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": err.Error(),
+				})
+			}
+
+			tags, err := ParseTags(req.Tags)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": err.Error(),
+				})
+			}
+
+			if req.Visibility == "" {
+				req.Visibility = VisibilityPublic
+			}
+
+			if err := ValidateVisibility(req.Visibility); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": err.Error(),
+				})
+			}
+
+			if len(req.Name) > 50 {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Name is too long. Maximum length is 50 characters",
+				})
+			}
+
+			if len(req.Description) > 150 {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Description is too long. Maximum length is 150 characters",
+				})
+			}
+
+			keyword, err := ParseKeyword(req.Keyword, "Keyword")
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": err.Error(),
+				})
+			}
+
+			req.SearchURL = strings.TrimSpace(req.SearchURL)
+			if err := validateSearchURL(req.SearchURL); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": err.Error(),
+				})
+			}
+
+			alias, err := ParseAlias(req.Alias)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": err.Error(),
+				})
+			}
+
+			// CreateLink checks this again along with creating the link, checking now saves fetching an icon for nothing
+			if err := checkTaken(app.db, &Link{Keyword: keyword, Alias: alias}); err != nil {
+				var taken *TakenError
+				if errors.As(err, &taken) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": taken.Error(),
+					})
+				}
+
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": "Failed to check keyword and alias",
+				})
+			}
+
+			categoryID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to parse category ID: %v", err),
+				})
+			}
+
+			if app.CategoryManager.GetCategory(categoryID) == nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Category not found",
+				})
+			}
+
+			var iconPath string
+			file, err := c.FormFile("icon")
+			if err != nil || file == nil {
+				// without an uploaded icon, use the one the site declares for itself
+				iconPath, err = app.fetchIcon(req.URL)
+				if err != nil {
+					slog.Info("Failed to fetch icon", "url", req.URL, "error", err)
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": fmt.Sprintf("Icon is required, and it could not be fetched from the URL: %v", err),
+					})
+				}
+			} else {
+				if file.Size > 5*1024*1024 {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "File size too large. Maximum size is 5MB",
+					})
+				}
+
+				contentType := file.Header.Get("Content-Type")
+				if !strings.HasPrefix(contentType, "image/") {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "Only image files are allowed",
+					})
+				}
+
+				iconPath, err = UploadFile(file, contentType, c)
+				if err != nil {
+					slog.Error("Failed to upload file", "error", err)
+					status := fiber.StatusInternalServerError
+					if strings.Contains(err.Error(), "unsupported file type") {
+						status = fiber.StatusBadRequest
+					}
+
+					return c.Status(status).JSON(fiber.Map{
+						"message": "Failed to upload file: " + err.Error(),
+					})
+				}
+			}
+
+			link, err := app.CategoryManager.CreateLink(app.CategoryManager.db, actorFrom(c), Link{
+				CategoryID:  categoryID,
+				Name:        req.Name,
+				Description: req.Description,
+				Icon:        iconPath,
+				URL:         req.URL,
+				Tags:        tags,
+				Visibility:  req.Visibility,
+				Keyword:     keyword,
+				SearchURL:   req.SearchURL,
+				Alias:       alias,
+			})
+			if err != nil {
+				os.Remove(filepath.Join("public/", iconPath))
+
+				var taken *TakenError
+				if errors.As(err, &taken) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": taken.Error(),
+					})
+				}
+
+				slog.Error("Failed to create link", "error", err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": "Failed to create link",
+				})
+			}
+
+			return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+				"message": "Link created successfully",
+				"link":    link,
+			})
+		})
+
+		api.Patch("/category/:id", func(c fiber.Ctx) error {
+			var req struct {
+				Name       string `form:"name"`
+				Sort       string `form:"sort"`
+				Visibility string `form:"visibility"`
+			}
+
+			if c.Params("id") == "" {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "ID is required",
+				})
+			}
+
+			id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to parse category ID: %v", err),
+				})
+			}
+
+			if err := c.Bind().Form(&req); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Failed to parse request",
+				})
+			}
+
+			if req.Name != "" {
+				if len(req.Name) > 50 {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "Name is too long. Maximum length is 50 characters",
+					})
+				}
+			}
+
+			if req.Sort != "" {
+				if err := ValidateSort(req.Sort); err != nil {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": err.Error(),
+					})
+				}
+			}
+
+			if req.Visibility != "" {
+				if err := ValidateVisibility(req.Visibility); err != nil {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": err.Error(),
+					})
+				}
+			}
+
+			category := app.CategoryManager.GetCategory(id)
+			if category == nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Category not found",
+				})
+			}
+
+			tx, err := app.db.Begin()
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": "Failed to start transaction",
+				})
+			}
+			defer tx.Rollback()
+
+			file, err := c.FormFile("icon")
+			if err == nil {
+				if file.Size > 5*1024*1024 {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "File size too large. Maximum size is 5MB",
+					})
+				}
+
+				contentType := file.Header.Get("Content-Type")
+				if contentType != "image/svg+xml" {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "Only svg files are allowed",
+					})
+				}
+
+				oldIconPath := category.Icon
+
+				iconPath, err := UploadFile(file, contentType, c)
+				if err != nil {
+					slog.Error("Failed to upload file", "error", err)
+					status := fiber.StatusInternalServerError
+					if strings.Contains(err.Error(), "unsupported file type") {
+						status = fiber.StatusBadRequest
+					}
+
+					return c.Status(status).JSON(fiber.Map{
+						"message": "Failed to upload file: " + err.Error(),
+					})
+				}
+
+				_, err = tx.Exec("UPDATE categories SET icon = ? WHERE id = ?", iconPath, id)
+				if err != nil {
+					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+						"message": "Failed to update category",
+					})
+				}
+
+				err = os.Remove(filepath.Join("public/", oldIconPath))
+				if err != nil {
+					slog.Error("Failed to delete icon", "error", err)
+				}
+			}
+
+			if req.Name != "" {
+				_, err = tx.Exec("UPDATE categories SET name = ? WHERE id = ?", req.Name, category.ID)
+				if err != nil {
+					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+						"message": "Failed to update category",
+					})
+				}
+			}
+
+			if req.Sort != "" {
+				_, err = tx.Exec("UPDATE categories SET sort = ? WHERE id = ?", req.Sort, category.ID)
+				if err != nil {
+					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+						"message": "Failed to update category",
+					})
+				}
+			}
+
+			if req.Visibility != "" {
+				_, err = tx.Exec("UPDATE categories SET visibility = ? WHERE id = ?", req.Visibility, category.ID)
+				if err != nil {
+					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+						"message": "Failed to update category",
+					})
+				}
+			}
+
+			updated, err := lookupCategory(tx, category.ID)
+			if err == nil {
+				err = audit.Record(tx, actorFrom(c), audit.ActionUpdate, audit.EntityCategory, category.ID, category, updated)
+			}
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": "Failed to write audit log",
+				})
+			}
+
+			err = tx.Commit()
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": "Failed to commit transaction",
+				})
+			}
+
+			return c.Status(fiber.StatusOK).JSON(fiber.Map{
+				"message": "Category updated successfully",
+			})
+		})
+
+		api.Patch("/category/:categoryID/link/:linkID", func(c fiber.Ctx) error {
+			var req struct {
+				Name        string `form:"name"`
+				Description string `form:"description"`
+				Icon        string `form:"icon"`
+				URL         string `form:"url"`
+				Tags        string `form:"tags"`
+				Visibility  string `form:"visibility"`
+				Keyword     string `form:"keyword"`
+				SearchURL   string `form:"search_url"`
+				Alias       string `form:"alias"`
+				CategoryID  int64  `form:"category_id"`
+			}
+			if err := c.Bind().Form(&req); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Failed to parse request",
+				})
+			}
+
+			req.Name = strings.TrimSpace(req.Name)
+			req.Description = strings.TrimSpace(req.Description)
+			req.URL = strings.TrimSpace(req.URL)
+
+			// an empty description is a valid value, so we need to know if it was sent at all
+			updateDescription := formHas(c, "description")
+			updateURL := formHas(c, "url")
+
+			if updateURL {
+				if err := validateLinkURL(req.URL); err != nil {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": err.Error(),
+					})
+				}
+			}
+
+			var tags []string
+			updateTags := formHas(c, "tags")
+			if updateTags {
+				var err error
+				tags, err = ParseTags(req.Tags)
+				if err != nil {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": err.Error(),
+					})
+				}
+			}
+
+			if req.Visibility != "" {
+				if err := ValidateVisibility(req.Visibility); err != nil {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": err.Error(),
+					})
+				}
+			}
+
+			if len(req.Name) > 50 {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Name is too long. Maximum length is 50 characters",
+				})
+			}
+
+			if len(req.Description) > 150 {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Description is too long. Maximum length is 150 characters",
+				})
+			}
+
+			// like the description, an empty keyword, search URL or alias removes it
+			var keyword string
+			updateKeyword := formHas(c, "keyword")
+			if updateKeyword {
+				var err error
+				keyword, err = ParseKeyword(req.Keyword, "Keyword")
+				if err != nil {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": err.Error(),
+					})
+				}
+			}
+
+			req.SearchURL = strings.TrimSpace(req.SearchURL)
+			updateSearchURL := formHas(c, "search_url")
+			if updateSearchURL {
+				if err := validateSearchURL(req.SearchURL); err != nil {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": err.Error(),
+					})
+				}
+			}
+
+			var alias string
+			updateAlias := formHas(c, "alias")
+			if updateAlias {
+				var err error
+				alias, err = ParseAlias(req.Alias)
+				if err != nil {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": err.Error(),
+					})
+				}
+			}
+
+			linkID, err := strconv.ParseInt(c.Params("linkID"), 10, 64)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to parse link ID: %v", err),
+				})
+			}
+
+			categoryID, err := strconv.ParseInt(c.Params("categoryID"), 10, 64)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to parse category ID: %v", err),
+				})
+			}
+
+			if app.CategoryManager.GetCategory(categoryID) == nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Category not found",
+				})
+			}
+
+			link := app.CategoryManager.GetLink(linkID)
+			if link == nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Link not found",
+				})
+			}
+
+			tx, err := app.db.Begin()
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": "Failed to start transaction",
+				})
+			}
+			defer tx.Rollback()
+
+			file, err := c.FormFile("icon")
+			if err == nil {
+				if file.Size > 5*1024*1024 {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "File size too large. Maximum size is 5MB",
+					})
+				}
+
+				contentType := file.Header.Get("Content-Type")
+				if !strings.HasPrefix(contentType, "image/") {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "Only image files are allowed",
+					})
+				}
+
+				oldIconPath := link.Icon
+
+				iconPath, err := UploadFile(file, contentType, c)
+				if err != nil {
+					slog.Error("Failed to upload file", "error", err)
+					status := fiber.StatusInternalServerError
+					if strings.Contains(err.Error(), "unsupported file type") {
+						status = fiber.StatusBadRequest
+					}
+
+					return c.Status(status).JSON(fiber.Map{
+						"message": "Failed to upload file: " + err.Error(),
+					})
+				}
+
+				_, err = tx.Exec("UPDATE links SET icon = ? WHERE id = ?", iconPath, linkID)
+				if err != nil {
+					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+						"message": "Failed to update link",
+					})
+				}
+
+				err = os.Remove(filepath.Join("public/", oldIconPath))
+				if err != nil {
+					slog.Error("Failed to delete icon", "error", err)
+				}
+			}
+
+			if req.Name != "" {
+				_, err = tx.Exec("UPDATE links SET name = ? WHERE id = ?", req.Name, linkID)
+				if err != nil {
+					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+						"message": "Failed to update link",
+					})
+				}
+			}
+
+			if updateDescription {
+				_, err = tx.Exec("UPDATE links SET description = ? WHERE id = ?", req.Description, linkID)
+				if err != nil {
+					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+						"message": "Failed to update link",
+					})
+				}
+			}
+
+			if updateURL {
+				_, err = tx.Exec("UPDATE links SET url = ? WHERE id = ?", req.URL, linkID)
+				if err != nil {
+					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+						"message": "Failed to update link",
+					})
+				}
+			}
+
+			if req.Visibility != "" {
+				_, err = tx.Exec("UPDATE links SET visibility = ? WHERE id = ?", req.Visibility, linkID)
+				if err != nil {
+					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+						"message": "Failed to update link",
+					})
+				}
+			}
+
+			if updateTags {
+				err = setLinkTags(tx, linkID, tags)
+				if err != nil {
+					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+						"message": "Failed to update link tags",
+					})
+				}
+			}
+
+			if updateKeyword {
+				if keyword != "" {
+					owner, err := keywordOwner(tx, keyword, linkID)
+					if err != nil {
+						return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+							"message": "Failed to check keyword",
+						})
+					}
+
+					if owner != "" {
+						return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+							"message": fmt.Sprintf("Keyword is already used by %s", owner),
+						})
+					}
+				}
+
+				_, err = tx.Exec("UPDATE links SET keyword = ? WHERE id = ?", keyword, linkID)
+				if err != nil {
+					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+						"message": "Failed to update link",
+					})
+				}
+			}
+
+			if updateSearchURL {
+				_, err = tx.Exec("UPDATE links SET search_url = ? WHERE id = ?", req.SearchURL, linkID)
+				if err != nil {
+					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+						"message": "Failed to update link",
+					})
+				}
+			}
+
+			if updateAlias {
+				if alias != "" {
+					owner, err := aliasOwner(tx, alias, linkID)
+					if err != nil {
+						return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+							"message": "Failed to check alias",
+						})
+					}
+
+					if owner != "" {
+						return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+							"message": fmt.Sprintf("Alias is already used by %s", owner),
+						})
+					}
+				}
+
+				_, err = tx.Exec("UPDATE links SET alias = ? WHERE id = ?", alias, linkID)
+				if err != nil {
+					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+						"message": "Failed to update link",
+					})
+				}
+			}
+
+			if req.CategoryID != 0 && req.CategoryID != link.CategoryID {
+				err = moveLinks(tx, []int64{linkID}, req.CategoryID)
+				if err != nil {
+					if errors.Is(err, ErrCategoryNotFound) {
+						return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+							"message": "Target category not found",
+						})
+					}
+
+					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+						"message": "Failed to move link",
+					})
+				}
+			}
+
+			updated, err := lookupLink(tx, linkID)
+			if err == nil {
+				err = audit.Record(tx, actorFrom(c), audit.ActionUpdate, audit.EntityLink, linkID, link, updated)
+			}
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": "Failed to write audit log",
+				})
+			}
+
+			err = tx.Commit()
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": "Failed to commit transaction",
+				})
+			}
+
+			slog.Info("Link updated successfully", "id", linkID, "name", req.Name)
+
+			response := fiber.Map{
+				"message": "Link updated successfully",
+			}
+
+			// tags are normalized, so let the client know what they ended up as
+			if updateTags {
+				response["tags"] = tags
+			}
+
+			if updateKeyword {
+				response["keyword"] = keyword
+			}
+
+			if updateAlias {
+				response["alias"] = alias
+			}
+
+			return c.Status(fiber.StatusOK).JSON(response)
+		})
+
+		api.Delete("/category/:categoryID/link/:linkID", func(c fiber.Ctx) error {
+			linkID, err := strconv.ParseInt(c.Params("linkID"), 10, 64)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to parse link ID: %v", err),
+				})
+			}
+
+			categoryID, err := strconv.ParseInt(c.Params("categoryID"), 10, 64)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to parse category ID: %v", err),
+				})
+			}
+
+			if app.CategoryManager.GetCategory(categoryID) == nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Category not found",
+				})
+			}
+
+			link := app.CategoryManager.GetLink(linkID)
+			if link == nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Link not found",
+				})
+			}
+
+			if link.CategoryID != categoryID {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Invalid category ID",
+				})
+			}
+
+			err = app.CategoryManager.DeleteLink(actorFrom(c), linkID)
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to delete link: %v", err),
+				})
+			}
+
+			return c.SendStatus(fiber.StatusOK)
+		})
+
+		// PUT pins a link, DELETE unpins it
+		setPinned := func(pinned bool) fiber.Handler {
+			return func(c fiber.Ctx) error {
+				linkID, err := strconv.ParseInt(c.Params("linkID"), 10, 64)
+				if err != nil {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": fmt.Sprintf("Failed to parse link ID: %v", err),
+					})
+				}
+
+				categoryID, err := strconv.ParseInt(c.Params("categoryID"), 10, 64)
+				if err != nil {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": fmt.Sprintf("Failed to parse category ID: %v", err),
+					})
+				}
+
+				link := app.CategoryManager.GetLink(linkID)
+				if link == nil {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "Link not found",
+					})
+				}
+
+				if link.CategoryID != categoryID {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "Invalid category ID",
+					})
+				}
+
+				link, err = app.CategoryManager.SetPinned(actorFrom(c), linkID, pinned)
+				if err != nil {
+					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+						"message": fmt.Sprintf("Failed to update link: %v", err),
+					})
+				}
+
+				message := "Link unpinned successfully"
+				if pinned {
+					message = "Link pinned successfully"
+				}
+
+				return c.Status(fiber.StatusOK).JSON(fiber.Map{
+					"message": message,
+					"link":    link,
+				})
+			}
+		}
+
+		api.Put("/category/:categoryID/link/:linkID/pin", setPinned(true))
+		api.Delete("/category/:categoryID/link/:linkID/pin", setPinned(false))
+
+		// saves the icon uploaded along with a search provider, if there is one
+		uploadProviderIcon := func(c fiber.Ctx) (string, error) {
+			file, err := c.FormFile("icon")
+			if err != nil || file == nil {
+				return "", nil
+			}
+
+			if file.Size > 5*1024*1024 {
+				return "", errors.New("File size too large. Maximum size is 5MB")
+			}
+
+			contentType := file.Header.Get("Content-Type")
+			if !strings.HasPrefix(contentType, "image/") {
+				return "", errors.New("Only image files are allowed")
+			}
+
+			iconPath, err := UploadFile(file, contentType, c)
+			if err != nil {
+				slog.Error("Failed to upload file", "error", err)
+				return "", fmt.Errorf("Failed to upload file: %w", err)
+			}
+
+			return iconPath, nil
+		}
 
-		// Set cookie
-		c.Cookie(&fiber.Cookie{
-			Name:    "SessionToken",
-			Value:   sessionID,
-			Expires: expiresAt,
+		api.Get("/search-providers", func(c fiber.Ctx) error {
+			return c.Status(fiber.StatusOK).JSON(fiber.Map{
+				"providers": app.CategoryManager.GetSearchProviders(),
+			})
 		})
 
-		return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Logged in successfully"})
-	})
+		api.Post("/search-provider", func(c fiber.Ctx) error {
+			var req struct {
+				Name       string `form:"name"`
+				Shortcut   string `form:"shortcut"`
+				URL        string `form:"url"`
+				SuggestURL string `form:"suggest_url"`
+				IsDefault  bool   `form:"default"`
+			}
+			if err := c.Bind().Form(&req); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Failed to parse request",
+				})
+			}
 
-	router.Get("/admin", func(c fiber.Ctx) error {
-		if c.Locals("IsAdmin") == nil {
-			return c.Redirect().To("/admin/login")
-		}
+			provider := SearchProvider{Name: req.Name, Shortcut: req.Shortcut, URL: req.URL, SuggestURL: req.SuggestURL, IsDefault: req.IsDefault}
+			if err := ValidateSearchProvider(&provider); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": err.Error(),
+				})
+			}
 
-		return c.Render("views/admin/index", fiber.Map{
-			"Categories": app.CategoryManager.GetCategories(),
-			"IsAdmin":    true,
-		})
-	})
+			icon, err := uploadProviderIcon(c)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": err.Error(),
+				})
+			}
 
-	api := router.Group("/api")
-	{
-		// all API routes require admin auth. No user needs to make api requests since the site is SSR
-		api.Use(func(c fiber.Ctx) error {
-			if c.Locals("IsAdmin") == nil {
-				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Unauthorized"})
+			// without an uploaded icon, use the one of the site being searched
+			if icon == "" {
+				icon, err = app.searchProviderIcon(provider.URL)
+				if err != nil {
+					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+						"message": fmt.Sprintf("Failed to save icon: %v", err),
+					})
+				}
 			}
-			return c.Next()
+			provider.Icon = icon
+
+			created, err := app.CategoryManager.CreateSearchProvider(actorFrom(c), provider)
+			if err != nil {
+				os.Remove(filepath.Join("public/", icon))
+
+				if errors.Is(err, ErrSearchProviderTaken) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "A search provider with that shortcut already exists",
+					})
+				}
+
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to create search provider: %v", err),
+				})
+			}
+
+			return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+				"message":  "Search provider created successfully",
+				"provider": created,
+			})
 		})
 
-		api.Post("/category", func(c fiber.Ctx) error {
+		api.Patch("/search-provider/:id", func(c fiber.Ctx) error {
 			var req struct {
-				Name string `form:"name"`
+				Name       string `form:"name"`
+				Shortcut   string `form:"shortcut"`
+				URL        string `form:"url"`
+				SuggestURL string `form:"suggest_url"`
+				IsDefault  bool   `form:"default"`
 			}
 			if err := c.Bind().Form(&req); err != nil {
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -889,70 +7277,166 @@ func main() {
 				})
 			}
 
-			if req.Name == "" {
+			id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+			if err != nil {
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": "Name is required",
+					"message": fmt.Sprintf("Failed to parse search provider ID: %v", err),
 				})
 			}
 
-			req.Name = strings.TrimSpace(req.Name)
-
-			if len(req.Name) > 50 {
+			before, err := lookupSearchProvider(app.db, id)
+			if err != nil {
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": "Name is too long. Maximum length is 50 characters",
+					"message": "Search provider not found",
 				})
 			}
 
-			file, err := c.FormFile("icon")
-			if err != nil || file == nil {
+			// leaving out default keeps the provider the default, or not, like it was
+			if !formHas(c, "default") {
+				req.IsDefault = before.IsDefault
+			}
+
+			provider := SearchProvider{
+				ID:         id,
+				Name:       req.Name,
+				Shortcut:   req.Shortcut,
+				URL:        req.URL,
+				SuggestURL: req.SuggestURL,
+				IsDefault:  req.IsDefault,
+			}
+			if err := ValidateSearchProvider(&provider); err != nil {
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": "Icon is required",
+					"message": err.Error(),
 				})
 			}
 
-			if file.Size > 5*1024*1024 {
+			provider.Icon, err = uploadProviderIcon(c)
+			if err != nil {
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": "File size too large. Maximum size is 5MB",
+					"message": err.Error(),
 				})
 			}
 
-			contentType := file.Header.Get("Content-Type")
-			if contentType != "image/svg+xml" {
+			err = app.CategoryManager.UpdateSearchProvider(actorFrom(c), provider)
+			if err != nil {
+				if provider.Icon != "" {
+					os.Remove(filepath.Join("public/", provider.Icon))
+				}
+
+				if errors.Is(err, ErrSearchProviderNotFound) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "Search provider not found",
+					})
+				}
+
+				if errors.Is(err, ErrSearchProviderTaken) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "A search provider with that shortcut already exists",
+					})
+				}
+
+				if errors.Is(err, ErrNoDefaultSearchProvider) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "There must be a default search provider, make another provider the default instead",
+					})
+				}
+
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to update search provider: %v", err),
+				})
+			}
+
+			if provider.Icon == "" {
+				provider.Icon = before.Icon
+			}
+
+			return c.Status(fiber.StatusOK).JSON(fiber.Map{
+				"message":  "Search provider updated successfully",
+				"provider": provider,
+			})
+		})
+
+		api.Delete("/search-provider/:id", func(c fiber.Ctx) error {
+			id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+			if err != nil {
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": "Only SVGs are supported for category icons!",
+					"message": fmt.Sprintf("Failed to parse search provider ID: %v", err),
 				})
 			}
 
-			iconPath, err := UploadFile(file, contentType, c)
+			err = app.CategoryManager.DeleteSearchProvider(actorFrom(c), id)
 			if err != nil {
+				if errors.Is(err, ErrSearchProviderNotFound) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "Search provider not found",
+					})
+				}
+
+				if errors.Is(err, ErrDefaultSearchProvider) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "The default search provider cannot be deleted, make another provider the default first",
+					})
+				}
+
 				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-					"message": "Failed to upload file, please try again!",
+					"message": fmt.Sprintf("Failed to delete search provider: %v", err),
 				})
 			}
 
-			category, err := app.CategoryManager.CreateCategory(Category{
-				Name:  req.Name,
-				Icon:  iconPath,
-				Links: []Link{},
+			return c.Status(fiber.StatusOK).JSON(fiber.Map{
+				"message": "Search provider deleted successfully",
+			})
+		})
+
+		api.Get("/bangs", func(c fiber.Ctx) error {
+			return c.Status(fiber.StatusOK).JSON(fiber.Map{
+				"bangs": app.CategoryManager.GetBangs(),
 			})
+		})
+
+		api.Post("/bang", func(c fiber.Ctx) error {
+			var req struct {
+				Bang string `form:"bang"`
+				Name string `form:"name"`
+				URL  string `form:"url"`
+			}
+			if err := c.Bind().Form(&req); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Failed to parse request",
+				})
+			}
+
+			bang := Bang{Bang: req.Bang, Name: req.Name, URL: req.URL}
+			if err := ValidateBang(&bang); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": err.Error(),
+				})
+			}
 
+			created, err := app.CategoryManager.CreateBang(actorFrom(c), bang)
 			if err != nil {
+				if errors.Is(err, ErrBangTaken) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "A bang with that name already exists",
+					})
+				}
+
 				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-					"message": fmt.Sprintf("Failed to create category: %v", err),
+					"message": fmt.Sprintf("Failed to create bang: %v", err),
 				})
 			}
 
 			return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-				"message":  "Category created successfully",
-				"category": category,
+				"message": "Bang created successfully",
+				"bang":    created,
 			})
 		})
 
-		api.Post("/category/:id/link", func(c fiber.Ctx) error {
+		api.Patch("/bang/:id", func(c fiber.Ctx) error {
 			var req struct {
-				Name        string `form:"name"`
-				Description string `form:"description"`
-				URL         string `form:"url"`
+				Bang string `form:"bang"`
+				Name string `form:"name"`
+				URL  string `form:"url"`
 			}
 			if err := c.Bind().Form(&req); err != nil {
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -960,230 +7444,258 @@ func main() {
 				})
 			}
 
-			if req.Name == "" || req.URL == "" {
+			id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+			if err != nil {
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": "Name and URL are required",
+					"message": fmt.Sprintf("Failed to parse bang ID: %v", err),
 				})
 			}
 
-			req.Name = strings.TrimSpace(req.Name)
-			if req.Description != "" {
-				req.Description = strings.TrimSpace(req.Description)
+			bang := Bang{ID: id, Bang: req.Bang, Name: req.Name, URL: req.URL}
+			if err := ValidateBang(&bang); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": err.Error(),
+				})
 			}
 
-			if len(req.Name) > 50 {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": "Name is too long. Maximum length is 50 characters",
+			err = app.CategoryManager.UpdateBang(actorFrom(c), bang)
+			if err != nil {
+				if errors.Is(err, ErrBangNotFound) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "Bang not found",
+					})
+				}
+
+				if errors.Is(err, ErrBangTaken) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "A bang with that name already exists",
+					})
+				}
+
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to update bang: %v", err),
 				})
 			}
 
-			if len(req.Description) > 150 {
+			return c.Status(fiber.StatusOK).JSON(fiber.Map{
+				"message": "Bang updated successfully",
+				"bang":    bang,
+			})
+		})
+
+		api.Delete("/bang/:id", func(c fiber.Ctx) error {
+			id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+			if err != nil {
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": "Description is too long. Maximum length is 150 characters",
+					"message": fmt.Sprintf("Failed to parse bang ID: %v", err),
 				})
 			}
 
-			categoryID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+			err = app.CategoryManager.DeleteBang(actorFrom(c), id)
 			if err != nil {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": fmt.Sprintf("Failed to parse category ID: %v", err),
+				if errors.Is(err, ErrBangNotFound) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "Bang not found",
+					})
+				}
+
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to delete bang: %v", err),
 				})
 			}
 
-			if app.CategoryManager.GetCategory(categoryID) == nil {
+			return c.SendStatus(fiber.StatusOK)
+		})
+
+		api.Post("/import/bookmarks", func(c fiber.Ctx) error {
+			var req struct {
+				BoardID           int64  `form:"board_id"`
+				Visibility        string `form:"visibility"`
+				Preview           bool   `form:"preview"`
+				IncludeDuplicates bool   `form:"include_duplicates"`
+				Format            string `form:"format"`
+			}
+			if err := c.Bind().Form(&req); err != nil {
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": "Category not found",
+					"message": "Failed to parse request",
 				})
 			}
 
-			file, err := c.FormFile("icon")
-			if err != nil || file == nil {
+			if req.Visibility == "" {
+				req.Visibility = VisibilityPublic
+			}
+
+			if err := ValidateVisibility(req.Visibility); err != nil {
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": "Icon is required",
+					"message": err.Error(),
 				})
 			}
 
-			if file.Size > 5*1024*1024 {
+			if req.Format != "" && req.Format != importFormatBookmarks && !slices.Contains(importers.Formats, req.Format) {
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": "File size too large. Maximum size is 5MB",
+					"message": "Unknown import format",
 				})
 			}
 
-			contentType := file.Header.Get("Content-Type")
-			if !strings.HasPrefix(contentType, "image/") {
+			if app.CategoryManager.GetBoard(req.BoardID) == nil {
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": "Only image files are allowed",
+					"message": "Board not found",
 				})
 			}
 
-			iconPath, err := UploadFile(file, contentType, c)
+			file, err := c.FormFile("file")
 			if err != nil {
-				slog.Error("Failed to upload file", "error", err)
-				status := fiber.StatusInternalServerError
-				if strings.Contains(err.Error(), "unsupported file type") {
-					status = fiber.StatusBadRequest
-				}
-
-				return c.Status(status).JSON(fiber.Map{
-					"message": "Failed to upload file: " + err.Error(),
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "A bookmark or dashboard file is required",
 				})
 			}
 
-			link, err := app.CategoryManager.CreateLink(app.CategoryManager.db, Link{
-				CategoryID:  categoryID,
-				Name:        req.Name,
-				Description: req.Description,
-				Icon:        iconPath,
-				URL:         req.URL,
-			})
+			src, err := file.Open()
 			if err != nil {
-				slog.Error("Failed to create link", "error", err)
 				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-					"message": "Failed to create link",
+					"message": "Failed to read file",
 				})
 			}
+			defer src.Close()
 
-			return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-				"message": "Link created successfully",
-				"link":    link,
-			})
-		})
-
-		api.Patch("/category/:id", func(c fiber.Ctx) error {
-			var req struct {
-				Name string `form:"name"`
-			}
-
-			if c.Params("id") == "" {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": "ID is required",
+			data, err := io.ReadAll(src)
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": "Failed to read file",
 				})
 			}
 
-			id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+			folders, format, err := parseImport(data, req.Format)
 			if err != nil {
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": fmt.Sprintf("Failed to parse category ID: %v", err),
+					"message": fmt.Sprintf("Failed to parse file: %v", err),
 				})
 			}
 
-			if err := c.Bind().Form(&req); err != nil {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": "Failed to parse request",
+			// the plan is worked out again when importing, so the preview can never be applied to a board that changed since
+			plan := app.CategoryManager.PlanImport(req.BoardID, folders)
+			if req.Preview {
+				return c.Status(fiber.StatusOK).JSON(fiber.Map{
+					"plan":   plan,
+					"format": format,
 				})
 			}
 
-			if req.Name != "" {
-				if len(req.Name) > 50 {
-					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-						"message": "Name is too long. Maximum length is 50 characters",
-					})
-				}
+			var icons *dashboardIcons
+			if format != importFormatBookmarks {
+				// every link can need its icon fetched, so the whole import gets a budget rather than only each icon
+				ctx, cancel := context.WithTimeout(context.Background(), maxImportIconTime)
+				defer cancel()
+
+				icons = newDashboardIcons(ctx, "", app.DashboardIconURL, app.favicons)
 			}
 
-			category := app.CategoryManager.GetCategory(id)
-			if category == nil {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": "Category not found",
+			categories, links, err := app.CategoryManager.ApplyImport(actorFrom(c), req.BoardID, plan, req.Visibility, req.IncludeDuplicates, icons)
+			if err != nil {
+				slog.Error("Failed to import bookmarks", "error", err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to import bookmarks: %v", err),
 				})
 			}
 
-			tx, err := app.db.Begin()
-			if err != nil {
+			slog.Info("Bookmarks imported", "board", req.BoardID, "format", format, "categories", categories, "links", links)
+
+			return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+				"message":    fmt.Sprintf("Imported %d links into %d new categories", links, categories),
+				"categories": categories,
+				"links":      links,
+			})
+		})
+
+		api.Get("/export/bookmarks.html", func(c fiber.Ctx) error {
+			var buf bytes.Buffer
+			if err := bookmarks.Write(&buf, app.CategoryManager.ExportBookmarks()); err != nil {
 				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-					"message": "Failed to start transaction",
+					"message": fmt.Sprintf("Failed to export bookmarks: %v", err),
 				})
 			}
-			defer tx.Rollback()
 
-			file, err := c.FormFile("icon")
-			if err == nil {
-				if file.Size > 5*1024*1024 {
-					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-						"message": "File size too large. Maximum size is 5MB",
-					})
-				}
+			c.Set("Content-Type", "text/html; charset=utf-8")
+			c.Set("Content-Disposition", `attachment; filename="passport-bookmarks.html"`)
 
-				contentType := file.Header.Get("Content-Type")
-				if contentType != "image/svg+xml" {
-					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-						"message": "Only svg files are allowed",
-					})
-				}
+			return c.Status(fiber.StatusOK).Send(buf.Bytes())
+		})
 
-				oldIconPath := category.Icon
+		api.Get("/backup", func(c fiber.Ctx) error {
+			var buf bytes.Buffer
+			if err := createBackup(app.db, &buf); err != nil {
+				slog.Error("Failed to create backup", "error", err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to create backup: %v", err),
+				})
+			}
 
-				iconPath, err := UploadFile(file, contentType, c)
-				if err != nil {
-					slog.Error("Failed to upload file", "error", err)
-					status := fiber.StatusInternalServerError
-					if strings.Contains(err.Error(), "unsupported file type") {
-						status = fiber.StatusBadRequest
-					}
+			c.Set("Content-Type", "application/gzip")
+			c.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="passport-backup-%s.tar.gz"`, time.Now().Format("20060102-150405")))
 
-					return c.Status(status).JSON(fiber.Map{
-						"message": "Failed to upload file: " + err.Error(),
-					})
-				}
+			return c.Status(fiber.StatusOK).Send(buf.Bytes())
+		})
 
-				_, err = tx.Exec("UPDATE categories SET icon = ? WHERE id = ?", iconPath, id)
-				if err != nil {
-					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-						"message": "Failed to update category",
-					})
-				}
+		api.Post("/restore", func(c fiber.Ctx) error {
+			file, err := c.FormFile("backup")
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "A backup file is required",
+				})
+			}
 
-				err = os.Remove(filepath.Join("public/", oldIconPath))
-				if err != nil {
-					slog.Error("Failed to delete icon", "error", err)
-				}
+			src, err := file.Open()
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": "Failed to read file",
+				})
 			}
+			defer src.Close()
 
-			if req.Name != "" {
-				_, err = tx.Exec("UPDATE categories SET name = ? WHERE id = ?", req.Name, category.ID)
-				if err != nil {
-					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-						"message": "Failed to update category",
+			if err := restoreBackup(app.db, src); err != nil {
+				if errors.Is(err, backup.ErrInvalidArchive) || errors.Is(err, migrations.ErrDatabaseTooNew) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": fmt.Sprintf("Failed to restore backup: %v", err),
 					})
 				}
-			}
 
-			err = tx.Commit()
-			if err != nil {
+				slog.Error("Failed to restore backup", "error", err)
 				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-					"message": "Failed to commit transaction",
+					"message": fmt.Sprintf("Failed to restore backup: %v", err),
 				})
 			}
 
+			slog.Info("Backup restored", "file", file.Filename)
+
 			return c.Status(fiber.StatusOK).JSON(fiber.Map{
-				"message": "Category updated successfully",
+				"message": "Backup restored successfully",
 			})
 		})
 
-		api.Patch("/category/:categoryID/link/:linkID", func(c fiber.Ctx) error {
-			var req struct {
-				Name        string `form:"name"`
-				Description string `form:"description"`
-				Icon        string `form:"icon"`
-			}
-			if err := c.Bind().Form(&req); err != nil {
+		api.Get("/metadata", func(c fiber.Ctx) error {
+			pageURL := c.Query("url")
+			if err := validateLinkURL(pageURL); err != nil {
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": "Failed to parse request",
+					"message": err.Error(),
 				})
 			}
 
-			if len(req.Name) > 50 {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": "Name is too long. Maximum length is 50 characters",
+			metadata, err := app.metadata.Fetch(pageURL)
+			if err != nil {
+				slog.Info("Failed to fetch metadata", "url", pageURL, "error", err)
+				return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to fetch page: %v", err),
 				})
 			}
 
-			if len(req.Description) > 150 {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": "Description is too long. Maximum length is 150 characters",
-				})
-			}
+			// cut to the limits of the link handlers, so the result can be submitted as is
+			return c.Status(fiber.StatusOK).JSON(fiber.Map{
+				"title":       truncateText(metadata.Title, 50),
+				"description": truncateText(metadata.Description, 150),
+			})
+		})
 
+		api.Post("/category/:categoryID/link/:linkID/icon/refetch", func(c fiber.Ctx) error {
 			linkID, err := strconv.ParseInt(c.Params("linkID"), 10, 64)
 			if err != nil {
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -1198,172 +7710,229 @@ func main() {
 				})
 			}
 
-			if app.CategoryManager.GetCategory(categoryID) == nil {
+			link := app.CategoryManager.GetLink(linkID)
+			if link == nil {
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": "Category not found",
+					"message": "Link not found",
 				})
 			}
 
-			link := app.CategoryManager.GetLink(linkID)
-			if link == nil {
+			if link.CategoryID != categoryID {
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": "Link not found",
+					"message": "Invalid category ID",
 				})
 			}
 
-			tx, err := app.db.Begin()
+			iconPath, err := app.fetchIcon(link.URL)
 			if err != nil {
-				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-					"message": "Failed to start transaction",
+				slog.Info("Failed to fetch icon", "url", link.URL, "error", err)
+				return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to fetch icon: %v", err),
 				})
 			}
-			defer tx.Rollback()
 
-			file, err := c.FormFile("icon")
-			if err == nil {
-				if file.Size > 5*1024*1024 {
-					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-						"message": "File size too large. Maximum size is 5MB",
-					})
-				}
+			oldIconPath := link.Icon
 
-				contentType := file.Header.Get("Content-Type")
-				if !strings.HasPrefix(contentType, "image/") {
-					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-						"message": "Only image files are allowed",
-					})
-				}
+			link, err = app.CategoryManager.SetIcon(actorFrom(c), linkID, iconPath)
+			if err != nil {
+				os.Remove(filepath.Join("public/", iconPath))
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to update link: %v", err),
+				})
+			}
 
-				oldIconPath := link.Icon
+			err = os.Remove(filepath.Join("public/", oldIconPath))
+			if err != nil {
+				slog.Error("Failed to delete icon", "icon", oldIconPath, "error", err)
+			}
 
-				iconPath, err := UploadFile(file, contentType, c)
-				if err != nil {
-					slog.Error("Failed to upload file", "error", err)
-					status := fiber.StatusInternalServerError
-					if strings.Contains(err.Error(), "unsupported file type") {
-						status = fiber.StatusBadRequest
-					}
+			return c.Status(fiber.StatusOK).JSON(fiber.Map{
+				"message": "Icon refetched successfully",
+				"link":    link,
+			})
+		})
 
-					return c.Status(status).JSON(fiber.Map{
-						"message": "Failed to upload file: " + err.Error(),
-					})
-				}
+		api.Delete("/category/:id", func(c fiber.Ctx) error {
+			// id = parseInt(c.Params("id"))
+			id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to parse category ID: %v", err),
+				})
+			}
 
-				_, err = tx.Exec("UPDATE links SET icon = ? WHERE id = ?", iconPath, linkID)
-				if err != nil {
-					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-						"message": "Failed to update link",
-					})
-				}
+			if app.CategoryManager.GetCategory(id) == nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Category not found",
+				})
+			}
 
-				err = os.Remove(filepath.Join("public/", oldIconPath))
-				if err != nil {
-					slog.Error("Failed to delete icon", "error", err)
-				}
+			err = app.CategoryManager.DeleteCategory(actorFrom(c), id)
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to delete category: %v", err),
+				})
 			}
 
-			if req.Name != "" {
-				_, err = tx.Exec("UPDATE links SET name = ? WHERE id = ?", req.Name, linkID)
-				if err != nil {
-					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-						"message": "Failed to update link",
-					})
-				}
+			return c.SendStatus(fiber.StatusOK)
+		})
+
+		api.Post("/trash/category/:id/restore", func(c fiber.Ctx) error {
+			id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to parse category ID: %v", err),
+				})
 			}
 
-			if req.Description != "" {
-				_, err = tx.Exec("UPDATE links SET description = ? WHERE id = ?", req.Description, linkID)
-				if err != nil {
-					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-						"message": "Failed to update link",
+			err = app.CategoryManager.RestoreCategory(actorFrom(c), id)
+			if err != nil {
+				if errors.Is(err, ErrNotInTrash) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "Category is not in the trash",
 					})
 				}
-			}
 
-			err = tx.Commit()
-			if err != nil {
 				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-					"message": "Failed to commit transaction",
+					"message": fmt.Sprintf("Failed to restore category: %v", err),
 				})
 			}
 
-			slog.Info("Link updated successfully", "id", linkID, "name", req.Name)
-
 			return c.Status(fiber.StatusOK).JSON(fiber.Map{
-				"message": "Link updated successfully",
+				"message": "Category restored successfully",
 			})
 		})
 
-		api.Delete("/category/:categoryID/link/:linkID", func(c fiber.Ctx) error {
-			linkID, err := strconv.ParseInt(c.Params("linkID"), 10, 64)
+		api.Delete("/trash/category/:id", func(c fiber.Ctx) error {
+			id, err := strconv.ParseInt(c.Params("id"), 10, 64)
 			if err != nil {
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": fmt.Sprintf("Failed to parse link ID: %v", err),
+					"message": fmt.Sprintf("Failed to parse category ID: %v", err),
 				})
 			}
 
-			categoryID, err := strconv.ParseInt(c.Params("categoryID"), 10, 64)
+			err = app.CategoryManager.PurgeCategory(actorFrom(c), id)
 			if err != nil {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": fmt.Sprintf("Failed to parse category ID: %v", err),
+				if errors.Is(err, ErrNotInTrash) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "Category is not in the trash",
+					})
+				}
+
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to purge category: %v", err),
 				})
 			}
 
-			if app.CategoryManager.GetCategory(categoryID) == nil {
+			return c.SendStatus(fiber.StatusOK)
+		})
+
+		api.Post("/trash/link/:id/restore", func(c fiber.Ctx) error {
+			id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+			if err != nil {
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": "Category not found",
+					"message": fmt.Sprintf("Failed to parse link ID: %v", err),
 				})
 			}
 
-			link := app.CategoryManager.GetLink(linkID)
-			if link == nil {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": "Link not found",
+			err = app.CategoryManager.RestoreLink(actorFrom(c), id)
+			if err != nil {
+				if errors.Is(err, ErrNotInTrash) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "Link is not in the trash",
+					})
+				}
+
+				if errors.Is(err, ErrCategoryInTrash) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "This link's category is in the trash, restore the category first",
+					})
+				}
+
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to restore link: %v", err),
 				})
 			}
 
-			if link.CategoryID != categoryID {
+			return c.Status(fiber.StatusOK).JSON(fiber.Map{
+				"message": "Link restored successfully",
+			})
+		})
+
+		api.Delete("/trash/link/:id", func(c fiber.Ctx) error {
+			id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+			if err != nil {
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": "Invalid category ID",
+					"message": fmt.Sprintf("Failed to parse link ID: %v", err),
 				})
 			}
 
-			err = app.CategoryManager.DeleteLink(linkID)
+			err = app.CategoryManager.PurgeLink(actorFrom(c), id)
 			if err != nil {
+				if errors.Is(err, ErrNotInTrash) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"message": "Link is not in the trash",
+					})
+				}
+
 				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-					"message": fmt.Sprintf("Failed to delete link: %v", err),
+					"message": fmt.Sprintf("Failed to purge link: %v", err),
 				})
 			}
 
 			return c.SendStatus(fiber.StatusOK)
 		})
 
-		api.Delete("/category/:id", func(c fiber.Ctx) error {
-			// id = parseInt(c.Params("id"))
-			id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+		api.Get("/audit", func(c fiber.Ctx) error {
+			filter, page, err := parseAuditQuery(c)
 			if err != nil {
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": fmt.Sprintf("Failed to parse category ID: %v", err),
+					"message": err.Error(),
 				})
 			}
 
-			if app.CategoryManager.GetCategory(id) == nil {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"message": "Category not found",
+			entries, total, err := audit.List(app.db, filter)
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"message": fmt.Sprintf("Failed to read audit log: %v", err),
 				})
 			}
 
-			err = app.CategoryManager.DeleteCategory(id)
+			return c.Status(fiber.StatusOK).JSON(fiber.Map{
+				"entries":  entries,
+				"total":    total,
+				"page":     page,
+				"per_page": filter.Limit,
+			})
+		})
+
+		// empties the whole trash
+		api.Delete("/trash", func(c fiber.Ctx) error {
+			purged, err := app.CategoryManager.PurgeTrash(actorFrom(c), time.Now())
 			if err != nil {
 				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-					"message": fmt.Sprintf("Failed to delete category: %v", err),
+					"message": fmt.Sprintf("Failed to empty trash: %v", err),
 				})
 			}
 
-			return c.SendStatus(fiber.StatusOK)
+			return c.Status(fiber.StatusOK).JSON(fiber.Map{
+				"message": "Trash emptied successfully",
+				"purged":  purged,
+			})
 		})
 	}
 
+	// a bare /<alias> works like /go/<alias>, it comes last so it never gets in the way of passport's own pages
+	bareAlias := func(c fiber.Ctx) error {
+		if slices.Contains(reservedAliases, strings.ToLower(c.Params("alias"))) {
+			return c.Next()
+		}
+
+		return followAlias(c, c.Params("alias"), c.Params("*"))
+	}
+	router.Get("/:alias", bareAlias)
+	router.Get("/:alias/*", bareAlias)
+
 	router.Listen(":3000", fiber.ListenConfig{
 		EnablePrefork: app.Config.Prefork,
 	})
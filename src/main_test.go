@@ -0,0 +1,62 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/juls0730/passport/src/audit"
+	"github.com/juls0730/passport/src/migrations"
+)
+
+// newTestManager returns a CategoryManager on a new database, which only has the default board
+func newTestManager(t *testing.T) *CategoryManager {
+	t.Helper()
+
+	db, err := OpenDB(filepath.Join(t.TempDir(), "passport.db"), dbOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err := NewCategoryManager(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return manager
+}
+
+func createTestCategory(t *testing.T, manager *CategoryManager, boardID int64, name string) *Category {
+	t.Helper()
+
+	category, err := manager.CreateCategory(audit.Actor{}, Category{
+		BoardID:    boardID,
+		Name:       name,
+		Sort:       SortManual,
+		Visibility: VisibilityPublic,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return category
+}
+
+func createTestLink(t *testing.T, manager *CategoryManager, link Link) *Link {
+	t.Helper()
+
+	if link.Visibility == "" {
+		link.Visibility = VisibilityPublic
+	}
+
+	created, err := manager.CreateLink(manager.db, audit.Actor{}, link)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return created
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
@@ -10,6 +11,7 @@ import (
 )
 
 type Session struct {
+	ID        int64  `json:"id"`
 	SessionID string `json:"session_id"`
 	ExpiresAt string `json:"expires_at"`
 }
@@ -24,10 +26,14 @@ func AdminMiddleware(db *sql.DB) func(c fiber.Ctx) error {
 		// Check if session exists
 		var session Session
 		err := db.QueryRow(`
-			SELECT session_id, expires_at 
+			SELECT id, session_id, expires_at 
 			FROM sessions 
 			WHERE session_id = ?
-		`, sessionToken).Scan(&session.SessionID, &session.ExpiresAt)
+		`, sessionToken).Scan(&session.ID, &session.SessionID, &session.ExpiresAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			// a stale cookie from a session that was logged out or cleaned up
+			return c.Next()
+		}
 		if err != nil {
 			slog.Error("Failed to check session", "error", err)
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -48,6 +54,8 @@ func AdminMiddleware(db *sql.DB) func(c fiber.Ctx) error {
 		}
 
 		c.Locals("IsAdmin", true)
+		// the row id identifies the session in the audit log without exposing the token
+		c.Locals("SessionID", session.ID)
 		return c.Next()
 	}
 }
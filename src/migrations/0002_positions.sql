@@ -0,0 +1,2 @@
+ALTER TABLE categories ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE links ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
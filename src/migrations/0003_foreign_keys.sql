@@ -0,0 +1,22 @@
+-- sqlite cant add a foreign key to an existing table, so links has to be rebuilt. Orphaned links are
+-- copied over as-is and reported by the integrity check on startup instead of being silently dropped.
+CREATE TABLE links_new (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	category_id INTEGER NOT NULL REFERENCES categories (id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL,
+	icon TEXT NOT NULL,
+	url TEXT NOT NULL,
+	position INTEGER NOT NULL DEFAULT 0
+);
+
+INSERT INTO links_new (id, category_id, name, description, icon, url, position)
+SELECT id, category_id, name, description, icon, url, position FROM links;
+
+DROP TABLE links;
+
+ALTER TABLE links_new RENAME TO links;
+
+CREATE INDEX links_category_id ON links (category_id);
+
+CREATE UNIQUE INDEX sessions_session_id ON sessions (session_id);
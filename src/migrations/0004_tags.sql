@@ -0,0 +1,12 @@
+CREATE TABLE tags (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE link_tags (
+	link_id INTEGER NOT NULL REFERENCES links (id) ON DELETE CASCADE,
+	tag_id INTEGER NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+	PRIMARY KEY (link_id, tag_id)
+);
+
+CREATE INDEX link_tags_tag_id ON link_tags (tag_id);
@@ -0,0 +1,29 @@
+CREATE TABLE boards (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	slug TEXT NOT NULL UNIQUE,
+	title TEXT NOT NULL,
+	is_default INTEGER NOT NULL DEFAULT 0
+);
+
+-- only one board can be served at /
+CREATE UNIQUE INDEX boards_default ON boards (is_default) WHERE is_default = 1;
+
+INSERT INTO boards (id, slug, title, is_default) VALUES (1, 'home', 'Passport', 1);
+
+-- categories are rebuilt so board_id can reference boards, existing categories end up on the default board
+CREATE TABLE categories_new (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	board_id INTEGER NOT NULL REFERENCES boards (id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	icon TEXT NOT NULL,
+	position INTEGER NOT NULL DEFAULT 0
+);
+
+INSERT INTO categories_new (id, board_id, name, icon, position)
+SELECT id, 1, name, icon, position FROM categories;
+
+DROP TABLE categories;
+
+ALTER TABLE categories_new RENAME TO categories;
+
+CREATE INDEX categories_board_id ON categories (board_id);
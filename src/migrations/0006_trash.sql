@@ -0,0 +1,3 @@
+-- deleted categories and links stay in the trash until they are restored or purged
+ALTER TABLE categories ADD COLUMN deleted_at TEXT;
+ALTER TABLE links ADD COLUMN deleted_at TEXT;
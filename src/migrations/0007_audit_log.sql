@@ -0,0 +1,15 @@
+-- session_id is the id of the sessions row, not the session token, so the log never holds credentials
+CREATE TABLE audit_log (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	session_id INTEGER,
+	ip TEXT NOT NULL,
+	action TEXT NOT NULL,
+	entity_type TEXT NOT NULL,
+	entity_id INTEGER NOT NULL,
+	before TEXT,
+	after TEXT,
+	created_at TEXT NOT NULL
+);
+
+CREATE INDEX audit_log_entity ON audit_log (entity_type, entity_id);
+CREATE INDEX audit_log_created_at ON audit_log (created_at);
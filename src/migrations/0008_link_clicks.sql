@@ -0,0 +1,11 @@
+-- one row per click through /go/:linkID, nothing about the visitor is kept
+CREATE TABLE link_clicks (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	link_id INTEGER NOT NULL REFERENCES links (id) ON DELETE CASCADE,
+	clicked_at TEXT NOT NULL
+);
+
+CREATE INDEX link_clicks_link_id ON link_clicks (link_id);
+
+-- how the links in a category are ordered, by hand or by how often they are used
+ALTER TABLE categories ADD COLUMN sort TEXT NOT NULL DEFAULT 'manual' CHECK (sort IN ('manual', 'most_used'));
@@ -0,0 +1,3 @@
+-- pinned links are shown in a row of favorites above the categories of their board, in pinned_position order
+ALTER TABLE links ADD COLUMN pinned INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE links ADD COLUMN pinned_position INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- private categories and links are only shown to logged in admins, a private category hides all of its links
+ALTER TABLE categories ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public' CHECK (visibility IN ('public', 'private'));
+ALTER TABLE links ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public' CHECK (visibility IN ('public', 'private'));
@@ -0,0 +1,10 @@
+-- the latest result of the built in link checker, one row per link
+CREATE TABLE link_health (
+	link_id INTEGER PRIMARY KEY REFERENCES links (id) ON DELETE CASCADE,
+	-- the HTTP status of the response, 0 if there was no response
+	status INTEGER NOT NULL,
+	latency_ms INTEGER NOT NULL,
+	-- why there was no response, such as a TLS or connection error
+	error TEXT NOT NULL DEFAULT '',
+	checked_at TEXT NOT NULL
+);
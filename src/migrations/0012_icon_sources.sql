@@ -0,0 +1,4 @@
+-- where the icon of a category or link declared in a dashboard file came from, so the icon is only fetched again when
+-- the declaration changes. NULL for everything made through the admin dashboard
+ALTER TABLE categories ADD COLUMN icon_source TEXT;
+ALTER TABLE links ADD COLUMN icon_source TEXT;
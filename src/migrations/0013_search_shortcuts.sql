@@ -0,0 +1,23 @@
+-- typing a link's keyword into the search bar opens the link, or searches it through its search URL when more follows,
+-- with %s in the search URL replaced by the rest of the query. Links without one have an empty keyword
+ALTER TABLE links ADD COLUMN keyword TEXT NOT NULL DEFAULT '';
+ALTER TABLE links ADD COLUMN search_url TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX links_keyword ON links (keyword) WHERE keyword != '';
+
+-- DuckDuckGo style bangs, !w anywhere in the query searches the rest of it with the bang's URL
+CREATE TABLE bangs (
+	id INTEGER PRIMARY KEY,
+	-- without the !
+	bang TEXT NOT NULL UNIQUE,
+	name TEXT NOT NULL,
+	url TEXT NOT NULL
+);
+
+INSERT INTO bangs (bang, name, url) VALUES
+	('g', 'Google', 'https://www.google.com/search?q=%s'),
+	('ddg', 'DuckDuckGo', 'https://duckduckgo.com/?q=%s'),
+	('w', 'Wikipedia', 'https://en.wikipedia.org/w/index.php?search=%s'),
+	('gh', 'GitHub', 'https://github.com/search?q=%s'),
+	('yt', 'YouTube', 'https://www.youtube.com/results?search_query=%s'),
+	('so', 'Stack Overflow', 'https://stackoverflow.com/search?q=%s');
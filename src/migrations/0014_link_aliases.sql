@@ -0,0 +1,5 @@
+-- go links, /go/grafana or just /grafana redirects to the link with the alias grafana. Links without one have an empty
+-- alias
+ALTER TABLE links ADD COLUMN alias TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX links_alias ON links (alias) WHERE alias != '';
@@ -0,0 +1,16 @@
+-- the sites the search bar can search, picked next to the search bar or with @shortcut in the search. The default one
+-- is seeded from PASSPORT_SEARCH_PROVIDER when the table is empty
+CREATE TABLE search_providers (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	-- without the @
+	shortcut TEXT NOT NULL UNIQUE,
+	-- %s is replaced by the search terms
+	url TEXT NOT NULL,
+	-- empty for providers without an icon
+	icon TEXT NOT NULL DEFAULT '',
+	icon_source TEXT,
+	is_default INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX search_providers_default ON search_providers (is_default) WHERE is_default = 1;
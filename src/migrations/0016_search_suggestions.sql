@@ -0,0 +1,3 @@
+-- where the search bar gets suggestions for what is being typed, in the OpenSearch suggestions format, with %s in place
+-- of the search terms. Empty for providers without suggestions
+ALTER TABLE search_providers ADD COLUMN suggest_url TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- purging links from the trash used to leave their tags behind
+DELETE FROM tags WHERE id NOT IN (SELECT tag_id FROM link_tags);
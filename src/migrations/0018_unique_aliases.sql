@@ -0,0 +1,16 @@
+-- aliases were only checked to be unique by passport itself, which restoring a link from the trash went around. Links
+-- in the trash give up their alias, every other link keeps its own. When two already share one, a link in a category
+-- that is not in the trash wins over one that is, and after that the oldest link wins
+WITH ranked AS (
+	SELECT links.id, ROW_NUMBER() OVER (
+		PARTITION BY links.alias
+		ORDER BY categories.deleted_at IS NOT NULL, links.id
+	) AS rank
+	FROM links
+	JOIN categories ON categories.id = links.category_id
+	WHERE links.alias != '' AND links.deleted_at IS NULL
+)
+UPDATE links SET alias = '' WHERE id IN (SELECT id FROM ranked WHERE rank > 1);
+
+DROP INDEX links_alias;
+CREATE UNIQUE INDEX links_alias ON links (alias) WHERE alias != '' AND deleted_at IS NULL;
@@ -0,0 +1,15 @@
+-- keywords get the same treatment as aliases did in 0018, links in the trash give up their keyword and every other link
+-- keeps its own
+WITH ranked AS (
+	SELECT links.id, ROW_NUMBER() OVER (
+		PARTITION BY links.keyword
+		ORDER BY categories.deleted_at IS NOT NULL, links.id
+	) AS rank
+	FROM links
+	JOIN categories ON categories.id = links.category_id
+	WHERE links.keyword != '' AND links.deleted_at IS NULL
+)
+UPDATE links SET keyword = '' WHERE id IN (SELECT id FROM ranked WHERE rank > 1);
+
+DROP INDEX links_keyword;
+CREATE UNIQUE INDEX links_keyword ON links (keyword) WHERE keyword != '' AND deleted_at IS NULL;
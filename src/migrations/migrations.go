@@ -0,0 +1,327 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//go:embed *.sql
+var migrationFiles embed.FS
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.sql$`)
+
+var ErrDatabaseTooNew = errors.New("database schema is newer than this version of passport supports")
+
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt string
+}
+
+// All returns every embedded migration sorted by version
+func All() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	for _, entry := range entries {
+		matches := migrationFileName.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			return nil, fmt.Errorf("migration %s does not match the NNNN_name.sql format", entry.Name())
+		}
+
+		version, err := strconv.Atoi(matches[1])
+		if err != nil {
+			return nil, err
+		}
+
+		contents, err := migrationFiles.ReadFile(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, Migration{
+			Version: version,
+			Name:    matches[2],
+			SQL:     string(contents),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	// versions have to be contiguous, otherwise a missing file would silently be skipped
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			return nil, fmt.Errorf("migration %04d_%s is out of sequence, expected version %d", migration.Version, migration.Name, i+1)
+		}
+	}
+
+	return migrations, nil
+}
+
+// Latest returns the newest schema version this binary knows about
+func Latest() (int, error) {
+	migrations, err := All()
+	if err != nil {
+		return 0, err
+	}
+
+	return len(migrations), nil
+}
+
+// querier is what reading the schema version needs, so it works both inside and outside of a transaction
+type querier interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// CurrentVersion returns the schema version of the database, 0 if it is empty. It only reads from the database, a
+// database created before migrations existed gets the version worked out from its tables
+func CurrentVersion(db *sql.DB) (int, error) {
+	tracked, err := hasVersionTable(db)
+	if err != nil {
+		return 0, err
+	}
+
+	if !tracked {
+		return detectLegacyVersion(db)
+	}
+
+	var version int
+	err = db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&version)
+	return version, err
+}
+
+// GetStatus returns every known migration and whether it has been applied to the database. Like CurrentVersion it only
+// reads from the database, migrations a database created before migrations existed already has are reported as applied
+// without a time
+func GetStatus(db *sql.DB) ([]Status, error) {
+	migrations, err := All()
+	if err != nil {
+		return nil, err
+	}
+
+	tracked, err := hasVersionTable(db)
+	if err != nil {
+		return nil, err
+	}
+
+	appliedAt := make(map[int]string)
+	if tracked {
+		rows, err := db.Query(`SELECT version, applied_at FROM schema_version`)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var version int
+			var at string
+			if err := rows.Scan(&version, &at); err != nil {
+				return nil, err
+			}
+			appliedAt[version] = at
+		}
+
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	} else {
+		legacyVersion, err := detectLegacyVersion(db)
+		if err != nil {
+			return nil, err
+		}
+
+		for version := 1; version <= legacyVersion; version++ {
+			appliedAt[version] = ""
+		}
+	}
+
+	statuses := make([]Status, len(migrations))
+	for i, migration := range migrations {
+		at, ok := appliedAt[migration.Version]
+		statuses[i] = Status{
+			Migration: migration,
+			Applied:   ok,
+			AppliedAt: at,
+		}
+	}
+
+	return statuses, nil
+}
+
+// Up applies every pending migration, each in its own transaction, and returns the ones that were applied.
+// It refuses to touch a database that was migrated by a newer version of passport.
+func Up(db *sql.DB) ([]Migration, error) {
+	migrations, err := All()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ensureVersionTable(db); err != nil {
+		return nil, err
+	}
+
+	current, err := CurrentVersion(db)
+	if err != nil {
+		return nil, err
+	}
+
+	if current > len(migrations) {
+		return nil, fmt.Errorf("%w (database is at version %d, latest known version is %d)", ErrDatabaseTooNew, current, len(migrations))
+	}
+
+	var applied []Migration
+	for _, migration := range migrations[current:] {
+		if err := apply(db, migration); err != nil {
+			return applied, fmt.Errorf("failed to apply migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		applied = append(applied, migration)
+	}
+
+	return applied, nil
+}
+
+func apply(db *sql.DB, migration Migration) error {
+	ctx := context.Background()
+
+	// foreign keys have to be turned off while tables are rebuilt, and that can only be done outside of a
+	// transaction, on the same connection the migration runs on
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return err
+	}
+	defer func() {
+		if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = ON"); err != nil {
+			// dont hand a connection without foreign keys back to the pool
+			conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+	}()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(migration.SQL); err != nil {
+		return err
+	}
+
+	if err := recordVersion(tx, migration); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func recordVersion(tx *sql.Tx, migration Migration) error {
+	_, err := tx.Exec(`
+		INSERT INTO schema_version (version, name, applied_at)
+		VALUES (?, ?, ?)
+	`, migration.Version, migration.Name, time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+// ensureVersionTable creates the schema_version table. Databases created before migrations existed already
+// have tables, so their version is worked out from what is there and recorded as if those migrations ran.
+func ensureVersionTable(db *sql.DB) error {
+	exists, err := hasVersionTable(db)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		CREATE TABLE schema_version (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TEXT NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	legacyVersion, err := detectLegacyVersion(tx)
+	if err != nil {
+		return err
+	}
+
+	migrations, err := All()
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range migrations[:legacyVersion] {
+		if err := recordVersion(tx, migration); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func hasVersionTable(q querier) (bool, error) {
+	var exists bool
+	err := q.QueryRow(`SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'schema_version')`).Scan(&exists)
+	return exists, err
+}
+
+func detectLegacyVersion(q querier) (int, error) {
+	var hasCategories bool
+	err := q.QueryRow(`SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'categories')`).Scan(&hasCategories)
+	if err != nil {
+		return 0, err
+	}
+
+	if !hasCategories {
+		return 0, nil
+	}
+
+	// position was added without a migration shortly before the migration runner existed
+	var hasPosition bool
+	err = q.QueryRow(`SELECT EXISTS(SELECT 1 FROM pragma_table_info('categories') WHERE name = 'position')`).Scan(&hasPosition)
+	if err != nil {
+		return 0, err
+	}
+
+	if hasPosition {
+		return 2, nil
+	}
+
+	return 1, nil
+}
@@ -0,0 +1,205 @@
+package migrations
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "passport.db")+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func hasTable(t *testing.T, db *sql.DB, name string) bool {
+	t.Helper()
+
+	var exists bool
+	err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = ?)`, name).Scan(&exists)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return exists
+}
+
+func TestAll(t *testing.T) {
+	migrations, err := All()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(migrations) == 0 {
+		t.Fatal("no migrations are embedded")
+	}
+
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			t.Errorf("migration %d has version %d", i, migration.Version)
+		}
+
+		if migration.Name == "" || migration.SQL == "" {
+			t.Errorf("migration %d has no name or SQL", migration.Version)
+		}
+	}
+
+	latest, err := Latest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if latest != len(migrations) {
+		t.Errorf("Latest() = %d, want %d", latest, len(migrations))
+	}
+}
+
+// legacy databases were created by running the first migrations by hand, before schema_version existed
+var legacyDatabases = []struct {
+	name    string
+	applied int
+}{
+	{"empty", 0},
+	{"before positions", 1},
+	{"with positions", 2},
+}
+
+func createLegacyDatabase(t *testing.T, applied int) *sql.DB {
+	t.Helper()
+
+	migrations, err := All()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := openTestDB(t)
+	for _, migration := range migrations[:applied] {
+		if _, err := db.Exec(migration.SQL); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return db
+}
+
+func TestStatusOfLegacyDatabase(t *testing.T) {
+	for _, test := range legacyDatabases {
+		t.Run(test.name, func(t *testing.T) {
+			db := createLegacyDatabase(t, test.applied)
+
+			current, err := CurrentVersion(db)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if current != test.applied {
+				t.Errorf("CurrentVersion() = %d, want %d", current, test.applied)
+			}
+
+			statuses, err := GetStatus(db)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, status := range statuses {
+				want := status.Version <= test.applied
+				if status.Applied != want || status.AppliedAt != "" {
+					t.Errorf("migration %d: applied %v at %q, want applied %v without a time", status.Version, status.Applied,
+						status.AppliedAt, want)
+				}
+			}
+
+			// looking is not allowed to change anything
+			if hasTable(t, db, "schema_version") {
+				t.Error("schema_version was created")
+			}
+		})
+	}
+}
+
+func TestUpFromLegacyDatabase(t *testing.T) {
+	latest, err := Latest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range legacyDatabases {
+		t.Run(test.name, func(t *testing.T) {
+			db := createLegacyDatabase(t, test.applied)
+
+			applied, err := Up(db)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(applied) != latest-test.applied {
+				t.Fatalf("applied %d migrations, want %d", len(applied), latest-test.applied)
+			}
+
+			for i, migration := range applied {
+				if migration.Version != test.applied+i+1 {
+					t.Errorf("migration %d applied as number %d", migration.Version, i+1)
+				}
+			}
+
+			current, err := CurrentVersion(db)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if current != latest {
+				t.Errorf("CurrentVersion() = %d, want %d", current, latest)
+			}
+
+			statuses, err := GetStatus(db)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the migrations the database already had are recorded too
+			for _, status := range statuses {
+				if !status.Applied || status.AppliedAt == "" {
+					t.Errorf("migration %d is not recorded as applied", status.Version)
+				}
+			}
+
+			again, err := Up(db)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(again) != 0 {
+				t.Errorf("applied %d migrations a second time", len(again))
+			}
+		})
+	}
+}
+
+func TestUpRefusesNewerDatabase(t *testing.T) {
+	db := openTestDB(t)
+	if _, err := Up(db); err != nil {
+		t.Fatal(err)
+	}
+
+	latest, err := Latest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec(`INSERT INTO schema_version (version, name, applied_at) VALUES (?, 'future', '')`, latest+1); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Up(db); !errors.Is(err, ErrDatabaseTooNew) {
+		t.Errorf("Up() = %v, want %v", err, ErrDatabaseTooNew)
+	}
+}
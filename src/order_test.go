@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/juls0730/passport/src/audit"
+)
+
+func TestReorderCategories(t *testing.T) {
+	tests := []struct {
+		name string
+		// indexes into the categories on the default board, -1 for the one on the other board and -2 for one that
+		// does not exist
+		order []int
+		err   error
+	}{
+		{name: "same order", order: []int{0, 1, 2}},
+		{name: "reversed", order: []int{2, 1, 0}},
+		{name: "moved to the front", order: []int{2, 0, 1}},
+		{name: "missing one", order: []int{0, 1}, err: ErrInvalidOrder},
+		{name: "duplicated", order: []int{0, 1, 1}, err: ErrInvalidOrder},
+		{name: "one too many", order: []int{0, 1, 2, -2}, err: ErrInvalidOrder},
+		{name: "from another board", order: []int{0, 1, -1}, err: ErrInvalidOrder},
+		{name: "in the trash", order: []int{0, 1, 2, 3}, err: ErrInvalidOrder},
+		{name: "empty", order: []int{}, err: ErrInvalidOrder},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			manager := newTestManager(t)
+			board := manager.GetDefaultBoard()
+
+			other, err := manager.CreateBoard(audit.Actor{}, Board{Slug: "other", Title: "Other"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			elsewhere := createTestCategory(t, manager, other.ID, "Elsewhere")
+
+			var categories []int64
+			for _, name := range []string{"Dev", "News", "Media", "Trashed"} {
+				categories = append(categories, createTestCategory(t, manager, board.ID, name).ID)
+			}
+
+			if err := manager.DeleteCategory(audit.Actor{}, categories[3]); err != nil {
+				t.Fatal(err)
+			}
+
+			var ids []int64
+			for _, index := range test.order {
+				switch index {
+				case -1:
+					ids = append(ids, elsewhere.ID)
+				case -2:
+					ids = append(ids, 1000)
+				default:
+					ids = append(ids, categories[index])
+				}
+			}
+
+			err = manager.ReorderCategories(audit.Actor{}, board.ID, ids)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("ReorderCategories() = %v, want %v", err, test.err)
+			}
+
+			// a rejected order leaves the categories as they were
+			want := ids
+			if test.err != nil {
+				want = categories[:3]
+			}
+
+			var got []int64
+			for _, category := range manager.GetCategories(board.ID) {
+				got = append(got, category.ID)
+			}
+
+			if !slices.Equal(got, want) {
+				t.Errorf("categories are in the order %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestReorderLinks(t *testing.T) {
+	tests := []struct {
+		name  string
+		order []int
+		err   error
+	}{
+		{name: "same order", order: []int{0, 1, 2}},
+		{name: "reversed", order: []int{2, 1, 0}},
+		{name: "missing one", order: []int{2, 1}, err: ErrInvalidOrder},
+		{name: "duplicated", order: []int{2, 2, 0}, err: ErrInvalidOrder},
+		{name: "from another category", order: []int{0, 1, 2, 3}, err: ErrInvalidOrder},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			manager := newTestManager(t)
+			board := manager.GetDefaultBoard()
+			category := createTestCategory(t, manager, board.ID, "Dev")
+			otherCategory := createTestCategory(t, manager, board.ID, "News")
+
+			var links []int64
+			for _, name := range []string{"Go", "Rust", "Zig"} {
+				links = append(links, createTestLink(t, manager, Link{CategoryID: category.ID, Name: name, URL: "https://" + name + ".example"}).ID)
+			}
+			links = append(links, createTestLink(t, manager, Link{CategoryID: otherCategory.ID, Name: "Elsewhere", URL: "https://elsewhere.example"}).ID)
+
+			var ids []int64
+			for _, index := range test.order {
+				ids = append(ids, links[index])
+			}
+
+			err := manager.ReorderLinks(audit.Actor{}, category.ID, ids)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("ReorderLinks() = %v, want %v", err, test.err)
+			}
+
+			want := ids
+			if test.err != nil {
+				want = links[:3]
+			}
+
+			var got []int64
+			for _, link := range manager.GetLinks(category.ID) {
+				got = append(got, link.ID)
+			}
+
+			if !slices.Equal(got, want) {
+				t.Errorf("links are in the order %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ let modalContainer = document.getElementById("modal-container");
 let modal = modalContainer.querySelector("div");
 let pageElement = document.getElementById("blur-target");
 let iconUploadInput = document.getElementById("icon-upload");
+let boardBar = document.getElementById("board-bar");
 let targetCategoryID = null;
 let activeModal = null;
 
@@ -20,7 +21,7 @@ document.addEventListener("DOMContentLoaded", () => {
 
 /**
  * Adds an event listener for the given from to error check after the first submit
- * @param {"category" | "link"} form - The form to initialize
+ * @param {"category" | "link" | "board" | "board-edit"} form - The form to initialize
  * @returns {void}
  */
 function addErrorListener(form) {
@@ -64,6 +65,11 @@ function cloneEditActions(primaryActions) {
         actionButton.setAttribute("aria-label", actionButtonObj.label);
     }
 
+    // drop the buttons that were not given an action
+    editActions
+        .querySelectorAll(`div:first-child button:nth-child(n + ${i + 1})`)
+        .forEach((button) => button.remove());
+
     return editActions;
 }
 
@@ -114,6 +120,12 @@ document
         event.preventDefault();
         let data = new FormData(event.target);
 
+        // without an icon the server fetches one from the site
+        let icon = data.get("icon");
+        if (!icon || icon.size === 0) {
+            data.delete("icon");
+        }
+
         const submitButton = event.target.querySelector("button");
         let originalContents = submitButton.innerHTML;
 
@@ -144,7 +156,9 @@ document
                     "div:first-child img"
                 );
 
-                newLinkImgElement.src = await processFile(data.get("icon"));
+                newLinkImgElement.src = data.has("icon")
+                    ? await processFile(data.get("icon"))
+                    : json.link.icon;
                 newLinkImgElement.alt = data.get("name");
 
                 newLinkCard.querySelector("h3").textContent = data.get("name");
@@ -152,6 +166,16 @@ document
                     data.get("description");
 
                 newLinkCard.setAttribute("id", `${json.link.id}_link`);
+                newLinkCard.dataset.url = json.link.url;
+                newLinkCard.dataset.tags = json.link.tags.join(",");
+                newLinkCard.dataset.visibility = json.link.visibility;
+                newLinkCard.dataset.keyword = json.link.keyword;
+                newLinkCard.dataset.searchUrl = json.link.search_url;
+                newLinkCard.dataset.alias = json.link.alias;
+                renderTagList(
+                    newLinkCard.querySelector("div:nth-child(2)"),
+                    json.link.tags
+                );
 
                 let editActions = cloneEditActions([
                     {
@@ -162,6 +186,14 @@ document
                         clickAction: "deleteLink(this)",
                         label: "Delete link",
                     },
+                    {
+                        clickAction: "togglePin(this)",
+                        label: "Pin link",
+                    },
+                    {
+                        clickAction: "refetchIcon(this)",
+                        label: "Refetch icon",
+                    },
                 ]);
 
                 editActions.classList.add("absolute", "right-1", "top-1");
@@ -187,12 +219,51 @@ document
             });
     });
 
+// fill in the name and description from the page the URL points to, leaving anything already typed alone
+document.getElementById("linkURL").addEventListener("change", async (event) => {
+    let urlInput = event.target;
+    let nameInput = document.getElementById("linkName");
+    let descInput = document.getElementById("linkDesc");
+
+    if (!urlInput.checkValidity() || (nameInput.value && descInput.value)) {
+        return;
+    }
+
+    let url = urlInput.value;
+
+    await fetch(`/api/metadata?url=${encodeURIComponent(url)}`)
+        .then(async (res) => {
+            let json = await res.json();
+
+            if (!res.ok) {
+                throw new Error(json.message);
+            }
+
+            // the URL was changed again while this one was being fetched
+            if (urlInput.value !== url) {
+                return;
+            }
+
+            if (!nameInput.value) {
+                nameInput.value = json.title;
+            }
+
+            if (!descInput.value) {
+                descInput.value = json.description;
+            }
+        })
+        .catch((err) => {
+            console.error(err);
+        });
+});
+
 addErrorListener("category");
 document
     .getElementById("category-form")
     .addEventListener("submit", async (event) => {
         event.preventDefault();
         let data = new FormData(event.target);
+        data.append("board_id", boardBar.dataset.boardId);
 
         const submitButton = event.target.querySelector("button");
         const originalContents = submitButton.innerHTML;
@@ -224,6 +295,8 @@ document
                 );
                 categoryHeader.querySelector("h2").textContent =
                     json.category.name;
+                categoryHeader.dataset.sort = json.category.sort;
+                categoryHeader.dataset.visibility = json.category.visibility;
 
                 let editActions = cloneEditActions([
                     {
@@ -284,6 +357,131 @@ document
             });
     });
 
+/**
+ * @typedef {Object} Board
+ * @property {number} id - The ID of the board
+ * @property {string} slug - The slug the board is served at
+ * @property {string} title - The title of the board
+ * @property {boolean} is_default - Whether the board is served at /
+ */
+
+/**
+ * Gets the admin page of a board
+ * @param {Board} board The board
+ * @returns {string} The path of the admin page
+ */
+function boardAdminPath(board) {
+    return board.is_default ? "/admin" : `/admin/b/${board.slug}`;
+}
+
+/**
+ * Submits one of the board forms, and goes to the board once it has been saved
+ * @param {SubmitEvent} event The submit event of the form
+ * @param {string} url The endpoint to send the form to
+ * @param {"POST" | "PATCH"} method The method to send the form with
+ * @param {string} messageID The ID of the element to show errors in
+ */
+async function submitBoardForm(event, url, method, messageID) {
+    event.preventDefault();
+    let data = new FormData(event.target);
+
+    const submitButton = event.target.querySelector("button");
+    const originalContents = submitButton.innerHTML;
+
+    submitButton.disabled = true;
+    submitButton.innerHTML = `${loadingSpinner.innerHTML}<span>Saving board...</span>`;
+
+    await fetch(url, {
+        method: method,
+        body: data,
+    })
+        .then(async (res) => {
+            let json = await res.json();
+
+            if (!res.ok) {
+                throw new Error(json.message);
+            }
+
+            // the board list and the urls may have changed, so just load the board again
+            window.location.href = boardAdminPath(json.board);
+        })
+        .catch((err) => {
+            document.getElementById(messageID).innerText = err.message;
+        })
+        .finally(() => {
+            submitButton.disabled = false;
+            submitButton.innerHTML = originalContents;
+        });
+}
+
+addErrorListener("board");
+document.getElementById("board-form").addEventListener("submit", (event) => {
+    submitBoardForm(event, "/api/board", "POST", "board-message");
+});
+
+addErrorListener("board-edit");
+document
+    .getElementById("board-edit-form")
+    .addEventListener("submit", (event) => {
+        submitBoardForm(
+            event,
+            `/api/board/${boardBar.dataset.boardId}`,
+            "PATCH",
+            "board-edit-message"
+        );
+    });
+
+/**
+ * Opens the edit board modal filled in with the current board
+ */
+function editBoard() {
+    if (currentlyEditing.type !== undefined) {
+        cancelEdit();
+    }
+
+    openModal("board-edit");
+
+    let isDefault = boardBar.dataset.boardDefault !== undefined;
+    document.getElementById("editBoardTitle").value =
+        boardBar.dataset.boardTitle;
+    document.getElementById("editBoardSlug").value = boardBar.dataset.boardSlug;
+
+    // the default board can only be changed by making another board the default
+    let defaultInput = document.getElementById("editBoardDefault");
+    defaultInput.checked = isDefault;
+    defaultInput.disabled = isDefault;
+}
+
+async function confirmDeleteBoard(ev) {
+    const originalContents = ev.target.innerHTML;
+    const deleteButton = ev.target;
+    const cancelButton = deleteButton.nextElementSibling;
+    deleteButton.disabled = true;
+    cancelButton.disabled = true;
+    deleteButton.innerHTML = `${loadingSpinner.innerHTML}<span>Deleting board...</span>`;
+
+    await fetch(`/api/board/${boardBar.dataset.boardId}`, {
+        method: "DELETE",
+    })
+        .then(async (res) => {
+            if (!res.ok) {
+                let json = await res.json();
+                throw new Error(json.message);
+            }
+
+            window.location.href = "/admin";
+        })
+        .catch((err) => {
+            document.getElementById(`board-delete-message`).innerText =
+                err.message;
+        })
+        .finally(() => {
+            deleteButton.disabled = false;
+            cancelButton.disabled = false;
+            deleteButton.innerHTML = originalContents;
+        });
+}
+
 // when the background is clicked, close the modal
 modalContainer.addEventListener("click", (event) => {
     if (event.target === modalContainer) {
@@ -400,6 +598,13 @@ function closeModal() {
  * @property {string | undefined} categoryID - The ID of the category we are currently editing, or that the link belongs to
  * @property {string | undefined} originalText - The original text of the currently editing element
  * @property {string | undefined} originalDescription - The original description of the currently editing element
+ * @property {string | undefined} originalURL - The original URL of the currently editing link
+ * @property {string | undefined} originalTags - The original comma separated tags of the currently editing link
+ * @property {string | undefined} originalKeyword - The original search keyword of the currently editing link
+ * @property {string | undefined} originalSearchURL - The original search URL of the currently editing link
+ * @property {string | undefined} originalAlias - The original alias of the currently editing link
+ * @property {string | undefined} originalSort - The original sort mode of the currently editing category
+ * @property {string | undefined} originalVisibility - The original visibility of the currently editing element
  * @property {string | undefined} icon - The original icon of the currently editing element
  * @property {Function | undefined} cleanup - The cleanup function for the currently editing element
  */
@@ -497,6 +702,7 @@ function editLink(target) {
     let linkImg = linkEl.querySelector("div:first-child img");
     let linkName = linkEl.querySelector("div:nth-child(2) h3");
     let linkDesc = linkEl.querySelector("div:nth-child(2) p");
+    let linkText = linkEl.querySelector("div:nth-child(2)");
     let editActions = linkEl.querySelector("div:nth-child(3)");
 
     currentlyEditing = {
@@ -505,6 +711,12 @@ function editLink(target) {
         categoryID: categoryID,
         originalText: linkName.textContent,
         originalDescription: linkDesc.textContent,
+        originalURL: linkEl.dataset.url,
+        originalTags: linkEl.dataset.tags,
+        originalKeyword: linkEl.dataset.keyword,
+        originalSearchURL: linkEl.dataset.searchUrl,
+        originalAlias: linkEl.dataset.alias,
+        originalVisibility: linkEl.dataset.visibility,
         icon: linkImg.src,
     };
 
@@ -515,6 +727,9 @@ function editLink(target) {
     iconUploadInput.accept = "image/jpeg,image/png,image/webp,image/svg+xml";
     targetedImageElement = linkImg;
 
+    // text selection inside of the textareas breaks if the card is draggable
+    linkEl.draggable = false;
+
     teleportElement(selectIconButton, linkImg.parentElement);
     teleportElement(confirmActions, editActions);
 
@@ -525,6 +740,42 @@ function editLink(target) {
             { targetEl: linkName, fill: false },
             { targetEl: linkDesc },
         ]);
+        appendEditInput(linkText, {
+            name: "url",
+            type: "url",
+            value: currentlyEditing.originalURL,
+            placeholder: "Enter URL...",
+        });
+        appendEditInput(linkText, {
+            name: "tags",
+            type: "text",
+            value: currentlyEditing.originalTags,
+            placeholder: "Enter tags, comma separated...",
+        });
+        appendEditInput(linkText, {
+            name: "keyword",
+            type: "text",
+            value: currentlyEditing.originalKeyword,
+            placeholder: "Enter search keyword...",
+        });
+        appendEditInput(linkText, {
+            name: "search_url",
+            type: "text",
+            value: currentlyEditing.originalSearchURL,
+            placeholder: "Enter search URL, %s for the search terms...",
+        });
+        appendEditInput(linkText, {
+            name: "alias",
+            type: "text",
+            value: currentlyEditing.originalAlias,
+            placeholder: "Enter alias...",
+        });
+        appendEditSelect(linkText, {
+            name: "visibility",
+            value: currentlyEditing.originalVisibility,
+            label: "Visible to",
+            options: visibilityOptions,
+        });
         // by adding a delay, we dont block the UI
         setTimeout(() => {
             linkEl.querySelector("textarea").focus();
@@ -540,10 +791,17 @@ async function confirmLinkEdit() {
     let linkEl = document.getElementById(`${currentlyEditing.linkID}_link`);
     let linkNameInput = linkEl.querySelector("textarea");
     let linkDescInput = linkNameInput.nextElementSibling;
+    let linkURLInput = linkEl.querySelector("input[name=url]");
+    let linkTagsInput = linkEl.querySelector("input[name=tags]");
+    let linkKeywordInput = linkEl.querySelector("input[name=keyword]");
+    let linkSearchURLInput = linkEl.querySelector("input[name=search_url]");
+    let linkAliasInput = linkEl.querySelector("input[name=alias]");
+    let linkVisibilitySelect = linkEl.querySelector("select[name=visibility]");
 
     linkNameInput.value = linkNameInput.value.trim();
     linkDescInput.value = linkDescInput.value.trim();
-    if (linkNameInput.value === "") {
+    linkURLInput.value = linkURLInput.value.trim();
+    if (linkNameInput.value === "" || !linkURLInput.reportValidity()) {
         return;
     }
 
@@ -556,6 +814,30 @@ async function confirmLinkEdit() {
         formData.append("description", linkDescInput.value);
     }
 
+    if (linkURLInput.value !== currentlyEditing.originalURL) {
+        formData.append("url", linkURLInput.value);
+    }
+
+    if (linkTagsInput.value.trim() !== currentlyEditing.originalTags) {
+        formData.append("tags", linkTagsInput.value);
+    }
+
+    if (linkKeywordInput.value.trim() !== currentlyEditing.originalKeyword) {
+        formData.append("keyword", linkKeywordInput.value);
+    }
+
+    if (linkSearchURLInput.value.trim() !== currentlyEditing.originalSearchURL) {
+        formData.append("search_url", linkSearchURLInput.value.trim());
+    }
+
+    if (linkAliasInput.value.trim() !== currentlyEditing.originalAlias) {
+        formData.append("alias", linkAliasInput.value);
+    }
+
+    if (linkVisibilitySelect.value !== currentlyEditing.originalVisibility) {
+        formData.append("visibility", linkVisibilitySelect.value);
+    }
+
     if (iconUploadInput.files.length > 0) {
         formData.append("icon", iconUploadInput.files[0]);
     }
@@ -564,6 +846,12 @@ async function confirmLinkEdit() {
     if (
         formData.get("name") === null &&
         formData.get("description") === null &&
+        formData.get("url") === null &&
+        formData.get("tags") === null &&
+        formData.get("keyword") === null &&
+        formData.get("search_url") === null &&
+        formData.get("alias") === null &&
+        formData.get("visibility") === null &&
         formData.get("icon") === null
     ) {
         cancelEdit();
@@ -576,22 +864,76 @@ async function confirmLinkEdit() {
             method: "PATCH",
             body: formData,
         }
-    ).then(() => {
+    ).then(async (res) => {
+        if (!res.ok) {
+            let json = await res.json();
+            throw new Error(json.message);
+        }
+
         iconUploadInput.value = "";
+        let json = await res.json();
+
+        if (formData.get("tags") !== null) {
+            // the server cleans up the tags, so show what they ended up as
+            currentlyEditing.originalTags = json.tags.join(",");
+            renderTagList(linkEl.querySelector("div:nth-child(2)"), json.tags);
+        }
+
+        if (formData.get("keyword") !== null) {
+            currentlyEditing.originalKeyword = json.keyword;
+        }
+
+        if (formData.get("search_url") !== null) {
+            currentlyEditing.originalSearchURL = formData.get("search_url");
+        }
+
+        if (formData.get("alias") !== null) {
+            currentlyEditing.originalAlias = json.alias;
+        }
+
+        // keep the favorites row in sync with the card
+        let pinnedEl = document.getElementById(
+            `${currentlyEditing.linkID}_pinned`
+        );
+        if (pinnedEl !== null) {
+            pinnedEl.querySelector("span").textContent = linkNameInput.value;
+            pinnedEl.querySelector("img").src = linkEl.querySelector(
+                "div:first-child img"
+            ).src;
+        }
 
+        currentlyEditing.originalVisibility = linkVisibilitySelect.value;
         currentlyEditing.icon = undefined;
-        cancelLinkEdit(linkNameInput.value, linkDescInput.value);
+        cancelLinkEdit(
+            linkNameInput.value,
+            linkDescInput.value,
+            linkURLInput.value
+        );
         currentlyEditing = {};
     });
 }
 
 function cancelLinkEdit(
     text = currentlyEditing.originalText,
-    description = currentlyEditing.originalDescription
+    description = currentlyEditing.originalDescription,
+    url = currentlyEditing.originalURL
 ) {
     let linkEl = document.getElementById(`${currentlyEditing.linkID}_link`);
     let linkInput = linkEl.querySelector("textarea");
     let linkTextarea = linkInput.nextElementSibling;
+
+    linkEl.querySelector("input[name=url]").remove();
+    linkEl.querySelector("input[name=tags]").remove();
+    linkEl.querySelector("input[name=keyword]").remove();
+    linkEl.querySelector("input[name=search_url]").remove();
+    linkEl.querySelector("input[name=alias]").remove();
+    linkEl.querySelector("select[name=visibility]").remove();
+    linkEl.dataset.url = url;
+    linkEl.dataset.tags = currentlyEditing.originalTags;
+    linkEl.dataset.keyword = currentlyEditing.originalKeyword;
+    linkEl.dataset.searchUrl = currentlyEditing.originalSearchURL;
+    linkEl.dataset.alias = currentlyEditing.originalAlias;
+    linkEl.dataset.visibility = currentlyEditing.originalVisibility;
     let linkImg = linkEl.querySelector("div:first-child img");
     let editActions = linkEl.querySelector("div:nth-child(3)");
 
@@ -600,6 +942,7 @@ function cancelLinkEdit(
     }
 
     editActions.querySelector("div:first-child").style.display = "";
+    linkEl.draggable = true;
 
     // teleport the teleported elements back to the body for literally safe keeping
     unteleportElement(selectIconButton);
@@ -667,6 +1010,9 @@ async function confirmDeleteLink(ev) {
                 `${currentlyEditing.linkID}_link`
             );
             linkEl.remove();
+            document
+                .getElementById(`${currentlyEditing.linkID}_pinned`)
+                ?.remove();
 
             closeModal();
             currentlyEditing = {};
@@ -706,6 +1052,8 @@ function editCategory(target) {
         type: "category",
         categoryID: categoryID,
         originalText: categoryName.textContent,
+        originalSort: categoryEl.dataset.sort,
+        originalVisibility: categoryEl.dataset.visibility,
         icon: categoryIcon.src,
     };
 
@@ -716,6 +1064,8 @@ function editCategory(target) {
     iconUploadInput.accept = "image/svg+xml";
     targetedImageElement = categoryIcon;
 
+    categoryEl.draggable = false;
+
     teleportElement(selectIconButton, categoryIcon.parentElement);
     teleportElement(confirmActions, editActions);
 
@@ -725,6 +1075,21 @@ function editCategory(target) {
         currentlyEditing.cleanup = replaceWithResizableTextarea([
             { targetEl: categoryName, fill: false },
         ]);
+        appendEditSelect(categoryEl, {
+            name: "sort",
+            value: currentlyEditing.originalSort,
+            label: "Sort links by",
+            options: [
+            ["manual", "Manual order"],
+            ["most_used", "Most used"],
+        ],
+        });
+        appendEditSelect(categoryEl, {
+            name: "visibility",
+            value: currentlyEditing.originalVisibility,
+            label: "Visible to",
+            options: visibilityOptions,
+        });
         // by adding a delay, we dont block the UI
         setTimeout(() => {
             categoryEl.querySelector("textarea").focus();
@@ -737,6 +1102,10 @@ async function confirmCategoryEdit() {
         `${currentlyEditing.categoryID}_category`
     );
     let categoryInput = categoryEl.querySelector("textarea");
+    let categorySortSelect = categoryEl.querySelector("select[name=sort]");
+    let categoryVisibilitySelect = categoryEl.querySelector(
+        "select[name=visibility]"
+    );
 
     if (categoryInput.value === "") {
         return;
@@ -753,8 +1122,23 @@ async function confirmCategoryEdit() {
         formData.append("icon", iconUploadInput.files[0]);
     }
 
+    if (categorySortSelect.value !== currentlyEditing.originalSort) {
+        formData.append("sort", categorySortSelect.value);
+    }
+
+    if (
+        categoryVisibilitySelect.value !== currentlyEditing.originalVisibility
+    ) {
+        formData.append("visibility", categoryVisibilitySelect.value);
+    }
+
     // nothing to update
-    if (formData.get("name") === null && formData.get("icon") === null) {
+    if (
+        formData.get("name") === null &&
+        formData.get("icon") === null &&
+        formData.get("sort") === null &&
+        formData.get("visibility") === null
+    ) {
         cancelEdit();
         return;
     }
@@ -766,6 +1150,9 @@ async function confirmCategoryEdit() {
         iconUploadInput.value = "";
 
         currentlyEditing.icon = undefined;
+        // the new order of the links shows up the next time the page is loaded
+        currentlyEditing.originalSort = categorySortSelect.value;
+        currentlyEditing.originalVisibility = categoryVisibilitySelect.value;
 
         cancelCategoryEdit(categoryInput.value);
 
@@ -789,7 +1176,13 @@ function cancelCategoryEdit(text = currentlyEditing.originalText) {
     unteleportElement(selectIconButton);
     unteleportElement(confirmActions);
 
+    categoryEl.querySelector("select[name=sort]").remove();
+    categoryEl.querySelector("select[name=visibility]").remove();
+    categoryEl.dataset.sort = currentlyEditing.originalSort;
+    categoryEl.dataset.visibility = currentlyEditing.originalVisibility;
+
     editActions.querySelector("div:first-child").style.display = "";
+    categoryEl.draggable = true;
 
     restoreElementFromInput(categoryInput, text);
 
@@ -822,7 +1215,7 @@ function deleteCategory(target) {
     openModal("category-delete");
 }
 
-async function confirmDeleteCategory() {
+async function confirmDeleteCategory(ev) {
     const originalContents = ev.target.innerHTML;
     const deleteButton = ev.target;
     const cancelButton = deleteButton.nextElementSibling;
@@ -844,6 +1237,9 @@ async function confirmDeleteCategory() {
             );
             // get the next element and remove it (its the link grid)
             let nextEl = categoryEl.nextElementSibling;
+            getLinkOrder(nextEl).forEach((id) => {
+                document.getElementById(`${id}_pinned`)?.remove();
+            });
             nextEl.remove();
             categoryEl.remove();
 
@@ -861,6 +1257,382 @@ async function confirmDeleteCategory() {
         });
 }
 
+/**
+ * The element currently being dragged, either a link card or a category header
+ * @type {HTMLElement | null}
+ */
+let draggedElement = null;
+/** @type {number[]} */
+let orderBeforeDrag = [];
+/**
+ * The link grid a link card was dragged out of
+ * @type {HTMLElement | null}
+ */
+let gridBeforeDrag = null;
+
+/**
+ * Gets the IDs of the link cards in the given link grid, in the order they are displayed
+ * @param {HTMLElement} linkGrid The link grid to read
+ * @returns {number[]} The link IDs
+ */
+function getLinkOrder(linkGrid) {
+    return Array.from(linkGrid.querySelectorAll(":scope > [data-card]")).map(
+        (el) => parseInt(el.id)
+    );
+}
+
+/**
+ * Gets the IDs of the pinned links, in the order they are displayed in the favorites row
+ * @returns {number[]} The link IDs
+ */
+function getPinnedOrder() {
+    return Array.from(
+        document.querySelectorAll("#pinned-bar > [data-pinned]")
+    ).map((el) => parseInt(el.id));
+}
+
+/**
+ * Gets the IDs of every category, in the order they are displayed
+ * @returns {number[]} The category IDs
+ */
+function getCategoryOrder() {
+    return Array.from(document.querySelectorAll(".category-header")).map(
+        (el) => parseInt(el.id)
+    );
+}
+
+/**
+ * Saves a new order for categories or links, or moves links into a category
+ * @param {string} url The reorder or move endpoint
+ * @param {number[]} ids The IDs in their new order
+ */
+async function saveOrder(url, ids) {
+    let res = await fetch(url, {
+        method: "PUT",
+        body: JSON.stringify({ ids: ids }),
+        headers: {
+            "Content-Type": "application/json",
+        },
+    });
+
+    if (!res.ok) {
+        let json = await res.json();
+        throw new Error(json.message);
+    }
+}
+
+document.addEventListener("dragstart", (event) => {
+    let target = event.target;
+    if (
+        !(target instanceof HTMLElement) ||
+        (!target.matches("[data-card]") &&
+            !target.matches("[data-pinned]") &&
+            !target.matches(".category-header"))
+    ) {
+        return;
+    }
+
+    // dont let the user drag things around while they are editing them
+    if (currentlyEditing.type !== undefined) {
+        event.preventDefault();
+        return;
+    }
+
+    draggedElement = target;
+    gridBeforeDrag = target.parentElement;
+    if (target.matches("[data-card]")) {
+        orderBeforeDrag = getLinkOrder(target.parentElement);
+    } else if (target.matches("[data-pinned]")) {
+        orderBeforeDrag = getPinnedOrder();
+    } else {
+        orderBeforeDrag = getCategoryOrder();
+    }
+
+    event.dataTransfer.effectAllowed = "move";
+    // firefox wont start a drag without some data
+    event.dataTransfer.setData("text/plain", target.id);
+
+    requestAnimationFrame(() => {
+        target.classList.add("dragging");
+    });
+});
+
+document.addEventListener("dragover", (event) => {
+    if (draggedElement === null) {
+        return;
+    }
+
+    if (draggedElement.matches("[data-pinned]")) {
+        let targetPinned = event.target.closest("[data-pinned]");
+        if (targetPinned === null) {
+            return;
+        }
+
+        event.preventDefault();
+
+        if (targetPinned === draggedElement) {
+            return;
+        }
+
+        let rect = targetPinned.getBoundingClientRect();
+        if (event.clientX > rect.left + rect.width / 2) {
+            targetPinned.after(draggedElement);
+        } else {
+            targetPinned.before(draggedElement);
+        }
+
+        return;
+    }
+
+    if (draggedElement.matches("[data-card]")) {
+        let targetGrid = event.target.closest(".link-grid");
+        if (targetGrid === null) {
+            return;
+        }
+
+        event.preventDefault();
+
+        let targetCard = event.target.closest("[data-card]");
+        if (targetCard === null) {
+            // dragging over an empty part of another category, or its add link card
+            if (draggedElement.parentElement !== targetGrid) {
+                targetGrid.insertBefore(
+                    draggedElement,
+                    targetGrid.lastElementChild
+                );
+            }
+            return;
+        }
+
+        if (targetCard === draggedElement) {
+            return;
+        }
+
+        let rect = targetCard.getBoundingClientRect();
+        if (event.clientX > rect.left + rect.width / 2) {
+            targetCard.after(draggedElement);
+        } else {
+            targetCard.before(draggedElement);
+        }
+
+        return;
+    }
+
+    let targetHeader = event.target.closest(".category-header");
+    if (targetHeader === null) {
+        let targetGrid = event.target.closest(".link-grid");
+        if (targetGrid === null) {
+            return;
+        }
+        targetHeader = targetGrid.previousElementSibling;
+    }
+
+    event.preventDefault();
+
+    if (targetHeader === draggedElement) {
+        return;
+    }
+
+    let draggedGrid = draggedElement.nextElementSibling;
+    let targetGrid = targetHeader.nextElementSibling;
+
+    let top = targetHeader.getBoundingClientRect().top;
+    let bottom = targetGrid.getBoundingClientRect().bottom;
+    if (event.clientY > top + (bottom - top) / 2) {
+        targetGrid.after(draggedElement, draggedGrid);
+    } else {
+        targetHeader.before(draggedElement, draggedGrid);
+    }
+});
+
+document.addEventListener("drop", (event) => {
+    if (draggedElement !== null) {
+        event.preventDefault();
+    }
+});
+
+document.addEventListener("dragend", async () => {
+    if (draggedElement === null) {
+        return;
+    }
+
+    let element = draggedElement;
+    let previousOrder = orderBeforeDrag;
+    let previousGrid = gridBeforeDrag;
+    draggedElement = null;
+    orderBeforeDrag = [];
+    gridBeforeDrag = null;
+
+    element.classList.remove("dragging");
+
+    if (element.matches("[data-pinned]")) {
+        let newPinnedOrder = getPinnedOrder();
+        if (newPinnedOrder.join() === previousOrder.join()) {
+            return;
+        }
+
+        try {
+            await saveOrder(
+                `/api/board/${boardBar.dataset.boardId}/pinned/order`,
+                newPinnedOrder
+            );
+        } catch (err) {
+            console.error(err);
+
+            let pinnedBar = document.getElementById("pinned-bar");
+            previousOrder.forEach((id) => {
+                pinnedBar.appendChild(
+                    document.getElementById(`${id}_pinned`)
+                );
+            });
+        }
+
+        return;
+    }
+
+    let isLink = element.matches("[data-card]");
+    let linkGrid = element.parentElement;
+    let movedCategory = isLink && linkGrid !== previousGrid;
+    let newOrder = isLink ? getLinkOrder(linkGrid) : getCategoryOrder();
+
+    if (!movedCategory && newOrder.join() === previousOrder.join()) {
+        return;
+    }
+
+    try {
+        if (isLink) {
+            let categoryID = parseInt(linkGrid.previousElementSibling.id);
+            if (movedCategory) {
+                await saveOrder(`/api/category/${categoryID}/link/move`, [
+                    parseInt(element.id),
+                ]);
+            }
+            await saveOrder(`/api/category/${categoryID}/link/order`, newOrder);
+        } else {
+            await saveOrder(
+                `/api/board/${boardBar.dataset.boardId}/category/order`,
+                newOrder
+            );
+        }
+    } catch (err) {
+        console.error(err);
+
+        // put everything back where it was so the page matches the server
+        if (isLink) {
+            previousOrder.forEach((id) => {
+                previousGrid.insertBefore(
+                    document.getElementById(`${id}_link`),
+                    previousGrid.lastElementChild
+                );
+            });
+        } else {
+            let addCategoryButton = document.getElementById(
+                "add-category-button"
+            );
+            previousOrder.forEach((id) => {
+                let categoryEl = document.getElementById(`${id}_category`);
+                addCategoryButton.before(
+                    categoryEl,
+                    categoryEl.nextElementSibling
+                );
+            });
+        }
+    }
+});
+
+/**
+ * Pins or unpins the link the pin button belongs to, and adds or removes it from the favorites row
+ * @param {HTMLButtonElement} target The pin button that was clicked
+ */
+async function togglePin(target) {
+    let linkEl = target.closest("[data-card]");
+    let linkID = parseInt(linkEl.id);
+    let categoryID = parseInt(linkEl.parentElement.previousElementSibling.id);
+    let pinned = target.getAttribute("aria-pressed") === "true";
+
+    target.disabled = true;
+
+    await fetch(`/api/category/${categoryID}/link/${linkID}/pin`, {
+        method: pinned ? "DELETE" : "PUT",
+    })
+        .then(async (res) => {
+            let json = await res.json();
+
+            if (!res.ok) {
+                throw new Error(json.message);
+            }
+
+            target.setAttribute("aria-pressed", String(json.link.pinned));
+
+            if (!json.link.pinned) {
+                document.getElementById(`${linkID}_pinned`)?.remove();
+                return;
+            }
+
+            let pinnedEl = document.createElement("div");
+            pinnedEl.id = `${linkID}_pinned`;
+            pinnedEl.dataset.pinned = "";
+            pinnedEl.draggable = true;
+
+            let pinnedImg = document.createElement("img");
+            pinnedImg.width = 24;
+            pinnedImg.height = 24;
+            pinnedImg.draggable = false;
+            pinnedImg.alt = "";
+            pinnedImg.src = linkEl.querySelector("div:first-child img").src;
+
+            let pinnedName = document.createElement("span");
+            pinnedName.textContent = json.link.name;
+
+            pinnedEl.append(pinnedImg, pinnedName);
+            document.getElementById("pinned-bar").appendChild(pinnedEl);
+        })
+        .catch((err) => {
+            console.error(err);
+        })
+        .finally(() => {
+            target.disabled = false;
+        });
+}
+
+/**
+ * Replaces the icon of the link the button belongs to with the one its site declares
+ * @param {HTMLButtonElement} target The refetch button that was clicked
+ */
+async function refetchIcon(target) {
+    let linkEl = target.closest("[data-card]");
+    let linkID = parseInt(linkEl.id);
+    let categoryID = parseInt(linkEl.parentElement.previousElementSibling.id);
+
+    target.disabled = true;
+
+    await fetch(`/api/category/${categoryID}/link/${linkID}/icon/refetch`, {
+        method: "POST",
+    })
+        .then(async (res) => {
+            let json = await res.json();
+
+            if (!res.ok) {
+                throw new Error(json.message);
+            }
+
+            linkEl.querySelector("div:first-child img").src = json.link.icon;
+
+            let pinnedImg = document
+                .getElementById(`${linkID}_pinned`)
+                ?.querySelector("img");
+            if (pinnedImg) {
+                pinnedImg.src = json.link.icon;
+            }
+        })
+        .catch((err) => {
+            console.error(err);
+        })
+        .finally(() => {
+            target.disabled = false;
+        });
+}
+
 function roundToNearestHundredth(num) {
     return Math.round(num * 100) / 100;
 }
@@ -1200,6 +1972,97 @@ function replaceWithResizableTextarea(targetEls) {
     };
 }
 
+/**
+ * Replaces the tag chips shown on a link card
+ * @param {HTMLElement} container The element holding the link name and description
+ * @param {string[]} tags The tags to show
+ */
+function renderTagList(container, tags) {
+    let tagList = container.querySelector(".tag-list");
+    if (tagList !== null) {
+        tagList.remove();
+    }
+
+    if (tags.length === 0) {
+        return;
+    }
+
+    tagList = document.createElement("ul");
+    tagList.className = "tag-list";
+    tagList.setAttribute("aria-label", "Tags");
+    tags.forEach((tag) => {
+        let tagEl = document.createElement("li");
+        tagEl.textContent = tag;
+        tagList.appendChild(tagEl);
+    });
+
+    container.appendChild(tagList);
+}
+
+/**
+ * @typedef {Object} EditInputOptions
+ * @property {string} name The name of the input, used to find it again later
+ * @property {string} type The type of the input
+ * @property {string} value The initial value of the input
+ * @property {string} placeholder The placeholder of the input
+ */
+
+/**
+ * Appends an extra input to an element that is being edited, for values that are not displayed on the card
+ * @param {HTMLElement} container The element to append the input to
+ * @param {EditInputOptions} options The options for the input
+ * @returns {HTMLInputElement} The created input
+ */
+function appendEditInput(container, options) {
+    const inputElement = document.createElement("input");
+    inputElement.className = "edit-input";
+    inputElement.name = options.name;
+    inputElement.type = options.type;
+    inputElement.value = options.value ?? "";
+    inputElement.placeholder = options.placeholder;
+    inputElement.setAttribute("aria-label", options.placeholder);
+
+    container.appendChild(inputElement);
+
+    return inputElement;
+}
+
+/**
+ * @typedef {Object} EditSelectOptions
+ * @property {string} name The name of the select, used to find it again later
+ * @property {string} value The initially selected value
+ * @property {string} label The accessible label of the select
+ * @property {[string, string][]} options The value and text of every option
+ */
+
+/**
+ * Appends a select to an element that is being edited, for settings that are not displayed on the card
+ * @param {HTMLElement} container The element to append the select to
+ * @param {EditSelectOptions} options The options for the select
+ * @returns {HTMLSelectElement} The created select
+ */
+function appendEditSelect(container, options) {
+    const selectElement = document.createElement("select");
+    selectElement.className = "edit-input";
+    selectElement.name = options.name;
+    selectElement.setAttribute("aria-label", options.label);
+
+    options.options.forEach(([optionValue, text]) => {
+        selectElement.add(
+            new Option(text, optionValue, false, optionValue === options.value)
+        );
+    });
+
+    container.appendChild(selectElement);
+
+    return selectElement;
+}
+
+const visibilityOptions = [
+    ["public", "Everyone"],
+    ["private", "Only logged in admins"],
+];
+
 /**
  * Restores an element from a textarea
  * @param {HTMLElement} inputEl The textarea to restore
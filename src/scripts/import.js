@@ -0,0 +1,154 @@
+"use strict";
+
+let importForm = document.getElementById("import-form");
+let importMessage = document.getElementById("import-message");
+let importPreview = document.getElementById("import-preview");
+
+/**
+ * Sends the bookmark file to the import endpoint
+ * @param {boolean} preview Whether to only work out what would be imported
+ * @returns {Promise<Object>} The response, or null if the request failed
+ */
+async function sendImport(preview) {
+    importMessage.innerText = "";
+
+    let data = new FormData(importForm);
+    data.append("preview", String(preview));
+
+    let res = await fetch("/api/import/bookmarks", {
+        method: "POST",
+        body: data,
+    });
+    let json = await res.json();
+
+    if (!res.ok) {
+        importMessage.innerText = json.message;
+        return null;
+    }
+
+    return json;
+}
+
+/**
+ * Shows what importing the file would add to the board
+ * @param {Object} plan The import plan returned by the server
+ */
+function renderPreview(plan) {
+    let includeDuplicates =
+        document.getElementById("importDuplicates").value === "true";
+    let added = plan.links + (includeDuplicates ? plan.duplicates : 0);
+
+    document.getElementById(
+        "import-summary"
+    ).textContent = `${added} links will be added, ${plan.duplicates} are already on the board and ${plan.skipped} cannot be imported.`;
+    document.getElementById("import-confirm").disabled = added === 0;
+
+    let container = document.getElementById("import-categories");
+    container.replaceChildren();
+
+    for (let category of plan.categories) {
+        let heading = document.createElement("h3");
+        heading.textContent = category.existing_id
+            ? `${category.name} (existing category)`
+            : `${category.name} (new category)`;
+
+        let list = document.createElement("ul");
+        list.classList.add("trash-list");
+
+        for (let link of category.links) {
+            let item = document.createElement("li");
+
+            let details = document.createElement("div");
+            let name = document.createElement("p");
+            name.textContent = link.name;
+            let url = document.createElement("p");
+            url.classList.add("trash-note");
+            url.textContent = link.url;
+            details.append(name, url);
+            item.appendChild(details);
+
+            if (link.skip || link.duplicate) {
+                let note = document.createElement("span");
+                note.classList.add("trash-note");
+                note.textContent = link.skip || "Duplicate";
+                item.appendChild(note);
+            }
+
+            list.appendChild(item);
+        }
+
+        container.append(heading, list);
+    }
+
+    importPreview.classList.remove("hidden");
+}
+
+importForm.addEventListener("submit", async (event) => {
+    event.preventDefault();
+
+    let submitButton = importForm.querySelector("button");
+    submitButton.disabled = true;
+
+    let json = await sendImport(true);
+    if (json) {
+        renderPreview(json.plan);
+    }
+
+    submitButton.disabled = false;
+});
+
+// the preview no longer matches once anything in the form changes
+importForm.addEventListener("change", () => {
+    importPreview.classList.add("hidden");
+});
+
+/**
+ * Imports the file that was previewed, and goes to the board it was imported into
+ * @param {HTMLButtonElement} target The import button that was clicked
+ */
+async function confirmImport(target) {
+    target.disabled = true;
+
+    let json = await sendImport(false);
+    if (!json) {
+        target.disabled = false;
+        return;
+    }
+
+    let board = document.getElementById("importBoard").selectedOptions[0];
+    let path = board.dataset.path === "/" ? "" : board.dataset.path;
+    window.location.href = `/admin${path}`;
+}
+
+let restoreForm = document.getElementById("restore-form");
+
+restoreForm.addEventListener("submit", async (event) => {
+    event.preventDefault();
+
+    if (
+        !confirm(
+            "Restoring replaces every board, category and link with the ones in the backup. This cannot be undone."
+        )
+    ) {
+        return;
+    }
+
+    let submitButton = restoreForm.querySelector("button");
+    let message = document.getElementById("restore-message");
+    submitButton.disabled = true;
+    message.innerText = "";
+
+    let res = await fetch("/api/restore", {
+        method: "POST",
+        body: new FormData(restoreForm),
+    });
+
+    if (res.ok) {
+        window.location.href = "/admin";
+        return;
+    }
+
+    let json = await res.json();
+    message.innerText = json.message;
+    submitButton.disabled = false;
+});
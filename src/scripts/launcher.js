@@ -0,0 +1,221 @@
+"use strict";
+
+let launcherForm = document.getElementById("search-form");
+let launcherInput = launcherForm.querySelector("input[name=q]");
+let launcherList = document.getElementById("launcher");
+
+/** @type {AbortController | undefined} */
+let launcherRequest;
+let launcherTimeout;
+// the link or suggestion picked with the arrow keys, -1 when enter should search what was typed
+let launcherSelected = -1;
+// the @shortcut the suggestions shown came from, empty for the provider picked next to the search bar
+let launcherShortcut = "";
+
+/**
+ * @typedef {object} SearchResult
+ * @property {number} id
+ * @property {string} name
+ * @property {string} description
+ * @property {string} icon
+ * @property {string} category
+ * @property {string} board
+ * @property {string} board_title
+ * @property {string} href
+ */
+
+/**
+ * Shows the links that match the search bar followed by what the search provider suggests, or hides the list if there
+ * is nothing to show
+ * @param {SearchResult[]} results The matching links, best first
+ * @param {string[]} suggestions The searches the search provider suggests
+ */
+function renderLauncher(results, suggestions = []) {
+    launcherList.replaceChildren();
+    launcherSelected = -1;
+    launcherInput.removeAttribute("aria-activedescendant");
+
+    for (let result of results) {
+        let item = document.createElement("li");
+        item.setAttribute("role", "option");
+        item.id = `launcher-${result.id}`;
+
+        let link = document.createElement("a");
+        link.href = result.href;
+        link.target = "_blank";
+        link.rel = "noreferrer";
+        link.tabIndex = -1;
+
+        let icon = document.createElement("img");
+        icon.width = 24;
+        icon.height = 24;
+        icon.draggable = false;
+        icon.alt = "";
+        icon.src = result.icon;
+
+        let text = document.createElement("div");
+        let name = document.createElement("span");
+        name.textContent = result.name;
+        let where = document.createElement("span");
+        // links on other boards say which board they are on
+        where.textContent =
+            result.board === launcherForm.dataset.board
+                ? result.category
+                : `${result.board_title} / ${result.category}`;
+        text.append(name, where);
+
+        link.append(icon, text);
+        item.append(link);
+        launcherList.append(item);
+    }
+
+    for (let [index, suggestion] of suggestions.entries()) {
+        let item = document.createElement("li");
+        item.setAttribute("role", "option");
+        item.id = `launcher-suggestion-${index}`;
+        item.className = "launcher-suggestion";
+        item.dataset.suggestion = suggestion;
+        item.textContent = suggestion;
+        item.addEventListener("click", () => searchSuggestion(suggestion));
+        launcherList.append(item);
+    }
+
+    let shown = results.length + suggestions.length > 0;
+    launcherList.hidden = !shown;
+    launcherInput.setAttribute("aria-expanded", String(shown));
+}
+
+/**
+ * Searches for a suggestion as if it had been typed
+ * @param {string} suggestion The suggestion that was picked
+ */
+function searchSuggestion(suggestion) {
+    launcherInput.value = launcherShortcut
+        ? `@${launcherShortcut} ${suggestion}`
+        : suggestion;
+    renderLauncher([]);
+    launcherForm.submit();
+}
+
+/**
+ * Highlights a result, so enter opens it instead of searching the web
+ * @param {number} index The index of the result, -1 for none
+ */
+function selectLauncherResult(index) {
+    let items = launcherList.children;
+    if (items.length === 0) {
+        return;
+    }
+
+    if (launcherSelected !== -1) {
+        items[launcherSelected].removeAttribute("aria-selected");
+    }
+
+    // going past either end goes back to the search bar
+    launcherSelected = index < -1 || index >= items.length ? -1 : index;
+
+    if (launcherSelected === -1) {
+        launcherInput.removeAttribute("aria-activedescendant");
+        return;
+    }
+
+    items[launcherSelected].setAttribute("aria-selected", "true");
+    items[launcherSelected].scrollIntoView({ block: "nearest" });
+    launcherInput.setAttribute(
+        "aria-activedescendant",
+        items[launcherSelected].id
+    );
+}
+
+/**
+ * Asks the server for the links and suggestions matching the search bar
+ */
+async function searchLinks() {
+    launcherRequest?.abort();
+
+    // bangs and @shortcuts can be anywhere in the query, the server works out what there is to show for them
+    let query = launcherInput.value.trim();
+    if (query === "") {
+        renderLauncher([]);
+        return;
+    }
+
+    launcherRequest = new AbortController();
+
+    let params = new URLSearchParams({ q: query });
+    // suggestions come from the search provider the search would go to
+    let provider = launcherForm.querySelector("select[name=provider]");
+    if (provider) {
+        params.set("provider", provider.value);
+    }
+
+    try {
+        let res = await fetch(`/api/suggest?${params}`, {
+            signal: launcherRequest.signal,
+        });
+        if (!res.ok) {
+            renderLauncher([]);
+            return;
+        }
+
+        let json = await res.json();
+        launcherShortcut = json.shortcut ?? "";
+        renderLauncher(json.links ?? [], json.suggestions ?? []);
+    } catch (error) {
+        // a newer search took over
+        if (error.name !== "AbortError") {
+            renderLauncher([]);
+        }
+    }
+}
+
+launcherInput.addEventListener("input", () => {
+    clearTimeout(launcherTimeout);
+    launcherTimeout = setTimeout(searchLinks, 100);
+});
+
+launcherInput.addEventListener("keydown", (event) => {
+    switch (event.key) {
+        case "ArrowDown":
+            event.preventDefault();
+            selectLauncherResult(launcherSelected + 1);
+            break;
+        case "ArrowUp":
+            event.preventDefault();
+            selectLauncherResult(launcherSelected - 1);
+            break;
+        case "Escape":
+            renderLauncher([]);
+            break;
+    }
+});
+
+// enter searches what was typed unless a link or suggestion was picked, so bangs and keywords keep working
+launcherForm.addEventListener("submit", (event) => {
+    if (launcherSelected === -1) {
+        return;
+    }
+
+    let item = launcherList.children[launcherSelected];
+    if (item.dataset.suggestion !== undefined) {
+        launcherInput.value = item.dataset.suggestion;
+        return;
+    }
+
+    event.preventDefault();
+    item.querySelector("a").click();
+});
+
+launcherInput.addEventListener("focus", () => {
+    if (launcherList.children.length > 0) {
+        launcherList.hidden = false;
+        launcherInput.setAttribute("aria-expanded", "true");
+    }
+});
+
+document.addEventListener("click", (event) => {
+    if (!launcherForm.contains(event.target)) {
+        launcherList.hidden = true;
+        launcherInput.setAttribute("aria-expanded", "false");
+    }
+});
@@ -0,0 +1,36 @@
+"use strict";
+
+let providerSelect = document.querySelector("#search-form select[name=provider]");
+let providerIcon = document.querySelector("#search-form .search-provider img");
+
+/**
+ * Shows the icon of the picked search provider next to the search bar
+ */
+function showProviderIcon() {
+    let option = providerSelect.selectedOptions[0];
+    if (option) {
+        providerIcon.src = option.dataset.icon;
+    }
+}
+
+// the last picked provider is kept until another one is picked, as long as it still exists
+let savedProvider = localStorage.getItem("searchProvider");
+if (
+    savedProvider !== null &&
+    Array.from(providerSelect.options).some(
+        (option) => option.value === savedProvider
+    )
+) {
+    providerSelect.value = savedProvider;
+}
+showProviderIcon();
+
+providerSelect.addEventListener("change", () => {
+    localStorage.setItem("searchProvider", providerSelect.value);
+    showProviderIcon();
+
+    // the suggestions shown came from the provider that was picked before
+    let input = document.querySelector("#search-form input[name=q]");
+    input.dispatchEvent(new Event("input"));
+    input.focus();
+});
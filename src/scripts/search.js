@@ -0,0 +1,181 @@
+"use strict";
+
+let bangForm = document.getElementById("bang-form");
+let bangMessage = document.getElementById("bang-message");
+let providerForm = document.getElementById("provider-form");
+let providerMessage = document.getElementById("provider-message");
+
+/**
+ * Sends a request and shows the error message if it fails
+ * @param {HTMLElement} message The element the error message goes in
+ * @param {string} url The endpoint
+ * @param {"POST" | "PATCH" | "DELETE"} method The method to send
+ * @param {FormData | undefined} body The fields to send, if any
+ * @returns {Promise<boolean>} Whether the request succeeded
+ */
+async function sendSearchRequest(message, url, method, body) {
+    message.innerText = "";
+
+    let res = await fetch(url, { method: method, body: body });
+    if (!res.ok) {
+        let json = await res.json();
+        message.innerText = json.message;
+        return false;
+    }
+
+    return true;
+}
+
+/**
+ * Sends a bang request and shows the error message if it fails
+ * @param {string} url The bang endpoint
+ * @param {"POST" | "PATCH" | "DELETE"} method The method to send
+ * @param {FormData | undefined} body The fields of the bang, if any
+ * @returns {Promise<boolean>} Whether the request succeeded
+ */
+async function sendBangRequest(url, method, body) {
+    return sendSearchRequest(bangMessage, url, method, body);
+}
+
+/**
+ * Sends a search provider request and shows the error message if it fails
+ * @param {string} url The search provider endpoint
+ * @param {"POST" | "PATCH" | "DELETE"} method The method to send
+ * @param {FormData | undefined} body The fields of the search provider, if any
+ * @returns {Promise<boolean>} Whether the request succeeded
+ */
+async function sendProviderRequest(url, method, body) {
+    return sendSearchRequest(providerMessage, url, method, body);
+}
+
+// either form is left out when the dashboard file manages what it adds
+providerForm?.addEventListener("submit", async (event) => {
+    event.preventDefault();
+
+    let submitButton = providerForm.querySelector("button");
+    submitButton.disabled = true;
+
+    // a new default provider changes the others too, so reload to show them all as they are now
+    if (
+        await sendProviderRequest(
+            "/api/search-provider",
+            "POST",
+            new FormData(providerForm)
+        )
+    ) {
+        window.location.reload();
+        return;
+    }
+
+    submitButton.disabled = false;
+});
+
+/**
+ * Saves the changes to the search provider the form belongs to
+ * @param {SubmitEvent} event The submit event of the search provider's form
+ */
+async function saveProvider(event) {
+    event.preventDefault();
+
+    let form = event.target;
+    let item = form.closest("li");
+    let submitButton = form.querySelector("button[type=submit]");
+    submitButton.disabled = true;
+
+    let body = new FormData(form);
+    // an unchecked box isnt sent at all, which would keep the provider as it was
+    if (!body.has("default") && form.querySelector("input[name=default]")) {
+        body.set("default", "false");
+    }
+
+    if (
+        await sendProviderRequest(
+            `/api/search-provider/${item.dataset.id}`,
+            "PATCH",
+            body
+        )
+    ) {
+        window.location.reload();
+        return;
+    }
+
+    submitButton.disabled = false;
+}
+
+/**
+ * Deletes the search provider the button belongs to
+ * @param {HTMLButtonElement} target The delete button that was clicked
+ */
+async function deleteProvider(target) {
+    let item = target.closest("li");
+    target.disabled = true;
+
+    if (
+        await sendProviderRequest(
+            `/api/search-provider/${item.dataset.id}`,
+            "DELETE"
+        )
+    ) {
+        item.remove();
+        return;
+    }
+
+    target.disabled = false;
+}
+
+bangForm?.addEventListener("submit", async (event) => {
+    event.preventDefault();
+
+    let submitButton = bangForm.querySelector("button");
+    submitButton.disabled = true;
+
+    // the list is sorted by the server, so reload to show the new bang in its place
+    if (await sendBangRequest("/api/bang", "POST", new FormData(bangForm))) {
+        window.location.reload();
+        return;
+    }
+
+    submitButton.disabled = false;
+});
+
+/**
+ * Saves the changes to the bang the form belongs to
+ * @param {SubmitEvent} event The submit event of the bang's form
+ */
+async function saveBang(event) {
+    event.preventDefault();
+
+    let form = event.target;
+    let item = form.closest("li");
+    let submitButton = form.querySelector("button[type=submit]");
+    submitButton.disabled = true;
+
+    if (
+        await sendBangRequest(
+            `/api/bang/${item.dataset.id}`,
+            "PATCH",
+            new FormData(form)
+        )
+    ) {
+        window.location.reload();
+        return;
+    }
+
+    submitButton.disabled = false;
+}
+
+/**
+ * Deletes the bang the button belongs to
+ * @param {HTMLButtonElement} target The delete button that was clicked
+ */
+async function deleteBang(target) {
+    let item = target.closest("li");
+    target.disabled = true;
+
+    if (await sendBangRequest(`/api/bang/${item.dataset.id}`, "DELETE")) {
+        item.remove();
+        return;
+    }
+
+    target.disabled = false;
+}
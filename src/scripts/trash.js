@@ -0,0 +1,97 @@
+"use strict";
+
+let trashMessage = document.getElementById("trash-message");
+
+/**
+ * Sends a trash request and shows the error message if it fails
+ * @param {string} url The trash endpoint
+ * @param {"POST" | "DELETE"} method The method to send
+ * @returns {Promise<boolean>} Whether the request succeeded
+ */
+async function sendTrashRequest(url, method) {
+    trashMessage.innerText = "";
+
+    let res = await fetch(url, { method: method });
+    if (!res.ok) {
+        let json = await res.json();
+        trashMessage.innerText = json.message;
+        return false;
+    }
+
+    return true;
+}
+
+/**
+ * Restores or purges the trashed item the button belongs to
+ * @param {HTMLButtonElement} target The button that was clicked
+ * @param {string} action The path to append to the item's url, if any
+ * @param {"POST" | "DELETE"} method The method to send
+ */
+async function trashItemAction(target, action, method) {
+    let item = target.closest("li");
+    item.querySelectorAll("button").forEach((button) => {
+        button.disabled = true;
+    });
+
+    let ok = await sendTrashRequest(
+        `/api/trash/${item.dataset.kind}/${item.dataset.id}${action}`,
+        method
+    );
+
+    if (!ok) {
+        item.querySelectorAll("button").forEach((button) => {
+            button.disabled = false;
+        });
+        return;
+    }
+
+    // links in the category change along with it, so reload to show where they ended up
+    if (item.dataset.kind === "category") {
+        window.location.reload();
+        return;
+    }
+
+    item.remove();
+}
+
+/**
+ * Takes the item out of the trash
+ * @param {HTMLButtonElement} target The restore button that was clicked
+ */
+function restoreItem(target) {
+    trashItemAction(target, "/restore", "POST");
+}
+
+/**
+ * Permanently deletes the item
+ * @param {HTMLButtonElement} target The delete button that was clicked
+ */
+function purgeItem(target) {
+    let name = target.closest("li").querySelector("p").textContent;
+    if (!confirm(`Delete ${name} forever? This action cannot be undone.`)) {
+        return;
+    }
+
+    trashItemAction(target, "", "DELETE");
+}
+
+/**
+ * Permanently deletes everything in the trash
+ * @param {HTMLButtonElement} target The empty trash button that was clicked
+ */
+async function emptyTrash(target) {
+    if (
+        !confirm(
+            "Delete everything in the trash forever? This action cannot be undone."
+        )
+    ) {
+        return;
+    }
+
+    target.disabled = true;
+    if (await sendTrashRequest("/api/trash", "DELETE")) {
+        window.location.reload();
+        return;
+    }
+    target.disabled = false;
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/juls0730/passport/src/audit"
+)
+
+func TestSearchTarget(t *testing.T) {
+	manager := newTestManager(t)
+	app := &App{Config: &Config{}, CategoryManager: manager}
+
+	for _, provider := range []SearchProvider{
+		{Name: "DuckDuckGo", Shortcut: "ddg", URL: "https://duckduckgo.com/?q=%s", IsDefault: true},
+		{Name: "Find", Shortcut: "f", URL: "https://find.example/search?q=%s"},
+	} {
+		if _, err := manager.CreateSearchProvider(audit.Actor{}, provider); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	category := createTestCategory(t, manager, manager.GetDefaultBoard().ID, "Dev")
+	createTestLink(t, manager, Link{CategoryID: category.ID, Name: "Docs", URL: "https://docs.example", Keyword: "docs",
+		SearchURL: "https://docs.example/search?q=%s"})
+	createTestLink(t, manager, Link{CategoryID: category.ID, Name: "Home", URL: "https://home.example", Keyword: "home"})
+	createTestLink(t, manager, Link{CategoryID: category.ID, Name: "Secret", URL: "https://secret.example", Keyword: "secret",
+		Visibility: VisibilityPrivate})
+
+	tests := []struct {
+		name    string
+		query   string
+		picked  string
+		isAdmin bool
+		want    string
+	}{
+		{name: "plain search", query: "hello world", want: "https://duckduckgo.com/?q=hello+world"},
+		{name: "empty search", query: "", want: "https://duckduckgo.com/?q="},
+		{name: "picked provider", query: "hello", picked: "f", want: "https://find.example/search?q=hello"},
+		{name: "unknown picked provider", query: "hello", picked: "nope", want: "https://duckduckgo.com/?q=hello"},
+
+		{name: "bang", query: "!w go", want: "https://en.wikipedia.org/w/index.php?search=go"},
+		{name: "bang at the end", query: "go lang !w", want: "https://en.wikipedia.org/w/index.php?search=go+lang"},
+		{name: "bang in upper case", query: "!W go", want: "https://en.wikipedia.org/w/index.php?search=go"},
+		{name: "bang on its own", query: "!w", want: "https://en.wikipedia.org/"},
+		{name: "unknown bang", query: "!nope go", want: "https://duckduckgo.com/?q=%21nope+go"},
+		{name: "bang over picked provider", query: "!w go", picked: "f", want: "https://en.wikipedia.org/w/index.php?search=go"},
+
+		{name: "shortcut", query: "@f cats", want: "https://find.example/search?q=cats"},
+		{name: "shortcut at the end", query: "cats @f", want: "https://find.example/search?q=cats"},
+		{name: "shortcut on its own", query: "@f", want: "https://find.example/"},
+		{name: "unknown shortcut", query: "@nope cats", want: "https://duckduckgo.com/?q=%40nope+cats"},
+		{name: "shortcut over picked provider", query: "@ddg cats", picked: "f", want: "https://duckduckgo.com/?q=cats"},
+		{name: "bang over shortcut", query: "@f !w cats", want: "https://en.wikipedia.org/w/index.php?search=%40f+cats"},
+
+		{name: "keyword", query: "docs", want: "https://docs.example"},
+		{name: "keyword with terms", query: "docs context", want: "https://docs.example/search?q=context"},
+		{name: "keyword in upper case", query: "DOCS context", want: "https://docs.example/search?q=context"},
+		{name: "keyword not first", query: "context docs", want: "https://duckduckgo.com/?q=context+docs"},
+		{name: "keyword without a search URL", query: "home stuff", want: "https://duckduckgo.com/?q=home+stuff"},
+		{name: "keyword over picked provider", query: "docs", picked: "f", want: "https://docs.example"},
+		{name: "bang over keyword", query: "docs !w context", want: "https://en.wikipedia.org/w/index.php?search=docs+context"},
+		{name: "shortcut over keyword", query: "docs context @f", want: "https://find.example/search?q=docs+context"},
+		{name: "private keyword", query: "secret", want: "https://duckduckgo.com/?q=secret"},
+		{name: "private keyword for an admin", query: "secret", isAdmin: true, want: "https://secret.example"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := app.searchTarget(test.query, test.picked, test.isAdmin); got != test.want {
+				t.Errorf("searchTarget(%q, %q, %v) = %q, want %q", test.query, test.picked, test.isAdmin, got, test.want)
+			}
+		})
+	}
+}
+
+func TestSearchTargetWithoutProviders(t *testing.T) {
+	app := &App{Config: &Config{}, CategoryManager: newTestManager(t)}
+
+	if got := app.searchTarget("hello", "", false); got != "/" {
+		t.Errorf("searchTarget() = %q, want %q", got, "/")
+	}
+
+	// bangs come with the database, so they still work
+	if got := app.searchTarget("!w go", "", false); got != "https://en.wikipedia.org/w/index.php?search=go" {
+		t.Errorf("searchTarget() = %q, want a Wikipedia search", got)
+	}
+}
+
+func TestKeywordTakenWhileInTheTrash(t *testing.T) {
+	manager := newTestManager(t)
+	category := createTestCategory(t, manager, manager.GetDefaultBoard().ID, "Dev")
+	old := createTestLink(t, manager, Link{CategoryID: category.ID, Name: "Old docs", URL: "https://old.example", Keyword: "docs"})
+
+	// the keyword is taken as long as the link is not in the trash
+	_, err := manager.CreateLink(manager.db, audit.Actor{}, Link{CategoryID: category.ID, Name: "Docs", URL: "https://docs.example",
+		Visibility: VisibilityPublic, Keyword: "docs"})
+	var taken *TakenError
+	if !errors.As(err, &taken) {
+		t.Fatalf("CreateLink() = %v, want the keyword to be taken", err)
+	}
+
+	trashTestLink(t, manager, old)
+	link := createTestLink(t, manager, Link{CategoryID: category.ID, Name: "Docs", URL: "https://docs.example", Keyword: "docs"})
+
+	if err := manager.RestoreLink(audit.Actor{}, old.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if restored := manager.GetLink(old.ID); restored == nil || restored.Keyword != "" {
+		t.Errorf("restored link = %+v, want it without a keyword", restored)
+	}
+
+	if got := manager.FindKeyword("docs", false); got == nil || got.ID != link.ID {
+		t.Errorf("FindKeyword() = %+v, want link %d", got, link.ID)
+	}
+}
+
+func TestDashboardManages(t *testing.T) {
+	boards := "boards:\n  - slug: home\n    title: Home\n"
+	bangs := "bangs:\n  - bang: w\n    name: Wikipedia\n    url: https://en.wikipedia.org/w/index.php?search=%s\n"
+	providers := "search_providers:\n  - name: DuckDuckGo\n    shortcut: ddg\n    url: https://duckduckgo.com/?q=%s\n"
+
+	tests := []struct {
+		name string
+		file string
+		path string
+		want bool
+	}{
+		{name: "links", file: boards, path: "/api/link", want: true},
+		{name: "bangs left out", file: boards, path: "/api/bang"},
+		{name: "bang left out", file: boards, path: "/api/bang/3"},
+		{name: "bangs declared", file: boards + bangs, path: "/api/bang/3", want: true},
+		{name: "bangs declared empty", file: boards + "bangs: []\n", path: "/api/bang", want: true},
+		{name: "providers left out", file: boards + bangs, path: "/api/search-provider/3"},
+		{name: "providers declared", file: boards + providers, path: "/api/search-provider", want: true},
+		{name: "not a bang", file: boards, path: "/api/bangs", want: true},
+		{name: "broken file", file: "boards: [", path: "/api/bang", want: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "dashboard.yml")
+			if err := os.WriteFile(path, []byte(test.file), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			app := &App{Config: &Config{DashboardFile: path}}
+			if got := app.dashboardManages(test.path); got != test.want {
+				t.Errorf("dashboardManages(%q) = %v, want %v", test.path, got, test.want)
+			}
+		})
+	}
+}
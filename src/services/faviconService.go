@@ -0,0 +1,496 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "image/jpeg"
+
+	"golang.org/x/image/bmp"
+	_ "golang.org/x/image/webp"
+	"golang.org/x/net/html"
+)
+
+var ErrNoFavicon = errors.New("no usable icon was found")
+
+// the same limit as uploaded icons
+const maxFaviconSize = 5 * 1024 * 1024
+
+// how many of the best candidates are downloaded before giving up on the page
+const maxFaviconCandidates = 5
+
+// how many of the web app manifests a page links to are read, pages rarely have more than one
+const maxFaviconManifests = 2
+
+// how long finding the icon of a page may take altogether, however many requests that takes
+const maxFaviconTime = 15 * time.Second
+
+// SVGs that plainly carry scripts are not worth keeping. This is not what keeps them from running, there are too many
+// ways to hide a script in an SVG, the uploads directory is served with a content security policy that blocks them
+var unsafeSVGPattern = regexp.MustCompile(`(?i)<script|<foreignObject|javascript:|\son[a-z]+\s*=`)
+
+type Favicon struct {
+	Data []byte
+	// one of image/svg+xml, image/png, image/jpeg or image/webp, other formats are converted to PNG
+	ContentType string
+	// where the icon was downloaded from
+	URL string
+}
+
+type faviconCandidate struct {
+	url string
+	// the largest side in pixels, 0 if it is not known
+	size     int
+	scalable bool
+}
+
+type FaviconFinder struct {
+	client *http.Client
+}
+
+func NewFaviconFinder() *FaviconFinder {
+	return &FaviconFinder{
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Find looks for the icons a page declares, in its <link> tags and its web app manifest, and downloads the best one
+// it can use, falling back to /favicon.ico. It gives up once maxFaviconTime has passed, or ctx is done if that is sooner
+func (f *FaviconFinder) Find(ctx context.Context, pageURL string) (*Favicon, error) {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, maxFaviconTime)
+	defer cancel()
+
+	// the page may have redirected, so the fallback is relative to where it ended up
+	candidates, finalURL, pageErr := f.candidates(ctx, base)
+	if pageErr == nil {
+		base = finalURL
+	}
+
+	tried := map[string]bool{}
+	for _, candidate := range rankFavicons(candidates) {
+		if len(tried) == maxFaviconCandidates {
+			break
+		}
+
+		if tried[candidate.url] {
+			continue
+		}
+		tried[candidate.url] = true
+
+		if icon, err := f.download(ctx, candidate.url); err == nil {
+			return icon, nil
+		}
+	}
+
+	fallback := base.ResolveReference(&url.URL{Path: "/favicon.ico"}).String()
+	if !tried[fallback] {
+		if icon, err := f.download(ctx, fallback); err == nil {
+			return icon, nil
+		}
+	}
+
+	if pageErr != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoFavicon, describeError(pageErr))
+	}
+
+	return nil, ErrNoFavicon
+}
+
+// candidates fetches the page and returns every icon it declares, along with the URL the page ended up at
+func (f *FaviconFinder) candidates(ctx context.Context, pageURL *url.URL) ([]faviconCandidate, *url.URL, error) {
+	resp, err := f.get(ctx, pageURL.String())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	base := resp.Request.URL
+	var candidates []faviconCandidate
+	var manifests []string
+
+	tokenizer := html.NewTokenizer(io.LimitReader(resp.Body, 2*1024*1024))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+
+		token := tokenizer.Token()
+		if tokenType == html.EndTagToken && token.Data == "head" {
+			break
+		}
+
+		if tokenType != html.StartTagToken && tokenType != html.SelfClosingTagToken {
+			continue
+		}
+
+		if token.Data == "body" {
+			break
+		}
+
+		attrs := map[string]string{}
+		for _, attr := range token.Attr {
+			attrs[attr.Key] = attr.Val
+		}
+
+		switch token.Data {
+		case "base":
+			if href, err := base.Parse(attrs["href"]); err == nil && attrs["href"] != "" {
+				base = href
+			}
+		case "link":
+			href, err := base.Parse(attrs["href"])
+			if err != nil || attrs["href"] == "" || (href.Scheme != "http" && href.Scheme != "https") {
+				continue
+			}
+
+			rels := strings.Fields(strings.ToLower(attrs["rel"]))
+			for _, rel := range rels {
+				switch rel {
+				case "icon", "apple-touch-icon", "apple-touch-icon-precomposed":
+					candidate := newFaviconCandidate(href.String(), attrs["sizes"], attrs["type"])
+					// apple touch icons without sizes are 180x180 more often than not
+					if candidate.size == 0 && rel != "icon" {
+						candidate.size = 180
+					}
+					candidates = append(candidates, candidate)
+				case "manifest":
+					if len(manifests) < maxFaviconManifests {
+						manifests = append(manifests, href.String())
+					}
+				}
+			}
+		}
+	}
+
+	for _, manifest := range manifests {
+		icons, err := f.manifestIcons(ctx, manifest)
+		if err != nil {
+			continue
+		}
+
+		candidates = append(candidates, icons...)
+	}
+
+	return candidates, resp.Request.URL, nil
+}
+
+func (f *FaviconFinder) manifestIcons(ctx context.Context, manifestURL string) ([]faviconCandidate, error) {
+	resp, err := f.get(ctx, manifestURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var manifest struct {
+		Icons []struct {
+			Src     string `json:"src"`
+			Sizes   string `json:"sizes"`
+			Type    string `json:"type"`
+			Purpose string `json:"purpose"`
+		} `json:"icons"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1024*1024)).Decode(&manifest); err != nil {
+		return nil, err
+	}
+
+	base := resp.Request.URL
+	var icons []faviconCandidate
+	for _, icon := range manifest.Icons {
+		// monochrome icons are a single flat color, which makes for a poor link icon
+		if icon.Purpose != "" && !strings.Contains(icon.Purpose, "any") && !strings.Contains(icon.Purpose, "maskable") {
+			continue
+		}
+
+		src, err := base.Parse(icon.Src)
+		if err != nil || icon.Src == "" || (src.Scheme != "http" && src.Scheme != "https") {
+			continue
+		}
+
+		icons = append(icons, newFaviconCandidate(src.String(), icon.Sizes, icon.Type))
+	}
+
+	return icons, nil
+}
+
+func newFaviconCandidate(iconURL string, sizes string, contentType string) faviconCandidate {
+	candidate := faviconCandidate{url: iconURL}
+
+	for _, size := range strings.Fields(strings.ToLower(sizes)) {
+		if size == "any" {
+			candidate.scalable = true
+			continue
+		}
+
+		width, _, found := strings.Cut(size, "x")
+		if !found {
+			continue
+		}
+
+		if n, err := strconv.Atoi(width); err == nil && n > candidate.size {
+			candidate.size = n
+		}
+	}
+
+	if contentType == "image/svg+xml" || strings.EqualFold(path.Ext(strings.SplitN(iconURL, "?", 2)[0]), ".svg") {
+		candidate.scalable = true
+	}
+
+	return candidate
+}
+
+// rankFavicons orders candidates from best to worst, SVGs first and then the largest bitmaps
+func rankFavicons(candidates []faviconCandidate) []faviconCandidate {
+	ranked := slices.Clone(candidates)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].scalable != ranked[j].scalable {
+			return ranked[i].scalable
+		}
+
+		return ranked[i].size > ranked[j].size
+	})
+
+	return ranked
+}
+
+func (f *FaviconFinder) get(ctx context.Context, target string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Passport icon fetcher")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("server responded with %s", resp.Status)
+	}
+
+	return resp, nil
+}
+
+// Download fetches the icon at iconURL itself, for when the address of the icon is known rather than the page
+func (f *FaviconFinder) Download(ctx context.Context, iconURL string) (*Favicon, error) {
+	return f.download(ctx, iconURL)
+}
+
+// download fetches an icon and makes sure it is in a format the upload pipeline understands
+func (f *FaviconFinder) download(ctx context.Context, iconURL string) (*Favicon, error) {
+	resp, err := f.get(ctx, iconURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFaviconSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > maxFaviconSize {
+		return nil, errors.New("icon is too large")
+	}
+
+	data, contentType, err := ConvertIcon(data, resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Favicon{Data: data, ContentType: contentType, URL: iconURL}, nil
+}
+
+// ConvertIcon checks that an icon is an image the upload pipeline can use, converting ICO and GIF files to PNG, and
+// returns it along with its actual content type
+func ConvertIcon(data []byte, contentType string) ([]byte, string, error) {
+	if len(data) == 0 {
+		return nil, "", errors.New("icon is empty")
+	}
+
+	contentType = strings.ToLower(contentType)
+	if strings.HasPrefix(contentType, "image/svg+xml") || (!strings.HasPrefix(contentType, "image/") && bytes.Contains(data[:min(len(data), 1024)], []byte("<svg"))) {
+		if !bytes.Contains(data, []byte("<svg")) {
+			return nil, "", errors.New("icon is not a valid SVG")
+		}
+
+		if unsafeSVGPattern.Match(data) {
+			return nil, "", errors.New("icon is an SVG with scripts")
+		}
+
+		return data, "image/svg+xml", nil
+	}
+
+	if bytes.HasPrefix(data, []byte{0, 0, 1, 0}) {
+		var err error
+		data, err = decodeICO(data)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch format {
+	case "png", "jpeg", "webp":
+		return data, "image/" + format, nil
+	case "gif":
+		img, err := gif.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, "", err
+		}
+
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", err
+		}
+
+		return buf.Bytes(), "image/png", nil
+	}
+
+	return nil, "", fmt.Errorf("unsupported icon format %s", format)
+}
+
+// decodeICO picks the largest image out of an ICO file and returns it as a PNG
+func decodeICO(data []byte) ([]byte, error) {
+	if len(data) < 6 {
+		return nil, errors.New("icon is not a valid ICO file")
+	}
+
+	count := int(binary.LittleEndian.Uint16(data[4:6]))
+	if len(data) < 6+count*16 {
+		return nil, errors.New("icon is not a valid ICO file")
+	}
+
+	var best []byte
+	bestSize, bestDepth := -1, -1
+	for i := range count {
+		entry := data[6+i*16 : 6+(i+1)*16]
+
+		// a width of 0 means 256
+		size := int(entry[0])
+		if size == 0 {
+			size = 256
+		}
+		depth := int(binary.LittleEndian.Uint16(entry[6:8]))
+		length := int(binary.LittleEndian.Uint32(entry[8:12]))
+		offset := int(binary.LittleEndian.Uint32(entry[12:16]))
+
+		if offset < 0 || length <= 0 || offset+length > len(data) {
+			continue
+		}
+
+		if size > bestSize || (size == bestSize && depth > bestDepth) {
+			best = data[offset : offset+length]
+			bestSize, bestDepth = size, depth
+		}
+	}
+
+	if best == nil {
+		return nil, errors.New("icon is not a valid ICO file")
+	}
+
+	// modern ICO files usually just wrap a PNG
+	if bytes.HasPrefix(best, []byte("\x89PNG\r\n\x1a\n")) {
+		return best, nil
+	}
+
+	img, err := decodeDIB(best)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decodeDIB decodes a bitmap stored in an ICO file. These are BMPs without the file header, and with the height
+// doubled to make room for a transparency mask after the pixels
+func decodeDIB(dib []byte) (image.Image, error) {
+	if len(dib) < 40 {
+		return nil, errors.New("icon is not a valid bitmap")
+	}
+
+	headerSize := int(binary.LittleEndian.Uint32(dib[0:4]))
+	width := int(int32(binary.LittleEndian.Uint32(dib[4:8])))
+	height := int(int32(binary.LittleEndian.Uint32(dib[8:12]))) / 2
+	depth := int(binary.LittleEndian.Uint16(dib[14:16]))
+	compression := binary.LittleEndian.Uint32(dib[16:20])
+
+	if headerSize < 40 || headerSize > len(dib) || width <= 0 || height <= 0 || width > 256 || height > 256 {
+		return nil, errors.New("icon is not a valid bitmap")
+	}
+
+	// the bmp package ignores the alpha channel of 32 bit images, which is what makes icons look right
+	if depth == 32 && compression == 0 {
+		pixels := dib[headerSize:]
+		if len(pixels) < width*height*4 {
+			return nil, errors.New("icon is not a valid bitmap")
+		}
+
+		img := image.NewNRGBA(image.Rect(0, 0, width, height))
+		for y := range height {
+			// rows are stored bottom up
+			row := pixels[(height-1-y)*width*4:]
+			for x := range width {
+				b, g, r, a := row[x*4], row[x*4+1], row[x*4+2], row[x*4+3]
+				img.SetNRGBA(x, y, color.NRGBA{R: r, G: g, B: b, A: a})
+			}
+		}
+
+		return img, nil
+	}
+
+	colors := 0
+	if depth <= 8 {
+		colors = int(binary.LittleEndian.Uint32(dib[32:36]))
+		if colors == 0 {
+			colors = 1 << depth
+		}
+	}
+
+	// give the bitmap the file header and real height it would have as a .bmp
+	info := bytes.Clone(dib)
+	binary.LittleEndian.PutUint32(info[8:12], uint32(height))
+
+	fileHeader := make([]byte, 14)
+	copy(fileHeader, "BM")
+	binary.LittleEndian.PutUint32(fileHeader[2:6], uint32(14+len(info)))
+	binary.LittleEndian.PutUint32(fileHeader[10:14], uint32(14+headerSize+colors*4))
+
+	return bmp.Decode(io.MultiReader(bytes.NewReader(fileHeader), bytes.NewReader(info)))
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+type HealthConfig struct {
+	// seconds between checking every link
+	Interval int `env:"PASSPORT_HEALTH_CHECK_INTERVAL" envDefault:"300"`
+	// seconds to wait for a link to respond before it is considered down
+	Timeout int `env:"PASSPORT_HEALTH_CHECK_TIMEOUT" envDefault:"10"`
+	// how many links are checked at the same time
+	Concurrency int `env:"PASSPORT_HEALTH_CHECK_CONCURRENCY" envDefault:"4"`
+}
+
+type HealthTarget struct {
+	ID  int64
+	URL string
+}
+
+type HealthResult struct {
+	ID int64
+	// the HTTP status of the response, 0 if there was no response
+	Status  int
+	Latency time.Duration
+	// why there was no response, empty if there was one
+	Error     string
+	CheckedAt time.Time
+}
+
+type HealthChecker struct {
+	client      *http.Client
+	concurrency int
+}
+
+func NewHealthChecker(config *HealthConfig) *HealthChecker {
+	timeout := config.Timeout
+	if timeout < 1 {
+		timeout = 10
+	}
+
+	concurrency := config.Concurrency
+	if concurrency < 1 {
+		concurrency = 4
+	}
+
+	return &HealthChecker{
+		client: &http.Client{
+			Timeout: time.Duration(timeout) * time.Second,
+		},
+		concurrency: concurrency,
+	}
+}
+
+// CheckAll checks every target, at most concurrency at a time, and returns the results in the same order
+func (h *HealthChecker) CheckAll(targets []HealthTarget) []HealthResult {
+	results := make([]HealthResult, len(targets))
+	semaphore := make(chan struct{}, h.concurrency)
+
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			results[i] = h.check(target)
+		}()
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+func (h *HealthChecker) check(target HealthTarget) HealthResult {
+	result := HealthResult{ID: target.ID, CheckedAt: time.Now()}
+
+	// HEAD is enough to know a site is up, but plenty of servers dont implement it
+	status, err := h.request(http.MethodHead, target.URL)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = h.request(http.MethodGet, target.URL)
+	}
+
+	result.Latency = time.Since(result.CheckedAt)
+
+	if err != nil {
+		result.Error = describeError(err)
+		return result
+	}
+
+	result.Status = status
+	return result
+}
+
+func (h *HealthChecker) request(method string, target string) (int, error) {
+	req, err := http.NewRequest(method, target, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", "Passport link checker")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// read a little of the body so the connection can be reused, without downloading whole pages
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	return resp.StatusCode, nil
+}
+
+func describeError(err error) string {
+	var certErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+
+	switch {
+	case errors.As(err, &certErr):
+		return fmt.Sprintf("TLS certificate error: %v", certErr.Err)
+	case errors.As(err, &recordErr):
+		return "TLS error: the server did not respond with TLS"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "Timed out"
+	}
+
+	var timeoutErr interface{ Timeout() bool }
+	if errors.As(err, &timeoutErr) && timeoutErr.Timeout() {
+		return "Timed out"
+	}
+
+	// the request method and url are already known, so leave them out
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err.Error()
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+type PageMetadata struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+type MetadataFetcher struct {
+	client *http.Client
+}
+
+func NewMetadataFetcher() *MetadataFetcher {
+	return &MetadataFetcher{
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Fetch reads the title and description of a page, preferring its Open Graph tags since they are written to be shown
+// out of context, while <title> often has the site name tacked on
+func (m *MetadataFetcher) Fetch(pageURL string) (*PageMetadata, error) {
+	req, err := http.NewRequest(http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Passport metadata fetcher")
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, errors.New(describeError(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("server responded with %s", resp.Status)
+	}
+
+	body, err := charset.NewReader(io.LimitReader(resp.Body, 2*1024*1024), resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+
+	var title, ogTitle, description, ogDescription string
+	inTitle := false
+
+	tokenizer := html.NewTokenizer(body)
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+
+		token := tokenizer.Token()
+		if tokenType == html.TextToken && inTitle {
+			title += token.Data
+			continue
+		}
+
+		if tokenType == html.EndTagToken && token.Data == "title" {
+			inTitle = false
+			continue
+		}
+
+		// everything we are after is in the head, so dont read the rest of the page
+		if (tokenType == html.EndTagToken && token.Data == "head") || (tokenType == html.StartTagToken && token.Data == "body") {
+			break
+		}
+
+		if tokenType != html.StartTagToken && tokenType != html.SelfClosingTagToken {
+			continue
+		}
+
+		if token.Data == "title" && title == "" {
+			inTitle = tokenType == html.StartTagToken
+			continue
+		}
+
+		if token.Data != "meta" {
+			continue
+		}
+
+		var name, content string
+		for _, attr := range token.Attr {
+			switch attr.Key {
+			case "name", "property":
+				name = strings.ToLower(attr.Val)
+			case "content":
+				content = attr.Val
+			}
+		}
+
+		switch name {
+		case "og:title":
+			ogTitle = content
+		case "og:description":
+			ogDescription = content
+		case "description":
+			description = content
+		}
+	}
+
+	metadata := &PageMetadata{
+		Title:       collapseSpace(title),
+		Description: collapseSpace(description),
+	}
+
+	if ogTitle := collapseSpace(ogTitle); ogTitle != "" {
+		metadata.Title = ogTitle
+	}
+
+	if ogDescription := collapseSpace(ogDescription); ogDescription != "" {
+		metadata.Description = ogDescription
+	}
+
+	return metadata, nil
+}
+
+// collapseSpace trims a string and replaces every run of whitespace in it with a single space
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// suggestions are asked for on every key press, so a slow provider is given up on quickly
+	suggestionTimeout = 2 * time.Second
+	suggestionTTL     = 5 * time.Minute
+	// a provider that failed is left alone for a bit instead of being waited on again for every letter typed
+	suggestionFailureTTL = 30 * time.Second
+	maxCachedSuggestions = 1000
+)
+
+type cachedSuggestions struct {
+	suggestions []string
+	err         error
+	expires     time.Time
+}
+
+// SuggestionFetcher fetches search suggestions in the OpenSearch suggestions format, which most search engines serve,
+// and keeps them for a few minutes since the same few letters get typed over and over. Requests for the same URL that
+// come in while it is being fetched wait for that fetch instead of starting their own
+type SuggestionFetcher struct {
+	client   *http.Client
+	mutex    sync.Mutex
+	cache    map[string]cachedSuggestions
+	inFlight singleflight.Group
+}
+
+func NewSuggestionFetcher() *SuggestionFetcher {
+	return &SuggestionFetcher{
+		client: &http.Client{
+			Timeout: suggestionTimeout,
+		},
+		cache: map[string]cachedSuggestions{},
+	}
+}
+
+// Fetch returns the suggestions served at suggestURL, the suggestion URL of a search provider with the search terms
+// already in it
+func (s *SuggestionFetcher) Fetch(suggestURL string) ([]string, error) {
+	s.mutex.Lock()
+	cached, ok := s.cache[suggestURL]
+	s.mutex.Unlock()
+
+	if ok && time.Now().Before(cached.expires) {
+		return cached.suggestions, cached.err
+	}
+
+	fetched, _, _ := s.inFlight.Do(suggestURL, func() (any, error) {
+		suggestions, err := s.fetch(suggestURL)
+
+		ttl := suggestionTTL
+		if err != nil {
+			ttl = suggestionFailureTTL
+		}
+
+		cached := cachedSuggestions{suggestions: suggestions, err: err, expires: time.Now().Add(ttl)}
+
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if len(s.cache) >= maxCachedSuggestions {
+			s.prune()
+		}
+		s.cache[suggestURL] = cached
+
+		return cached, nil
+	})
+
+	cached = fetched.(cachedSuggestions)
+	return cached.suggestions, cached.err
+}
+
+func (s *SuggestionFetcher) fetch(suggestURL string) ([]string, error) {
+	req, err := http.NewRequest(http.MethodGet, suggestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Passport suggestion fetcher")
+	req.Header.Set("Accept", "application/x-suggestions+json, application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, errors.New(describeError(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("server responded with %s", resp.Status)
+	}
+
+	// some providers still answer in latin-1 unless asked otherwise
+	body, err := charset.NewReader(io.LimitReader(resp.Body, 256*1024), resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+
+	// the format is ["query", ["suggestion", ...], ...], anything after the suggestions is optional and unused
+	var response []json.RawMessage
+	if err := json.NewDecoder(body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("response is not in the OpenSearch suggestions format: %w", err)
+	}
+
+	if len(response) < 2 {
+		return nil, errors.New("response is not in the OpenSearch suggestions format")
+	}
+
+	var suggestions []string
+	if err := json.Unmarshal(response[1], &suggestions); err != nil {
+		return nil, fmt.Errorf("response is not in the OpenSearch suggestions format: %w", err)
+	}
+
+	return suggestions, nil
+}
+
+// prune drops the expired suggestions, or all of them if none have expired, so the cache cannot keep growing. The mutex
+// has to be held
+func (s *SuggestionFetcher) prune() {
+	now := time.Now()
+	for key, cached := range s.cache {
+		if now.After(cached.expires) {
+			delete(s.cache, key)
+		}
+	}
+
+	if len(s.cache) >= maxCachedSuggestions {
+		clear(s.cache)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/juls0730/passport/src/audit"
+)
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    []string
+		wantErr bool
+	}{
+		{raw: "", want: []string{}},
+		{raw: " , ,", want: []string{}},
+		{raw: "media", want: []string{"media"}},
+		{raw: "Media, Video", want: []string{"media", "video"}},
+		{raw: "  home   lab ,media", want: []string{"home lab", "media"}},
+		{raw: "media, MEDIA, media ", want: []string{"media"}},
+		{raw: strings.Repeat("a", 30), want: []string{strings.Repeat("a", 30)}},
+		{raw: strings.Repeat("a", 31), wantErr: true},
+		{raw: "a,b,c,d,e,f,g,h,i,j", want: []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}},
+		{raw: "a,b,c,d,e,f,g,h,i,j,k", wantErr: true},
+		// duplicates do not count towards the limit
+		{raw: "a,b,c,d,e,f,g,h,i,j,a", want: []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.raw, func(t *testing.T) {
+			got, err := ParseTags(test.raw)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ParseTags(%q) error = %v, want error %v", test.raw, err, test.wantErr)
+			}
+
+			if !test.wantErr && !slices.Equal(got, test.want) {
+				t.Errorf("ParseTags(%q) = %q, want %q", test.raw, got, test.want)
+			}
+		})
+	}
+}
+
+func TestOrphanedTagsAreDeleted(t *testing.T) {
+	tests := []struct {
+		name   string
+		delete func(t *testing.T, manager *CategoryManager, category *Category, link *Link)
+		want   []string
+	}{
+		{
+			name: "link in the trash",
+			delete: func(t *testing.T, manager *CategoryManager, category *Category, link *Link) {
+				trashTestLink(t, manager, link)
+			},
+			want: []string{"lang", "shared"},
+		},
+		{
+			name: "link purged",
+			delete: func(t *testing.T, manager *CategoryManager, category *Category, link *Link) {
+				trashTestLink(t, manager, link)
+				if err := manager.PurgeLink(audit.Actor{}, link.ID); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: []string{"shared"},
+		},
+		{
+			name: "category purged",
+			delete: func(t *testing.T, manager *CategoryManager, category *Category, link *Link) {
+				if err := manager.DeleteCategory(audit.Actor{}, category.ID); err != nil {
+					t.Fatal(err)
+				}
+				if err := manager.PurgeCategory(audit.Actor{}, category.ID); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: []string{"shared"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			manager := newTestManager(t)
+			board := manager.GetDefaultBoard()
+
+			category := createTestCategory(t, manager, board.ID, "Dev")
+			link := createTestLink(t, manager, Link{CategoryID: category.ID, Name: "Go", URL: "https://go.dev", Tags: []string{"lang", "shared"}})
+
+			other := createTestCategory(t, manager, board.ID, "News")
+			createTestLink(t, manager, Link{CategoryID: other.ID, Name: "Feed", URL: "https://feed.example", Tags: []string{"shared"}})
+
+			test.delete(t, manager, category, link)
+
+			got, err := queryTags(manager)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(got, test.want) {
+				t.Errorf("tags = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func trashTestLink(t *testing.T, manager *CategoryManager, link *Link) {
+	t.Helper()
+
+	if err := manager.DeleteLink(audit.Actor{}, link.ID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func queryTags(manager *CategoryManager) ([]string, error) {
+	rows, err := manager.db.Query(`SELECT name FROM tags ORDER BY name ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tags = append(tags, name)
+	}
+
+	return tags, rows.Err()
+}